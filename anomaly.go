@@ -0,0 +1,154 @@
+package applogger
+
+import (
+	"sync"
+	"time"
+)
+
+// Anomaly describes a window whose error rate deviated from the baseline
+type Anomaly struct {
+	Start    time.Time
+	End      time.Time
+	Errors   int
+	Total    int
+	Rate     float64
+	Baseline float64
+}
+
+// ErrorRateAnalyzer is a sink that counts Error and Fatal entries in
+// consecutive windows and reports a window whose error rate is Factor times
+// above or below the average of the previous Baseline windows, for example
+// to watch a canary deployment. Add it to the Sinks of the logger it
+// watches.
+type ErrorRateAnalyzer struct {
+	// Window is the length of a window, one minute when zero
+	Window time.Duration
+	// Baseline is the number of previous windows averaged, 10 when zero
+	Baseline int
+	// Factor is the deviation that counts as an anomaly, 3 when zero
+	Factor float64
+	// MinEntries ignores windows with fewer entries, they are too small to
+	// say anything about the rate
+	MinEntries int
+	// OnAnomaly is called for every anomaly
+	OnAnomaly func(a Anomaly)
+	// Logger, when set, gets a WARN meta-entry for every anomaly
+	Logger *AppLogger
+
+	mu      sync.Mutex
+	now     func() time.Time
+	start   time.Time
+	errors  int
+	total   int
+	history []float64
+}
+
+// Write counts the entry in the current window
+func (a *ErrorRateAnalyzer) Write(e Entry) error {
+	a.mu.Lock()
+	anomalies := a.roll(a.clock())
+	a.total++
+	if levelOf(e.Level) >= LevelError {
+		a.errors++
+	}
+	a.mu.Unlock()
+
+	for _, an := range anomalies {
+		a.report(an)
+	}
+	return nil
+}
+
+// Close does nothing
+func (a *ErrorRateAnalyzer) Close() error {
+	return nil
+}
+
+func (a *ErrorRateAnalyzer) clock() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now()
+}
+
+// roll closes the windows that ended before now and returns the anomalies
+// found in them
+func (a *ErrorRateAnalyzer) roll(now time.Time) []Anomaly {
+	window := a.Window
+	if window <= 0 {
+		window = time.Minute
+	}
+	if a.start.IsZero() {
+		a.start = now
+		return nil
+	}
+
+	var anomalies []Anomaly
+	for !now.Before(a.start.Add(window)) {
+		if an, ok := a.closeWindow(window); ok {
+			anomalies = append(anomalies, an)
+		}
+		a.start = a.start.Add(window)
+		a.errors, a.total = 0, 0
+		if now.Sub(a.start) > window*time.Duration(a.baseline()+1) {
+			// idle for longer than the baseline, skip the empty windows
+			a.start = now
+		}
+	}
+	return anomalies
+}
+
+func (a *ErrorRateAnalyzer) baseline() int {
+	if a.Baseline <= 0 {
+		return 10
+	}
+	return a.Baseline
+}
+
+// closeWindow compares the window with the baseline and adds it to it
+func (a *ErrorRateAnalyzer) closeWindow(window time.Duration) (Anomaly, bool) {
+	if a.total == 0 || a.total < a.MinEntries {
+		return Anomaly{}, false
+	}
+	factor := a.Factor
+	if factor <= 0 {
+		factor = 3
+	}
+
+	rate := float64(a.errors) / float64(a.total)
+	an := Anomaly{Start: a.start, End: a.start.Add(window), Errors: a.errors, Total: a.total, Rate: rate}
+	found := false
+	if len(a.history) == a.baseline() {
+		for _, r := range a.history {
+			an.Baseline += r
+		}
+		an.Baseline /= float64(len(a.history))
+		if an.Baseline == 0 {
+			found = rate > 0
+		} else {
+			found = rate >= an.Baseline*factor || rate*factor <= an.Baseline
+		}
+	}
+
+	a.history = append(a.history, rate)
+	if len(a.history) > a.baseline() {
+		a.history = a.history[1:]
+	}
+	return an, found
+}
+
+func (a *ErrorRateAnalyzer) report(an Anomaly) {
+	if a.OnAnomaly != nil {
+		a.OnAnomaly(an)
+	}
+	if a.Logger != nil {
+		a.Logger.LogFields("WARN", "applogger", "ErrorRateAnalyzer", "error rate anomaly", map[string]interface{}{
+			"window_start":  an.Start,
+			"window_end":    an.End,
+			"errors":        an.Errors,
+			"total":         an.Total,
+			"error_rate":    an.Rate,
+			"baseline_rate": an.Baseline,
+		})
+	}
+}
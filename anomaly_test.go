@@ -0,0 +1,73 @@
+package applogger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestErrorRateAnalyzer(t *testing.T) {
+	now := time.Date(2020, 8, 23, 10, 0, 0, 0, time.UTC)
+	var anomalies []Anomaly
+	a := &ErrorRateAnalyzer{Window: time.Minute, Baseline: 3, Factor: 3, MinEntries: 10, OnAnomaly: func(an Anomaly) {
+		anomalies = append(anomalies, an)
+	}}
+	a.now = func() time.Time { return now }
+
+	window := func(errors, total int) {
+		for i := 0; i < total; i++ {
+			level := "INFO"
+			if i < errors {
+				level = "ERROR"
+			}
+			a.Write(Entry{Level: level})
+		}
+		now = now.Add(time.Minute)
+	}
+
+	// three windows at 10% build the baseline
+	window(1, 10)
+	window(1, 10)
+	window(1, 10)
+	// 20% is within the factor, 50% is not
+	window(2, 10)
+	window(5, 10)
+	// the window with 50% is only closed by the next entry
+	a.Write(Entry{Level: "INFO"})
+
+	if len(anomalies) != 1 {
+		t.Fatalf("expected 1 anomaly, got %v", anomalies)
+	}
+	an := anomalies[0]
+	if an.Errors != 5 || an.Total != 10 || an.Rate != 0.5 {
+		t.Fatalf("unexpected anomaly %+v", an)
+	}
+	if an.Baseline < 0.13 || an.Baseline > 0.14 {
+		t.Fatalf("unexpected baseline %v", an.Baseline)
+	}
+}
+
+func TestErrorRateAnalyzerMetaEntry(t *testing.T) {
+	now := time.Date(2020, 8, 23, 10, 0, 0, 0, time.UTC)
+	mem := &memorySink{}
+	a := &ErrorRateAnalyzer{Baseline: 1}
+	a.now = func() time.Time { return now }
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{a, mem}}
+	logger.Initialise()
+	a.Logger = &logger
+
+	logger.Log("INFO", "main", "app", "fine")
+	now = now.Add(time.Minute)
+	logger.Log("ERROR", "main", "app", "broken")
+	now = now.Add(time.Minute)
+	logger.Log("INFO", "main", "app", "closes the window")
+
+	// the meta-entry is written while the entry closing the window is fanned
+	// out, so it arrives just before it
+	if len(mem.entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(mem.entries))
+	}
+	meta := mem.entries[2]
+	if meta.Message != "error rate anomaly" || meta.Attributes["error_rate"] != 1.0 {
+		t.Fatalf("expected a meta-entry, got %+v", meta)
+	}
+}
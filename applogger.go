@@ -1,22 +1,251 @@
 package applogger
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"time"
-
-	"github.com/gofrs/uuid"
-)
-
-var (
-	generalLogger *log.Logger
-	errorLogger   *log.Logger
 )
 
+// AppLogger writes ndjson entries to the file at Path, or to stdout when
+// Path is empty
 type AppLogger struct {
 	Path string
+	// Writer replaces the file at Path as the main output, e.g. a
+	// bytes.Buffer in tests or a network connection. It is not closed.
+	Writer io.Writer
+	// Format selects the output encoding, by default console-pretty on a
+	// terminal and NDJSON everywhere else
+	Format Format
+	// ConsoleDiff shows, with FormatConsole, what changed between the
+	// entries of a request, see ConsoleDiff
+	ConsoleDiff *ConsoleDiff
+	// Resource holds the resource attributes, like service.name, written
+	// with FormatOTel
+	Resource map[string]interface{}
+	// GCPProject qualifies trace IDs with FormatGCP, GOOGLE_CLOUD_PROJECT
+	// when empty
+	GCPProject string
+	// Sinks receive every entry in addition to the main output
+	Sinks []Sink
+	// Routes are sinks by name that only receive the entries sent to them
+	// with To or OnlyTo, e.g. a locked-down destination for security events
+	Routes map[string]Sink
+	// BufferSize enables buffering of the output with a buffer of that many
+	// bytes, FlushInterval (DefaultFlushInterval when zero, never when
+	// negative) bounds how long an entry can stay in the buffer
+	BufferSize    int
+	FlushInterval time.Duration
+	// Async moves encoding and writing to a background goroutine, Log
+	// only enqueues the entry. Close writes out what is still queued.
+	Async bool
+	// HighWaterMark turns on load shedding in async mode: while at least
+	// that many entries are queued, entries below ShedLevel (by default
+	// Debug and Trace) are dropped and counted, see Dropped
+	HighWaterMark int
+	ShedLevel     LogLevel
+	// WriteDeadline bounds the time a synchronous Log call may spend
+	// writing, so a hung disk or NFS mount cannot block request handlers.
+	// A slower write goes on in the background while the call returns,
+	// entries logged while it is stuck are dropped after waiting
+	// WriteDeadline, see Dropped. Ignored in async mode.
+	WriteDeadline time.Duration
+	// DurableAudit makes logging an entry of the RetentionAudit class return
+	// only once it is synced to disk, for entries with legal retention
+	// requirements. Concurrent callers share one fsync, which waits up to
+	// SyncWindow to gather more of them. It needs a Path and cannot be
+	// combined with Async or WriteDeadline.
+	DurableAudit bool
+	SyncWindow   time.Duration
+	// RotateEvery cuts the file at Path every period (e.g. time.Hour or
+	// 24 * time.Hour), aligned to midnight in RotateLocation (UTC when
+	// nil). The old file is renamed after the start of its period.
+	RotateEvery    time.Duration
+	RotateLocation *time.Location
+	// MaxSize cuts the file at Path before a write would take it over that
+	// many bytes (counted before compression). The old file becomes
+	// app.1.ndjson, older ones move up to app.2.ndjson and so on. It
+	// cannot be combined with RotateEvery, CopyTruncate or SharedFile.
+	MaxSize int64
+	// CompressRotated gzips every file cut by RotateEvery or MaxSize, in
+	// the background, to app.1.ndjson.gz. Rotated files older than MaxAge
+	// and, oldest first, those over MaxBackups are removed, all are kept
+	// when zero. Files left by earlier runs are compressed and removed on
+	// start.
+	CompressRotated bool
+	MaxAge          time.Duration
+	MaxBackups      int
+	// OnRotate is called with the path of every rotated file, on its own
+	// goroutine so uploading or indexing it never blocks logging. A panic
+	// in OnRotate is reported on stderr. Close waits for running hooks.
+	OnRotate func(path string)
+	// CopyTruncate keeps the logger correct when an external tool rotates
+	// the file at Path, either with logrotate's copytruncate or by moving
+	// the file away, by checking the file at most once a second. To switch
+	// files right away call Reopen, or set ReopenSignal, instead.
+	CopyTruncate bool
+	// SharedFile makes it safe for several processes to append to the same
+	// file: every entry reaches the file in a single O_APPEND write, even
+	// when buffered. Entries up to MaxAtomicEntrySize do not interleave,
+	// FileLock adds an advisory flock around each write for larger entries
+	// and network filesystems. Rotation is not coordinated across processes.
+	SharedFile bool
+	FileLock   bool
+	// ContextDeadline records, for entries logged with a context that has
+	// a deadline, the time left in milliseconds as ctx_deadline_ms
+	ContextDeadline bool
+	// IndexEvery writes a Path+".idx" sidecar with the time and byte
+	// offset of every IndexEvery-th entry, so readers can jump to a time
+	// range with IndexOffset instead of scanning the whole file
+	IndexEvery int
+	// Development turns programmer errors, like logging after Close or
+	// attributes that cannot be serialized, into a panic with a
+	// *MisuseError instead of silently degrading the entry
+	Development bool
+	// Lifecycle writes a startup entry with a summary of the configuration,
+	// the build and the host when the logger is opened and a shutdown entry
+	// with the uptime, the number of entries and errors and the drop count
+	// on Close
+	Lifecycle bool
+	// GoroutineDump adds the stacks of all goroutines to every FATAL entry
+	// as goroutines, or appends them to GoroutineDumpPath and records that
+	// path instead, so the state that led to the exit is not lost
+	GoroutineDump     bool
+	GoroutineDumpPath string
+	// ErrorStack adds the stack of the logging goroutine to Error entries as
+	// stack. Within StackDedupWindow an identical stack is written only
+	// once, later entries get the pid of that entry as stack_ref.
+	ErrorStack       bool
+	StackDedupWindow time.Duration
+	// Caller adds the file and line of the call that wrote the entry as
+	// caller, e.g. "payments/charge.go:42"
+	Caller bool
+	// DiagnosticSignal writes a diagnostic dump, see Diagnostics, whenever
+	// the process receives SIGUSR1. RecentErrors is how many recent errors
+	// it includes, DefaultRecentErrors when zero.
+	DiagnosticSignal bool
+	RecentErrors     int
+	// ReopenSignal calls Reopen whenever the process receives SIGHUP, as
+	// sent by the postrotate script of logrotate
+	ReopenSignal bool
+	// Scrub rewrites the values of attributes by key before anything else
+	// looks at them, e.g. {"sql": TruncateString(500)}, for the default
+	// fields of WithFields as well as the attributes of each call
+	Scrub map[string]Scrubber
+	// MaxAttributes caps the attributes of an entry, those over it are
+	// dropped and counted under _overflow_count, so a pathological map with
+	// thousands of keys cannot flood the outputs. The cap applies once the
+	// pipeline is done: the attributes it adds (caller, stack, goroutines,
+	// retention, _schema_violations, _marshal_error and _quota_dropped)
+	// count towards it and are always kept. Zero means no limit.
+	MaxAttributes int
+	// Processors are the steps added to the pipeline of every entry, see
+	// Stage for the order they run in
+	Processors []Processor
+	// Schema checks the attributes of every entry, see Schema
+	Schema *Schema
+	// StrictSerialization removes attributes that cannot be serialized,
+	// like channels and funcs, and lists them under _marshal_error instead
+	// of failing the whole entry
+	StrictSerialization bool
+	// Checksum ends every NDJSON line with a checksum of the rest of the
+	// line, so consumers can detect corrupted lines with VerifyChecksum
+	Checksum Checksum
+	// Compress writes the file at Path gzip compressed (name it .ndjson.gz),
+	// for archives only ever read by machines. Every flush, at least every
+	// FlushInterval, ends a gzip member so the file can be read up to the
+	// last flush even if the process dies. The output is buffered with
+	// DefaultCompressBufferSize unless BufferSize is set. It cannot be
+	// combined with SharedFile, FileLock, CopyTruncate or IndexEvery.
+	Compress bool
+	// ErrorPath also writes Error and Fatal entries to their own file, for
+	// example app.error.log next to app.log. With SplitErrors they are
+	// only written there and not to the main output.
+	ErrorPath   string
+	SplitErrors bool
+	// ErrorsToStderr writes Error and Fatal entries to stderr, as 12-factor
+	// apps do, instead of stdout when that is the main output and in
+	// addition to the file at Path or Writer otherwise. Sinks still get
+	// every entry. It cannot be combined with ErrorPath.
+	ErrorsToStderr bool
+	// DiskBudget caps the bytes used by the files at Path and ErrorPath
+	// together with their rotated files and index sidecars. Checked every DiskBudgetInterval,
+	// the oldest rotated files are removed to get under it and, when that
+	// is not enough, Debug and Trace entries are dropped (see Dropped)
+	// until usage is back under the budget.
+	DiskBudget int64
+	// Levels is the minimum level per package, keyed by the package given
+	// to Log, with "" as the default. A package also covers its children
+	// (github.com/acme/svc covers github.com/acme/svc/payments). When nil
+	// it is read from APPLOGGER_LEVELS, see ParseLevels. SetLevel changes
+	// the default while the logger runs.
+	Levels map[string]LogLevel
+	// Clock replaces time.Now for the time of entries, for tests
+	Clock func() time.Time
+	// EntryIDs generates the pid of entries, UUIDv4 by default
+	EntryIDs IDGenerator
+	// Retention is the retention class, e.g. RetentionStandard, written as
+	// retention on entries that have none from WithFields, the call or
+	// Rules
+	Retention string
+	// RetentionPeriods removes the rotated files of Path once they are
+	// older than the period of every retention class they hold, e.g.
+	// {"short": 24 * time.Hour, "standard": 720 * time.Hour}. Entries without a
+	// class count as RetentionStandard and files holding a class without a
+	// period, like audit, are kept.
+	RetentionPeriods map[string]time.Duration
+	// TenantKey names the attribute holding the tenant of an entry, e.g.
+	// tenant_id from baggage. With TenantQuota every tenant may write that
+	// many entries a second, in bursts of up to TenantBurst (TenantQuota by
+	// default), so one noisy tenant does not crowd out the others. Entries
+	// over the quota are dropped (see Dropped and TenantDropped) and the
+	// next entry of the tenant carries their count as _quota_dropped.
+	// Error and Fatal entries and entries without a tenant are never
+	// dropped.
+	TenantKey   string
+	TenantQuota float64
+	TenantBurst int
+	// Rules decide per entry which routes get it, whether it is sampled or
+	// dropped and which attributes are redacted, see Rule
+	Rules []Rule
+	// Escalations raise the level of entries by attribute, see Escalation
+	Escalations []Escalation
+	// ErrorClassifiers pick the level of the errors given to LogError, the
+	// first one that matches wins, see DefaultErrorLevel for the rest
+	ErrorClassifiers []ErrorClassifier
+
+	generalLogger *log.Logger
+	out           *fileWriter
+	errOut        *fileWriter
+	budget        *diskBudget
+	retention     *retentionSweeper
+	outputs       *outputSet
+	async         *asyncWriter
+	deadline      *writeDeadline
+	stacks        *stackCache
+	fields        *fieldSet
+	format        Format
+	color         bool
+	life          *lifecycle
+	diag          *diagnostics
+	reopener      *reopener
+	stats         []sinkStats
+	level         *levelSwitch
+	rules         []*Filter
+	quotas        *tenantQuotas
+
+	// derived is set on loggers made by WithFields, they share the outputs
+	// of their parent and do not close them
+	derived bool
+	// stderrOnly is set when ErrorsToStderr takes errors off stdout
+	stderrOnly bool
+	// muted is set on the loggers of Once and Every that should not write
+	muted bool
+	// to and toOnly are the routes set by To and OnlyTo
+	to     []string
+	toOnly bool
 }
 
 type AppLoggerInterface interface {
@@ -24,58 +253,529 @@ type AppLoggerInterface interface {
 	LogHTTP(level string, logPackage string, logFunc string, message string, code int, duration float64)
 }
 
-// logNDJOSNHTTP json format for logs in lib and controller packages
-type logNDJOSN struct {
-	PID        string    `json:"pid"`
-	Level      string    `json:"level"`
-	LogPackage string    `json:"package"`
-	LogFunc    string    `json:"func"`
-	Message    string    `json:"message"`
-	DOB        time.Time `json:"time"`
-}
+// Entry is a single log record before it gets encoded
+type Entry struct {
+	PID      string
+	Level    string
+	Package  string
+	Func     string
+	Message  string
+	Time     time.Time
+	HTTP     bool
+	Code     int
+	Duration float64
+	// Attributes are extra key/values, written under "attributes"
+	Attributes map[string]interface{}
 
-// logNDJOSNHTTP json format for logs in the main package
-type logNDJOSNHTTP struct {
-	PID        string    `json:"pid"`
-	Level      string    `json:"level"`
-	LogPackage string    `json:"package"`
-	LogFunc    string    `json:"func"`
-	Message    string    `json:"message"`
-	DOB        time.Time `json:"time"`
-	Code       int       `json:"code"`
-	Duration   float64   `json:"duration"`
+	// base and attrs are the default fields of the logger and the
+	// attributes of the call, Attributes is only filled from them when
+	// the entry is handed to sinks
+	base  *fieldSet
+	attrs []attr
+	// route names the Routes the entry goes to, only or in addition to
+	// the main output and Sinks
+	route     []string
+	routeOnly bool
+	// batch holds the entries of LogBatch, written out together
+	batch []Entry
 }
 
-func (r AppLogger) Initialise() {
-	generalLog, err := os.OpenFile(r.Path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
+// Initialise opens the output, exiting the process when it cannot. The
+// output is kept in r, so r has to be a variable (see CHANGELOG.md).
+func (r *AppLogger) Initialise() {
+	if err := r.open(); err != nil {
 		fmt.Println("Error opening file:", err)
 		os.Exit(1)
 	}
-	generalLogger = log.New(generalLog, "", 0)
-	errorLogger = log.New(generalLog, "", 0)
+}
+
+// Open opens the output like Initialise but returns the error, for
+// loggers configured field by field outside of the constructors
+func (r *AppLogger) Open() error {
+	return r.open()
+}
+
+// NewLogger returns an initialised logger writing NDJSON to the file at
+// path, or to stdout when path is empty
+func NewLogger(path string) (*AppLogger, error) {
+	logger := &AppLogger{Path: path}
+	if err := logger.open(); err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+// NewLoggerFromWriter returns an initialised logger writing NDJSON to w,
+// which is not closed by Close
+func NewLoggerFromWriter(w io.Writer) (*AppLogger, error) {
+	logger := &AppLogger{Writer: w, Format: FormatJSON}
+	if err := logger.open(); err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+// MustNewLogger is like NewLogger but panics on error, for main and tests
+func MustNewLogger(path string) *AppLogger {
+	logger, err := NewLogger(path)
+	if err != nil {
+		panic(err)
+	}
+	return logger
+}
+
+// DefaultCompressBufferSize is the buffer size of a compressed output when
+// BufferSize is not set
+const DefaultCompressBufferSize = 64 << 10
+
+// open opens the file at Path, or picks stdout, and resolves the format
+func (r *AppLogger) open() error {
+	if r.Compress && (r.Path == "" || r.SharedFile || r.FileLock || r.CopyTruncate || r.IndexEvery > 0) {
+		return fmt.Errorf("applogger: Compress needs a Path and cannot be combined with SharedFile, FileLock, CopyTruncate or IndexEvery")
+	}
+	if r.MaxSize > 0 && (r.Path == "" || r.RotateEvery > 0 || r.CopyTruncate || r.SharedFile || r.FileLock) {
+		return fmt.Errorf("applogger: MaxSize needs a Path and cannot be combined with RotateEvery, CopyTruncate, SharedFile or FileLock")
+	}
+	if (r.CompressRotated || r.MaxAge > 0 || r.MaxBackups > 0) && (r.Path == "" || r.RotateEvery <= 0 && r.MaxSize <= 0 || r.Compress) {
+		return fmt.Errorf("applogger: CompressRotated, MaxAge and MaxBackups need RotateEvery or MaxSize and cannot be combined with Compress")
+	}
+	if r.ErrorsToStderr && r.ErrorPath != "" {
+		return fmt.Errorf("applogger: ErrorsToStderr cannot be combined with ErrorPath")
+	}
+	if r.Writer != nil && (r.Path != "" || r.SharedFile || r.FileLock) {
+		return fmt.Errorf("applogger: Writer cannot be combined with Path, SharedFile or FileLock")
+	}
+	if r.DurableAudit && (r.Path == "" || r.Async || r.WriteDeadline > 0) {
+		return fmt.Errorf("applogger: DurableAudit needs a Path and cannot be combined with Async or WriteDeadline")
+	}
+	if r.TenantQuota > 0 && r.TenantKey == "" || r.TenantQuota < 0 {
+		return fmt.Errorf("applogger: TenantQuota needs a TenantKey and cannot be negative")
+	}
+	if env := os.Getenv(LevelsEnv); r.Levels == nil && env != "" {
+		levels, err := ParseLevels(env)
+		if err != nil {
+			return fmt.Errorf("applogger: %s: %w", LevelsEnv, err)
+		}
+		r.Levels = levels
+	}
+	rules, err := compileRules(r.Rules, r.Routes)
+	if err != nil {
+		return err
+	}
+	r.rules = rules
+	out := os.Stdout
+	owned := false
+	if f, ok := r.Writer.(*os.File); ok {
+		out = f
+	} else if r.Writer != nil {
+		out = nil
+	} else if r.Path != "" {
+		generalLog, err := openLogFile(r.Path)
+		if err != nil {
+			return err
+		}
+		out, owned = generalLog, true
+	}
+	size := r.BufferSize
+	if r.Compress && size <= 0 {
+		size = DefaultCompressBufferSize
+	}
+	r.out = newFileWriter(out, owned, size, r.FlushInterval)
+	r.out.errorPath = r.ErrorPath
+	if out == nil {
+		r.out.writer = r.Writer
+	}
+	if r.Compress {
+		r.out.compress()
+	}
+	if r.SharedFile || r.FileLock {
+		r.out.share(r.FileLock)
+	}
+	if r.Path != "" && r.CopyTruncate {
+		r.out.watchCopyTruncate(r.Path)
+	}
+	if r.Path != "" && r.IndexEvery > 0 {
+		if err := r.out.indexEvery(r.Path, r.IndexEvery); err != nil {
+			r.out.Close()
+			return err
+		}
+	}
+	if r.Path != "" && r.RotateEvery > 0 {
+		if err := r.out.rotateTime(r.Path, r.RotateEvery, r.RotateLocation, r.OnRotate); err != nil {
+			r.out.Close()
+			return err
+		}
+	}
+	if r.MaxSize > 0 {
+		if err := r.out.rotateSize(r.Path, r.MaxSize, r.OnRotate); err != nil {
+			r.out.Close()
+			return err
+		}
+	}
+	if r.DurableAudit {
+		r.out.durable(r.SyncWindow)
+	}
+	if r.CompressRotated || r.MaxAge > 0 || r.MaxBackups > 0 {
+		r.out.cleanRotated(r.CompressRotated, r.MaxAge, r.MaxBackups)
+	}
+	if r.ErrorPath != "" {
+		f, err := openLogFile(r.ErrorPath)
+		if err != nil {
+			r.out.Close()
+			return err
+		}
+		r.errOut = newFileWriter(f, true, r.BufferSize, r.FlushInterval)
+		if r.DurableAudit {
+			r.errOut.durable(r.SyncWindow)
+		}
+	}
+	if r.ErrorsToStderr {
+		r.errOut = newFileWriter(os.Stderr, false, 0, 0)
+		r.stderrOnly = out == os.Stdout
+	}
+	if r.Path != "" && r.DiskBudget > 0 {
+		r.budget = newDiskBudget(r.Path, r.ErrorPath, r.DiskBudget)
+	}
+	if r.Path != "" && r.RetentionPeriods != nil {
+		r.retention = newRetentionSweeper(r.Path, r.ErrorPath, r.RetentionPeriods)
+	}
+	if r.level == nil {
+		r.level = &levelSwitch{}
+	}
+	if r.TenantQuota > 0 {
+		r.quotas = newTenantQuotas(r.TenantKey, r.TenantQuota, r.TenantBurst)
+	}
+	r.outputs = &outputSet{}
+	r.stats = newSinkStats(len(r.Sinks))
+	r.generalLogger = log.New(r.out, "", 0)
+	r.format, r.color = resolveFormat(r.Format, out)
+	if r.Async {
+		w := *r
+		r.async = newAsyncWriter(w.writeSync)
+	} else if r.WriteDeadline > 0 {
+		r.deadline = newWriteDeadline(r.WriteDeadline)
+	}
+	if r.Lifecycle {
+		r.life = &lifecycle{started: time.Now()}
+	}
+	if r.ErrorStack && r.StackDedupWindow > 0 {
+		r.stacks = newStackCache(r.StackDedupWindow)
+	}
+	if r.DiagnosticSignal || r.RecentErrors > 0 {
+		r.diag = newDiagnostics(r.RecentErrors)
+		if r.DiagnosticSignal {
+			r.diag.listen(*r)
+		}
+	}
+	if r.ReopenSignal {
+		r.reopener = listenReopen(*r)
+	}
+	if r.life != nil {
+		r.logStartup()
+	}
+	return nil
+}
+
+// Flush writes out entries still held in the output buffer
+func (r AppLogger) Flush() error {
+	err := r.out.Flush()
+	if r.errOut != nil {
+		if ferr := r.errOut.Flush(); err == nil {
+			err = ferr
+		}
+	}
+	if ferr := r.outputs.flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// Dropped returns the number of entries dropped by load shedding, by the
+// disk budget, by WriteDeadline and over TenantQuota
+func (r AppLogger) Dropped() uint64 {
+	var n uint64
+	if r.async != nil {
+		n += r.async.dropped.Load()
+	}
+	if r.budget != nil {
+		n += r.budget.dropped.Load()
+	}
+	if r.deadline != nil {
+		n += r.deadline.dropped.Load()
+	}
+	if r.quotas != nil {
+		n += r.quotas.dropped.Load()
+	}
+	return n
+}
+
+// TenantDropped returns the entries dropped so far per tenant over their
+// TenantQuota. Past 10000 tenants the drops of the others are counted
+// together under OtherTenantsKey.
+func (r AppLogger) TenantDropped() map[string]uint64 {
+	if r.quotas == nil {
+		return map[string]uint64{}
+	}
+	return r.quotas.droppedByTenant()
+}
+
+// Close flushes and closes the output and the sinks. On a logger made by
+// WithFields it only flushes, the outputs belong to the logger it was
+// derived from.
+func (r AppLogger) Close() error {
+	if r.derived {
+		return r.Flush()
+	}
+	if r.life != nil {
+		r.logShutdown()
+	}
+	if r.diag != nil {
+		r.diag.stop()
+	}
+	if r.reopener != nil {
+		r.reopener.stop()
+	}
+	if r.retention != nil {
+		r.retention.Close()
+	}
+	if r.budget != nil {
+		r.budget.Close()
+	}
+	if r.async != nil {
+		r.async.Close()
+	}
+	if r.deadline != nil {
+		r.deadline.Close()
+	}
+	err := r.out.Close()
+	if r.errOut != nil {
+		if cerr := r.errOut.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := r.outputs.close(); err == nil {
+		err = cerr
+	}
+	for _, s := range r.Sinks {
+		if serr := s.Close(); err == nil {
+			err = serr
+		}
+	}
+	for _, s := range r.Routes {
+		if serr := s.Close(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// Shutdown is Close bounded by ctx, it returns ctx.Err() when the queued
+// entries, sinks and rotation hooks do not finish in time; closing then
+// goes on in the background
+func (r AppLogger) Shutdown(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() { done <- r.Close() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run ties the logger to ctx: it blocks until ctx is done and then closes
+// the logger, stopping every goroutine it started (async writer, flusher,
+// disk budget and signal handler). It is meant to be run by the owner of
+// the logger, e.g. in an errgroup next to the servers using it.
+func (r AppLogger) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return r.Close()
 }
 
 // Log writting to a ndjson file logs for lib and controller packages
 func (r AppLogger) Log(level string, logPackage string, logFunc string, message string) {
+	if r.disabled(levelOf(level), logPackage) {
+		return
+	}
 
-	s1 := time.Now()
-	u := uuid.Must(uuid.NewV4())
+	s1 := r.now()
 
-	x := logNDJOSN{PID: u.String(), Level: level, LogPackage: logPackage, LogFunc: logFunc, Message: message, DOB: s1}
-	res2B, _ := json.Marshal(x)
-	generalLogger.Println(string(res2B))
+	r.logInternal(Entry{PID: r.newID(), Level: level, Package: logPackage, Func: logFunc, Message: message, Time: s1}, nil)
 }
 
 // LogHTTP writting to a ndjson file logs for the main package
 // the difference is that we are recording the http status
 // and the duration of the request
 func (r AppLogger) LogHTTP(level string, logPackage string, logFunc string, message string, code int, duration float64) {
+	if r.disabled(levelOf(level), logPackage) {
+		return
+	}
+
+	s1 := r.now()
+
+	r.logInternal(Entry{PID: r.newID(), Level: level, Package: logPackage, Func: logFunc, Message: message, Time: s1, HTTP: true, Code: code, Duration: duration}, nil)
+}
+
+// now returns the time for a new entry
+func (r AppLogger) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock()
+	}
+	return time.Now()
+}
+
+// write hands the entry to the async writer or writes it right away
+func (r AppLogger) write(e Entry) {
+	if r.life != nil {
+		r.life.count(e)
+	}
+	if r.diag != nil {
+		r.diag.record(e)
+	}
+	if r.budget != nil && !r.budget.allow(e) {
+		return
+	}
+	if r.async != nil {
+		if r.HighWaterMark > 0 && r.async.queue.len() >= r.HighWaterMark && levelOf(e.Level) < r.ShedLevel {
+			r.async.dropped.Add(1)
+			return
+		}
+		r.async.enqueue(e)
+		return
+	}
+	r.writeNow(e)
+}
 
-	s1 := time.Now()
-	u := uuid.Must(uuid.NewV4())
+// writeNow writes the entry, or a batch, on the calling goroutine: synced
+// to disk before returning when it is a durable audit entry, bounded by
+// WriteDeadline otherwise
+func (r AppLogger) writeNow(e Entry) {
+	if r.DurableAudit && isAudit(e) {
+		r.writeSync(e)
+		r.waitDurable(e)
+		return
+	}
+	if r.deadline != nil {
+		r.deadline.write(e, r.writeSync)
+		return
+	}
+	r.writeSync(e)
+}
+
+// waitDurable returns once the files the entry was written to are synced
+func (r AppLogger) waitDurable(e Entry) {
+	if e.routeOnly {
+		return
+	}
+	err := r.out.waitDurable()
+	hasErrors := levelOf(e.Level) >= LevelError
+	for _, b := range e.batch {
+		hasErrors = hasErrors || levelOf(b.Level) >= LevelError
+	}
+	if r.ErrorPath != "" && hasErrors {
+		if eerr := r.errOut.waitDurable(); err == nil {
+			err = eerr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error syncing entry:", err)
+	}
+}
+
+// writeSync encodes the entry in the selected format, writes it out and
+// hands it to every sink
+func (r AppLogger) writeSync(e Entry) {
+	if e.batch != nil {
+		r.writeBatchSync(e.batch)
+		return
+	}
+	if len(r.Sinks) > 0 || len(e.route) > 0 {
+		e.Attributes = e.attributeMap()
+	}
+	r.writeRoutes(e)
+	if e.routeOnly {
+		return
+	}
+	if line, ok := r.line(e); ok {
+		r.println(e, line)
+	}
+	r.writeSinks(e)
+}
+
+// line returns the entry encoded for the main output, reporting encoding
+// errors on stderr
+func (r AppLogger) line(e Entry) (string, bool) {
+	if r.format == FormatConsole {
+		return consoleLine(e, r.color, r.ConsoleDiff), true
+	}
+	line, err := r.encode(e)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error encoding entry:", err)
+		return "", false
+	}
+	if r.Checksum != ChecksumNone {
+		line = appendChecksum(line, r.Checksum)
+	}
+	return string(line), true
+}
+
+// writeRoutes hands the entry to the Routes it was sent to
+func (r AppLogger) writeRoutes(e Entry) {
+	for _, name := range e.route {
+		s, ok := r.Routes[name]
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Error writing to route: unknown route", name)
+			continue
+		}
+		if err := s.Write(e); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing to route:", err)
+		}
+	}
+}
+
+// writeSinks hands the entry to every sink
+func (r AppLogger) writeSinks(e Entry) {
+	for i, s := range r.Sinks {
+		err := s.Write(e)
+		if i < len(r.stats) {
+			r.stats[i].record(err)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing to sink:", err)
+		}
+	}
+}
+
+// println writes a line to the main output, and Error and Fatal entries to
+// the error file or stderr when there is one
+func (r AppLogger) println(e Entry, line string) {
+	if r.errOut != nil && levelOf(e.Level) >= LevelError {
+		r.errOut.Write([]byte(line + "\n"))
+		if r.SplitErrors || r.stderrOnly {
+			return
+		}
+	}
+	r.generalLogger.Println(line)
+	r.outputs.write([]byte(line + "\n"))
+}
+
+// encode returns the line of the entry in the format of the logger
+func (r AppLogger) encode(e Entry) ([]byte, error) {
+	switch r.format {
+	case FormatOTel:
+		return OTelEncoder{Resource: r.Resource}.Encode(e)
+	case FormatGCP:
+		project := r.GCPProject
+		if project == "" {
+			project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+		}
+		return GCPEncoder{ProjectID: project}.Encode(e)
+	}
+	return encodeJSON(e)
+}
 
-	x := logNDJOSNHTTP{PID: u.String(), Level: level, LogPackage: logPackage, LogFunc: logFunc, Message: message, DOB: s1, Code: code, Duration: duration}
-	res2B, _ := json.Marshal(x)
-	generalLogger.Println(string(res2B))
+// encodeJSON returns the ndjson representation of the entry without the
+// trailing newline
+func encodeJSON(e Entry) ([]byte, error) {
+	return JSONEncoder{}.Encode(e)
 }
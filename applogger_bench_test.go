@@ -0,0 +1,136 @@
+package applogger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+// The benchmarks below log to /dev/null so they measure the cost of the
+// logger rather than of the disk. Run them with
+//
+//	go test -run '^$' -bench . -benchmem
+//
+// and compare runs with benchstat before and after a change.
+
+func benchmarkLogger(b *testing.B, logger AppLogger) AppLogger {
+	logger.Path = os.DevNull
+	logger.Format = FormatJSON
+	if err := logger.open(); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { logger.Close() })
+	return logger
+}
+
+func BenchmarkLog(b *testing.B) {
+	b.Run("sync", func(b *testing.B) {
+		logger := benchmarkLogger(b, AppLogger{})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Log("INFO", "main", "app", "hot path")
+		}
+	})
+	b.Run("async", func(b *testing.B) {
+		logger := benchmarkLogger(b, AppLogger{Async: true})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Log("INFO", "main", "app", "hot path")
+		}
+	})
+	b.Run("buffered", func(b *testing.B) {
+		logger := benchmarkLogger(b, AppLogger{BufferSize: 64 << 10})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Log("INFO", "main", "app", "hot path")
+		}
+	})
+	b.Run("filtered", func(b *testing.B) {
+		logger := benchmarkLogger(b, AppLogger{Levels: map[string]LogLevel{"": LevelWarn}})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Log("DEBUG", "main", "app", "hot path")
+		}
+	})
+}
+
+// BenchmarkCaller compares explicit package and func names with looking
+// up the caller, as LogError does
+func BenchmarkCaller(b *testing.B) {
+	err := errors.New("connection refused")
+	b.Run("off", func(b *testing.B) {
+		logger := benchmarkLogger(b, AppLogger{})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.LogFields("ERROR", "main", "app", "hot path", map[string]interface{}{ErrorKey: err.Error()})
+		}
+	})
+	b.Run("on", func(b *testing.B) {
+		logger := benchmarkLogger(b, AppLogger{})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.LogError(context.Background(), err, "hot path")
+		}
+	})
+}
+
+// BenchmarkCallerLine measures Caller, which looks up the file and line of
+// every entry
+func BenchmarkCallerLine(b *testing.B) {
+	logger := benchmarkLogger(b, AppLogger{Caller: true})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Log("INFO", "main", "app", "hot path")
+	}
+}
+
+// BenchmarkAttributes compares attributes given as a map on every call
+// with the same attributes preserialized by WithFields
+func BenchmarkAttributes(b *testing.B) {
+	fields := map[string]interface{}{"service": "billing", "region": "eu-west-1", "version": 3, "user_id": 42}
+	b.Run("map", func(b *testing.B) {
+		logger := benchmarkLogger(b, AppLogger{})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.LogFields("INFO", "main", "app", "hot path", fields)
+		}
+	})
+	b.Run("default", func(b *testing.B) {
+		logger := benchmarkLogger(b, AppLogger{}).WithFields(fields)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Log("INFO", "main", "app", "hot path")
+		}
+	})
+	b.Run("typed", func(b *testing.B) {
+		logger := benchmarkLogger(b, AppLogger{})
+		ctx := context.Background()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.LogAttrs(ctx, LevelInfo, "hot path", String("service", "billing"), String("region", "eu-west-1"), Int("version", 3), Int("user_id", 42))
+		}
+	})
+}
+
+// BenchmarkBaseline is the standard library slog writing a similar entry,
+// as a reference point for the numbers above
+func BenchmarkBaseline(b *testing.B) {
+	b.Run("slog", func(b *testing.B) {
+		logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Info("hot path", "package", "main", "func", "app", "user_id", 42)
+		}
+	})
+	b.Run("applogger", func(b *testing.B) {
+		logger := benchmarkLogger(b, AppLogger{})
+		fields := map[string]interface{}{"user_id": 42}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.LogFields("INFO", "main", "app", "hot path", fields)
+		}
+	})
+}
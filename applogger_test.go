@@ -2,10 +2,15 @@ package applogger
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLog(t *testing.T) {
@@ -44,3 +49,79 @@ func isJSON(s string) error {
 	err := json.Unmarshal([]byte(s), &js)
 	return err
 }
+
+func TestMustNewLogger(t *testing.T) {
+	logger := MustNewLogger("/dev/null")
+	logger.Log("INFO", "main", "app", "hello")
+	logger.Close()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for a missing directory")
+		}
+	}()
+	MustNewLogger("./does/not/exist/app.ndjson")
+}
+
+func TestNewLoggerFromWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLoggerFromWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Log("INFO", "main", "app", "hello")
+	logger.Close()
+	if err := isJSON(strings.TrimSpace(buf.String())); err != nil || !strings.Contains(buf.String(), `"message":"hello"`) {
+		t.Fatalf("unexpected output %q %v", buf.String(), err)
+	}
+
+	if err := (&AppLogger{Writer: &buf, Path: "/dev/null"}).Open(); err == nil {
+		t.Fatal("expected an error for Writer with Path")
+	}
+}
+
+func TestRunStopsGoroutines(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	// the first signal.Notify starts a watcher goroutine of os/signal that
+	// lives as long as the process
+	warm := AppLogger{Path: "/dev/null", DiagnosticSignal: true}
+	warm.Initialise()
+	warm.Close()
+
+	before := runtime.NumGoroutine()
+	logger := AppLogger{Path: "./tmp/run.ndjson", Async: true, BufferSize: 4096, DiskBudget: 1 << 20, DiagnosticSignal: true, RotateEvery: time.Hour}
+	logger.Initialise()
+	logger.Log("INFO", "main", "app", "hello")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- logger.Run(ctx) }()
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("goroutines leaked: %d before, %d after Run", before, n)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	logger := AppLogger{Path: "/dev/null", Async: true, Sinks: []Sink{blockingSink{&memorySink{}, block}}}
+	logger.Initialise()
+	logger.Log("INFO", "main", "app", "stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := logger.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected the deadline to expire, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package applogtest runs the applogger pipeline in tests against an
+// in-memory sink and a manual clock, so tests of async logging wait for
+// entries instead of sleeping or reading files.
+//
+//	h := applogtest.New(t, func(l *applogger.AppLogger) { l.Async = true })
+//	service := NewService(h.Logger)
+//	service.Handle(request)
+//	entries := h.WaitForEntries(2, time.Second)
+package applogtest
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/junkd0g/applogger"
+)
+
+// Start is the time of a new Clock
+var Start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Clock is a manual clock for AppLogger.Clock, it only moves with Advance
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a clock at Start
+func NewClock() *Clock {
+	return &Clock{now: Start}
+}
+
+// Now returns the time of the clock
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Sink keeps every entry in memory
+type Sink struct {
+	mu      sync.Mutex
+	entries []applogger.Entry
+	changed chan struct{}
+}
+
+// NewSink returns an empty sink
+func NewSink() *Sink {
+	return &Sink{changed: make(chan struct{})}
+}
+
+// Write stores the entry
+func (s *Sink) Write(e applogger.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return nil
+}
+
+// Close does nothing, the entries stay readable
+func (s *Sink) Close() error { return nil }
+
+// Entries returns a copy of the entries written so far
+func (s *Sink) Entries() []applogger.Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]applogger.Entry(nil), s.entries...)
+}
+
+// Wait waits until the sink holds at least n entries and returns them
+func (s *Sink) Wait(n int, timeout time.Duration) ([]applogger.Entry, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		s.mu.Lock()
+		got, changed := len(s.entries), s.changed
+		s.mu.Unlock()
+		if got >= n {
+			return s.Entries(), nil
+		}
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return s.Entries(), fmt.Errorf("applogtest: got %d entries after %s, want %d", got, timeout, n)
+		}
+	}
+}
+
+// Harness is a logger wired to a Sink and a Clock
+type Harness struct {
+	t      testing.TB
+	Logger *applogger.AppLogger
+	Sink   *Sink
+	Clock  *Clock
+}
+
+// New returns a harness whose logger is configured by configure, if not
+// nil, before it is opened. The main output goes to os.DevNull, the Sink
+// is added to the sinks and the Clock set. Entry IDs are entry-1, entry-2,
+// ... unless configure sets EntryIDs. The logger is closed when the
+// test ends.
+func New(t testing.TB, configure func(l *applogger.AppLogger)) *Harness {
+	t.Helper()
+	h := &Harness{t: t, Sink: NewSink(), Clock: NewClock()}
+	l := &applogger.AppLogger{Format: applogger.FormatJSON}
+	if configure != nil {
+		configure(l)
+	}
+	l.Path = os.DevNull
+	l.Sinks = append(l.Sinks, h.Sink)
+	l.Clock = h.Clock.Now
+	if l.EntryIDs == nil {
+		l.EntryIDs = applogger.NewSequence("entry-")
+	}
+	if err := l.Open(); err != nil {
+		t.Fatalf("applogtest: %v", err)
+	}
+	h.Logger = l
+	t.Cleanup(func() { h.Logger.Close() })
+	return h
+}
+
+// WaitForEntries waits until n entries went through the pipeline and
+// returns them, failing the test after timeout
+func (h *Harness) WaitForEntries(n int, timeout time.Duration) []applogger.Entry {
+	h.t.Helper()
+	entries, err := h.Sink.Wait(n, timeout)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return entries
+}
@@ -0,0 +1,35 @@
+package applogtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/junkd0g/applogger"
+)
+
+func TestHarnessAsync(t *testing.T) {
+	h := New(t, func(l *applogger.AppLogger) { l.Async = true })
+	h.Logger.Log("info", "pkg", "F", "first")
+	h.Clock.Advance(time.Minute)
+	h.Logger.Log("info", "pkg", "F", "second")
+
+	entries := h.WaitForEntries(2, time.Second)
+	if entries[0].Message != "first" || entries[1].Message != "second" {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if entries[0].PID != "entry-1" || entries[1].PID != "entry-2" {
+		t.Fatalf("IDs = %s, %s", entries[0].PID, entries[1].PID)
+	}
+	if !entries[0].Time.Equal(Start) || !entries[1].Time.Equal(Start.Add(time.Minute)) {
+		t.Fatalf("times = %s, %s", entries[0].Time, entries[1].Time)
+	}
+}
+
+func TestSinkWaitTimeout(t *testing.T) {
+	s := NewSink()
+	s.Write(applogger.Entry{Message: "only"})
+	entries, err := s.Wait(2, 10*time.Millisecond)
+	if err == nil || len(entries) != 1 {
+		t.Fatalf("Wait = %d entries, %v", len(entries), err)
+	}
+}
@@ -0,0 +1,80 @@
+package applogger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// asyncWriter hands entries from the logging goroutines to a single writer
+// goroutine through a lock-free queue
+type asyncWriter struct {
+	queue   *mpscQueue
+	write   func(e Entry)
+	dropped atomic.Uint64
+	// pushed and written count the entries for drain
+	pushed  atomic.Uint64
+	written atomic.Uint64
+
+	closeOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func newAsyncWriter(write func(e Entry)) *asyncWriter {
+	a := &asyncWriter{
+		queue: newMPSCQueue(),
+		write: write,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *asyncWriter) enqueue(e Entry) {
+	a.pushed.Add(1)
+	a.queue.push(e)
+}
+
+// drain waits until the entries enqueued before the call are written
+func (a *asyncWriter) drain() {
+	target := a.pushed.Load()
+	for a.written.Load() < target {
+		select {
+		case <-a.done:
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	for {
+		for {
+			e, ok := a.queue.pop()
+			if !ok {
+				break
+			}
+			a.write(e)
+			a.written.Add(1)
+		}
+		select {
+		case <-a.stop:
+			// drain what was pushed before Close
+			for e, ok := a.queue.pop(); ok; e, ok = a.queue.pop() {
+				a.write(e)
+			}
+			return
+		default:
+		}
+		a.queue.wait(a.stop)
+	}
+}
+
+// Close writes out the queued entries and stops the writer goroutine
+func (a *asyncWriter) Close() {
+	a.closeOnce.Do(func() { close(a.stop) })
+	<-a.done
+}
@@ -0,0 +1,127 @@
+package applogger
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// attr is a single key/value of an entry. Entries keep their attributes as
+// an append-only slice, when a key appears more than once the last one wins
+// on encode.
+type attr struct {
+	key   string
+	value interface{}
+}
+
+// appendMap appends the key/values of m to attrs
+func appendMap(attrs []attr, m map[string]interface{}) []attr {
+	for k, v := range m {
+		attrs = append(attrs, attr{k, v})
+	}
+	return attrs
+}
+
+// hasAttributes reports whether the entry has any attribute to encode
+func (e *Entry) hasAttributes() bool {
+	if e.base == nil && e.attrs == nil {
+		return len(e.Attributes) > 0
+	}
+	return len(e.attrs) > 0 || (e.base != nil && len(e.base.attrs) > 0)
+}
+
+// eachAttribute calls f for every attribute of the entry. Attributes set
+// through Entry.Attributes only are visited in key order, the internal ones
+// in insertion order skipping keys that are overwritten later.
+func (e *Entry) eachAttribute(f func(key string, value interface{})) {
+	if e.base == nil && e.attrs == nil {
+		for _, k := range sortedKeys(e.Attributes) {
+			f(k, e.Attributes[k])
+		}
+		return
+	}
+
+	var base []attr
+	if e.base != nil {
+		base = e.base.attrs
+	}
+	n := len(base) + len(e.attrs)
+	at := func(i int) attr {
+		if i < len(base) {
+			return base[i]
+		}
+		return e.attrs[i-len(base)]
+	}
+	for i := 0; i < n; i++ {
+		a := at(i)
+		shadowed := false
+		for j := i + 1; j < n && !shadowed; j++ {
+			shadowed = at(j).key == a.key
+		}
+		if !shadowed {
+			f(a.key, a.value)
+		}
+	}
+}
+
+// attributeMap returns the attributes of the entry as a map
+func (e *Entry) attributeMap() map[string]interface{} {
+	if e.base == nil && e.attrs == nil {
+		return e.Attributes
+	}
+	m := make(map[string]interface{})
+	e.eachAttribute(func(k string, v interface{}) { m[k] = v })
+	return m
+}
+
+// encodeAttributes returns the JSON object of the attributes of the entry,
+// splicing the preserialized default fields when no call attribute
+// overrides one of them
+func encodeAttributes(e Entry) ([]byte, error) {
+	if e.base == nil && e.attrs == nil {
+		return json.Marshal(e.Attributes)
+	}
+	if e.base != nil && e.base.object != nil {
+		if len(e.attrs) == 0 {
+			return e.base.object, nil
+		}
+		if len(e.base.attrs) > 0 && !overrides(e.base.attrs, e.attrs) {
+			var buf bytes.Buffer
+			buf.Write(e.base.object[:len(e.base.object)-1])
+			for _, a := range e.attrs {
+				if err := writeJSONField(&buf, a.key, a.value); err != nil {
+					return nil, err
+				}
+			}
+			buf.WriteByte('}')
+			return buf.Bytes(), nil
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	buf.WriteByte('{')
+	e.eachAttribute(func(k string, v interface{}) {
+		if err == nil {
+			err = writeJSONField(&buf, k, v)
+		}
+	})
+	buf.WriteByte('}')
+	return buf.Bytes(), err
+}
+
+// overrides reports whether a key of attrs is in base or repeated in attrs
+func overrides(base, attrs []attr) bool {
+	for i, a := range attrs {
+		for _, b := range base {
+			if a.key == b.key {
+				return true
+			}
+		}
+		for _, b := range attrs[i+1:] {
+			if a.key == b.key {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package applogger
+
+import (
+	"testing"
+)
+
+func TestEncodeAttributesLastWriteWins(t *testing.T) {
+	base := newFieldSet([]attr{{"a", 1}, {"b", 2}})
+	cases := []struct {
+		attrs []attr
+		want  string
+	}{
+		{nil, `{"a":1,"b":2}`},
+		{[]attr{{"c", 3}}, `{"a":1,"b":2,"c":3}`},
+		{[]attr{{"b", "x"}}, `{"a":1,"b":"x"}`},
+		{[]attr{{"c", 3}, {"c", 4}}, `{"a":1,"b":2,"c":4}`},
+	}
+	for _, c := range cases {
+		got, err := encodeAttributes(Entry{base: base, attrs: c.attrs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != c.want {
+			t.Fatalf("got %s want %s", got, c.want)
+		}
+	}
+
+	m := (&Entry{base: base, attrs: []attr{{"a", "y"}}}).attributeMap()
+	if len(m) != 2 || m["a"] != "y" || m["b"] != 2 {
+		t.Fatalf("unexpected attribute map %v", m)
+	}
+}
@@ -0,0 +1,92 @@
+package applogger
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HTTPAuth adds credentials to the requests of an HTTP sink. It is called
+// for every request, so credentials that rotate are picked up without
+// recreating the sink or the logger.
+type HTTPAuth interface {
+	Authorize(req *http.Request) error
+}
+
+// HTTPAuthFunc adapts a function to HTTPAuth
+type HTTPAuthFunc func(req *http.Request) error
+
+// Authorize calls f(req)
+func (f HTTPAuthFunc) Authorize(req *http.Request) error {
+	return f(req)
+}
+
+// BearerToken sends a static token as "Authorization: Bearer <token>"
+func BearerToken(token string) HTTPAuth {
+	return HeaderAuth("Authorization", "Bearer "+token)
+}
+
+// HeaderAuth sends a static header, e.g. HeaderAuth("Authorization",
+// "Splunk "+token) or HeaderAuth("X-API-Key", key)
+func HeaderAuth(name string, value string) HTTPAuth {
+	return HTTPAuthFunc(func(req *http.Request) error {
+		req.Header.Set(name, value)
+		return nil
+	})
+}
+
+// BasicAuth sends a user name and password
+func BasicAuth(user string, password string) HTTPAuth {
+	return HTTPAuthFunc(func(req *http.Request) error {
+		req.SetBasicAuth(user, password)
+		return nil
+	})
+}
+
+// TokenProvider sends the token returned by token as a bearer token, for
+// OAuth2 or cloud IAM credentials that expire. token is called for every
+// request and should cache the token until it is about to expire, for
+// example with an oauth2.TokenSource:
+//
+//	applogger.TokenProvider(func() (string, error) {
+//		t, err := ts.Token()
+//		if err != nil {
+//			return "", err
+//		}
+//		return t.AccessToken, nil
+//	})
+func TokenProvider(token func() (string, error)) HTTPAuth {
+	return HTTPAuthFunc(func(req *http.Request) error {
+		t, err := token()
+		if err != nil {
+			return fmt.Errorf("applogger: token provider: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+t)
+		return nil
+	})
+}
+
+// authOption reads the "auth" option of an HTTP sink, one of
+// {"type": "bearer", "token": "..."}, {"type": "basic", "username": "...",
+// "password": "..."} or {"type": "header", "name": "...", "value": "..."}
+func authOption(options map[string]interface{}) (HTTPAuth, error) {
+	raw, ok := options["auth"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	str := func(key string) string {
+		v, _ := raw[key].(string)
+		return v
+	}
+	switch str("type") {
+	case "bearer":
+		return BearerToken(str("token")), nil
+	case "basic":
+		return BasicAuth(str("username"), str("password")), nil
+	case "header":
+		if str("name") == "" {
+			return nil, fmt.Errorf("applogger: header auth needs a name")
+		}
+		return HeaderAuth(str("name"), str("value")), nil
+	}
+	return nil, fmt.Errorf("applogger: unknown auth type %q", str("type"))
+}
@@ -0,0 +1,53 @@
+package applogger
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPSinkAuth(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	s, err := NewSink("http", map[string]interface{}{"url": srv.URL, "max_entries": float64(1), "auth": map[string]interface{}{"type": "basic", "username": "app", "password": "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write(Entry{Level: "INFO"})
+	s.Close()
+
+	tokens := []string{"first", "rotated"}
+	rotating := &HTTPSink{URL: srv.URL, Batch: BatchConfig{MaxEntries: 1}, Auth: TokenProvider(func() (string, error) {
+		if len(tokens) == 0 {
+			return "", errors.New("expired")
+		}
+		t := tokens[0]
+		tokens = tokens[1:]
+		return t, nil
+	})}
+	rotating.Write(Entry{Level: "INFO"})
+	rotating.Write(Entry{Level: "INFO"})
+	rotating.Write(Entry{Level: "INFO"})
+	if err := rotating.Close(); err == nil {
+		t.Fatal("expected the token provider error")
+	}
+
+	want := []string{"Basic YXBwOnNlY3JldA==", "Bearer first", "Bearer rotated"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	if _, err := NewSink("http", map[string]interface{}{"url": srv.URL, "auth": map[string]interface{}{"type": "kerberos"}}); err == nil {
+		t.Fatal("expected an error for an unknown auth type")
+	}
+}
@@ -0,0 +1,80 @@
+package applogger
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// BaggageHeader is the W3C baggage header carrying propagated fields
+// between services
+const BaggageHeader = "baggage"
+
+type baggageKey struct{}
+
+// WithBaggage returns a copy of ctx with the field key=value, which entries
+// logged with the context record as an attribute and InjectBaggage passes
+// on to the services called, e.g. tenant_id
+func WithBaggage(ctx context.Context, key, value string) context.Context {
+	old := Baggage(ctx)
+	fields := make(map[string]string, len(old)+1)
+	for k, v := range old {
+		fields[k] = v
+	}
+	fields[key] = value
+	return context.WithValue(ctx, baggageKey{}, fields)
+}
+
+// Baggage returns the propagated fields of ctx, the map must not be
+// changed
+func Baggage(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(baggageKey{}).(map[string]string)
+	return fields
+}
+
+// InjectBaggage sets the propagated fields of the request context on an
+// outgoing request
+func InjectBaggage(req *http.Request) {
+	fields := Baggage(req.Context())
+	if len(fields) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = url.PathEscape(k) + "=" + url.PathEscape(fields[k])
+	}
+	req.Header.Set(BaggageHeader, strings.Join(parts, ","))
+}
+
+// ExtractBaggage returns the context of an incoming request with the
+// propagated fields of its baggage header. Malformed members and their
+// properties are ignored.
+func ExtractBaggage(req *http.Request) context.Context {
+	ctx := req.Context()
+	for _, header := range req.Header.Values(BaggageHeader) {
+		for _, member := range strings.Split(header, ",") {
+			member, _, _ = strings.Cut(member, ";")
+			k, v, ok := strings.Cut(member, "=")
+			if !ok {
+				continue
+			}
+			key, kerr := url.PathUnescape(strings.TrimSpace(k))
+			value, verr := url.PathUnescape(strings.TrimSpace(v))
+			if kerr != nil || verr != nil || key == "" {
+				continue
+			}
+			ctx = WithBaggage(ctx, key, value)
+		}
+	}
+	return ctx
+}
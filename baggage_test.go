@@ -0,0 +1,36 @@
+package applogger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBaggagePropagation(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "tenant_id", "acme corp")
+	ctx = WithBaggage(ctx, "plan", "gold")
+	out, _ := http.NewRequestWithContext(ctx, "GET", "http://billing/", nil)
+	InjectBaggage(out)
+	if got := out.Header.Get(BaggageHeader); got != "plan=gold,tenant_id=acme%20corp" {
+		t.Fatalf("unexpected header %q", got)
+	}
+
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+	h := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.LogContext(r.Context(), "INFO", "billing", "charge", "charged")
+	}))
+	in := httptest.NewRequest("GET", "/", nil)
+	in.Header.Set(BaggageHeader, out.Header.Get(BaggageHeader)+",broken;prop=1")
+	h.ServeHTTP(httptest.NewRecorder(), in)
+
+	for _, e := range decodeLines(t, &buf) {
+		attributes := e["attributes"].(map[string]interface{})
+		if attributes["tenant_id"] != "acme corp" || attributes["plan"] != "gold" {
+			t.Fatalf("entry without the baggage %v", e)
+		}
+	}
+}
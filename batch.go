@@ -0,0 +1,98 @@
+package applogger
+
+import (
+	"bytes"
+	"context"
+)
+
+// Event is one entry of LogBatch
+type Event struct {
+	Level   string
+	Package string
+	Func    string
+	Message string
+	Fields  map[string]interface{}
+}
+
+// LogBatch writes a set of related entries at once: they go through the
+// pipeline one by one, then are encoded together and reach the main output
+// in a single write followed by a flush, so they stay together in the
+// file and other entries cannot interleave with them. In async mode the
+// batch is queued as one item and never shed. Sinks still receive the
+// entries one by one.
+func (r AppLogger) LogBatch(ctx context.Context, events []Event) {
+	if r.muted {
+		return
+	}
+	entries := make([]Entry, 0, len(events))
+	for _, ev := range events {
+		s1 := r.now()
+
+		e := Entry{PID: r.newID(), Level: ev.Level, Package: ev.Package, Func: ev.Func, Message: ev.Message, Time: s1}
+		if e, ok := r.prepare(e, r.contextAttrs(ctx, s1, appendMap(nil, ev.Fields))); ok {
+			entries = append(entries, e)
+		}
+	}
+
+	kept := entries[:0]
+	for _, e := range entries {
+		if r.life != nil {
+			r.life.count(e)
+		}
+		if r.diag != nil {
+			r.diag.record(e)
+		}
+		if r.budget == nil || r.budget.allow(e) {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+	if r.async != nil {
+		r.async.enqueue(Entry{batch: kept})
+		return
+	}
+	r.writeNow(Entry{batch: kept})
+}
+
+// writeBatchSync encodes the entries of a batch into one buffer per output
+// and writes each buffer with a single call
+func (r AppLogger) writeBatchSync(entries []Entry) {
+	var main, errs bytes.Buffer
+	for i := range entries {
+		e := &entries[i]
+		if len(r.Sinks) > 0 || len(e.route) > 0 {
+			e.Attributes = e.attributeMap()
+		}
+		if e.routeOnly {
+			continue
+		}
+		line, ok := r.line(*e)
+		if !ok {
+			continue
+		}
+		if r.errOut != nil && levelOf(e.Level) >= LevelError {
+			errs.WriteString(line + "\n")
+			if r.SplitErrors {
+				continue
+			}
+		}
+		main.WriteString(line + "\n")
+	}
+	if errs.Len() > 0 {
+		r.errOut.Write(errs.Bytes())
+		r.errOut.Flush()
+	}
+	if main.Len() > 0 {
+		r.out.Write(main.Bytes())
+		r.out.Flush()
+		r.outputs.write(main.Bytes())
+	}
+	for _, e := range entries {
+		r.writeRoutes(e)
+		if !e.routeOnly {
+			r.writeSinks(e)
+		}
+	}
+}
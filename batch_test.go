@@ -0,0 +1,54 @@
+package applogger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// countingWriter counts the calls to Write
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestLogBatch(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "batch.ndjson")
+		var sink bytes.Buffer
+		out := &countingWriter{}
+		logger := AppLogger{Path: path, Async: async, Sinks: []Sink{NewWriterSink(&sink, nil)}, Levels: map[string]LogLevel{"": LevelInfo}}
+		logger.Initialise()
+		logger.AddOutput(out, false)
+
+		logger.LogBatch(context.Background(), []Event{
+			{Level: "INFO", Package: "import", Func: "step", Message: "row 1", Fields: map[string]interface{}{"row": 1}},
+			{Level: "DEBUG", Package: "import", Func: "step", Message: "filtered"},
+			{Level: "WARN", Package: "import", Func: "step", Message: "row 2", Fields: map[string]interface{}{"row": 2}},
+		})
+		logger.Close()
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		if len(lines) != 2 || !strings.Contains(lines[0], `"row":1`) || !strings.Contains(lines[1], `"row":2`) {
+			t.Fatalf("async=%v: unexpected file %s", async, b)
+		}
+		if out.writes != 1 {
+			t.Fatalf("async=%v: batch took %d writes", async, out.writes)
+		}
+		if strings.Count(sink.String(), "\n") != 2 {
+			t.Fatalf("async=%v: sink got %s", async, sink.String())
+		}
+	}
+}
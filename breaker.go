@@ -0,0 +1,191 @@
+package applogger
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Default settings of a CircuitBreakerSink
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+var (
+	// ErrSinkTimeout is returned when a write takes longer than the timeout
+	ErrSinkTimeout = errors.New("applogger: sink write timed out")
+	// ErrCircuitOpen is returned for entries that could not be routed while
+	// the circuit is open
+	ErrCircuitOpen = errors.New("applogger: sink circuit open")
+)
+
+// Circuit breaker states as returned by CircuitBreakerSink.State
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// CircuitBreakerSink protects the application from a slow or failing sink,
+// usually one writing over the network. A write taking longer than Timeout
+// fails. After Threshold failures in a row the circuit opens and entries go
+// to Fallback, or are kept in a spill queue of up to Spill entries (the
+// oldest are dropped), without touching the sink. After Cooldown one write
+// is let through; when it succeeds the circuit closes and the spilled
+// entries are written, when it fails the circuit opens again.
+//
+// A write that timed out keeps running in the background, until it returns
+// further writes fail right away so a hanging backend never piles up
+// goroutines.
+type CircuitBreakerSink struct {
+	Sink      Sink
+	Timeout   time.Duration
+	Threshold int
+	Cooldown  time.Duration
+	Fallback  Sink
+	Spill     int
+
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	openUntil time.Time
+	spilled   []Entry
+	inflight  chan struct{}
+	now       func() time.Time
+}
+
+// NewCircuitBreakerSink wraps s with a write timeout and the default
+// threshold and cooldown
+func NewCircuitBreakerSink(s Sink, timeout time.Duration) *CircuitBreakerSink {
+	return &CircuitBreakerSink{Sink: s, Timeout: timeout}
+}
+
+func (b *CircuitBreakerSink) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// State returns CircuitClosed, CircuitOpen or CircuitHalfOpen
+func (b *CircuitBreakerSink) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stateLocked()
+}
+
+func (b *CircuitBreakerSink) stateLocked() string {
+	switch {
+	case !b.open:
+		return CircuitClosed
+	case b.clock().Before(b.openUntil):
+		return CircuitOpen
+	}
+	return CircuitHalfOpen
+}
+
+// Write writes the entry to the sink, or routes it while the circuit is
+// open
+func (b *CircuitBreakerSink) Write(e Entry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.stateLocked()
+	if state == CircuitOpen {
+		return b.routeLocked(e, ErrCircuitOpen)
+	}
+
+	err := b.writeLocked(e)
+	if err == nil {
+		b.failures = 0
+		b.open = false
+		b.drainLocked()
+		return nil
+	}
+
+	b.failures++
+	threshold := b.Threshold
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if state == CircuitHalfOpen || b.failures >= threshold {
+		cooldown := b.Cooldown
+		if cooldown <= 0 {
+			cooldown = DefaultBreakerCooldown
+		}
+		b.open = true
+		b.openUntil = b.clock().Add(cooldown)
+	}
+	return b.routeLocked(e, err)
+}
+
+// writeLocked writes to the sink within the timeout
+func (b *CircuitBreakerSink) writeLocked(e Entry) error {
+	if b.Timeout <= 0 {
+		return b.Sink.Write(e)
+	}
+	if b.inflight != nil {
+		select {
+		case <-b.inflight:
+			b.inflight = nil
+		default:
+			return ErrSinkTimeout
+		}
+	}
+
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		err = b.Sink.Write(e)
+	}()
+	t := time.NewTimer(b.Timeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return err
+	case <-t.C:
+		b.inflight = done
+		return ErrSinkTimeout
+	}
+}
+
+// routeLocked hands an entry the sink did not take to the fallback or the
+// spill queue, err is returned when there is neither
+func (b *CircuitBreakerSink) routeLocked(e Entry, err error) error {
+	if b.Fallback != nil {
+		return b.Fallback.Write(e)
+	}
+	if b.Spill > 0 {
+		if len(b.spilled) == b.Spill {
+			b.spilled = b.spilled[1:]
+		}
+		b.spilled = append(b.spilled, e)
+		return nil
+	}
+	return err
+}
+
+// drainLocked writes the spilled entries after the sink recovered
+func (b *CircuitBreakerSink) drainLocked() {
+	for len(b.spilled) > 0 {
+		if err := b.writeLocked(b.spilled[0]); err != nil {
+			return
+		}
+		b.spilled = b.spilled[1:]
+	}
+}
+
+// Close closes the sink and the fallback
+func (b *CircuitBreakerSink) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.Sink.Close()
+	if b.Fallback != nil {
+		if ferr := b.Fallback.Close(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package applogger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakySink fails while failing is set
+type flakySink struct {
+	memorySink
+	failing bool
+	delay   time.Duration
+}
+
+func (f *flakySink) Write(e Entry) error {
+	time.Sleep(f.delay)
+	if f.failing {
+		return errors.New("backend down")
+	}
+	return f.memorySink.Write(e)
+}
+
+func TestCircuitBreakerSink(t *testing.T) {
+	now := time.Date(2020, 8, 23, 10, 0, 0, 0, time.UTC)
+	backend := &flakySink{failing: true}
+	b := &CircuitBreakerSink{Sink: backend, Threshold: 2, Cooldown: time.Minute, Spill: 2}
+	b.now = func() time.Time { return now }
+
+	for _, m := range []string{"1", "2", "3", "4"} {
+		if err := b.Write(Entry{Message: m}); err != nil {
+			t.Fatalf("spilled writes should not fail: %v", err)
+		}
+	}
+	if b.State() != CircuitOpen {
+		t.Fatalf("expected the circuit to be open, got %s", b.State())
+	}
+
+	// still failing when half-open, the circuit opens again
+	now = now.Add(time.Minute)
+	if b.State() != CircuitHalfOpen {
+		t.Fatalf("expected half-open after the cooldown, got %s", b.State())
+	}
+	b.Write(Entry{Message: "5"})
+	if b.State() != CircuitOpen {
+		t.Fatalf("a failed trial should open the circuit, got %s", b.State())
+	}
+
+	now = now.Add(time.Minute)
+	backend.failing = false
+	b.Write(Entry{Message: "6"})
+	if b.State() != CircuitClosed {
+		t.Fatalf("a good trial should close the circuit, got %s", b.State())
+	}
+	var got []string
+	for _, e := range backend.entries {
+		got = append(got, e.Message)
+	}
+	if len(got) != 3 || got[0] != "6" || got[1] != "4" || got[2] != "5" {
+		t.Fatalf("expected the trial then the last 2 spilled entries, got %v", got)
+	}
+}
+
+func TestCircuitBreakerTimeoutAndFallback(t *testing.T) {
+	backend := &flakySink{delay: 200 * time.Millisecond}
+	fallback := &memorySink{}
+	b := &CircuitBreakerSink{Sink: backend, Timeout: 10 * time.Millisecond, Threshold: 1, Fallback: fallback}
+
+	start := time.Now()
+	b.Write(Entry{Message: "slow"})
+	b.Write(Entry{Message: "routed"})
+	if time.Since(start) > 150*time.Millisecond {
+		t.Fatalf("writes were not bounded by the timeout")
+	}
+	if len(fallback.entries) != 2 || b.State() != CircuitOpen {
+		t.Fatalf("expected both entries in the fallback and an open circuit, got %d %s", len(fallback.entries), b.State())
+	}
+	time.Sleep(250 * time.Millisecond)
+}
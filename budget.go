@@ -0,0 +1,186 @@
+package applogger
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DiskBudgetInterval is how often the disk usage of a logger with a
+// DiskBudget is checked
+var DiskBudgetInterval = 10 * time.Second
+
+// diskBudget keeps the files at path and errorPath and their rotated
+// files under limit bytes. The oldest rotated files are removed first, when the active file
+// alone is over the limit Debug and Trace entries are dropped until it is
+// back under.
+type diskBudget struct {
+	path  string
+	limit int64
+	// errorPath is the live ErrorPath, counted with its rotated files and
+	// never taken for a rotated file of path
+	errorPath string
+
+	paused  atomic.Bool
+	dropped atomic.Uint64
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newDiskBudget(path, errorPath string, limit int64) *diskBudget {
+	b := &diskBudget{path: path, errorPath: errorPath, limit: limit, stop: make(chan struct{}), done: make(chan struct{})}
+	b.check()
+	go b.run()
+	return b
+}
+
+func (b *diskBudget) run() {
+	defer close(b.done)
+	t := time.NewTicker(DiskBudgetInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			b.check()
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// allow reports whether the entry may be written, counting it as dropped
+// when not
+func (b *diskBudget) allow(e Entry) bool {
+	if b.paused.Load() && levelOf(e.Level) <= LevelDebug {
+		b.dropped.Add(1)
+		return false
+	}
+	return true
+}
+
+// live returns the files being written to
+func (b *diskBudget) live() []string {
+	if b.errorPath == "" || excluded(b.errorPath, []string{b.path}) {
+		return []string{b.path}
+	}
+	return []string{b.path, b.errorPath}
+}
+
+// rotated returns the rotated files of the log and of the error log,
+// oldest first
+func (b *diskBudget) rotated() []string {
+	files := rotatedFiles(b.path, b.errorPath)
+	if len(b.live()) > 1 {
+		files = append(files, rotatedFiles(b.errorPath, b.path)...)
+		sortByModTime(files)
+	}
+	return files
+}
+
+// rotatedInfix matches what rotated files put between the base and the
+// extension of the log: the start of their period, with a counter when
+// the name was taken, or the index of a size backup
+var rotatedInfix = regexp.MustCompile(`^(\d+|\d{4}-\d{2}-\d{2}(T\d{2}-\d{2})?(\.\d+)?)$`)
+
+// rotatedFiles returns the rotated files of the log at path, by time or
+// size and compressed or not, oldest first. Only the names rotation makes
+// are returned, never path or exclude (the live ErrorPath).
+func rotatedFiles(path string, exclude ...string) []string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	matches, _ := filepath.Glob(base + ".*" + ext)
+	compressed, _ := filepath.Glob(base + ".*" + ext + ".gz")
+	matches = append(matches, compressed...)
+	files := matches[:0]
+	for _, m := range matches {
+		infix := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(m), ".gz"), ext)
+		infix = strings.TrimPrefix(infix, filepath.Base(base)+".")
+		if m == filepath.Clean(path) || !rotatedInfix.MatchString(infix) || excluded(m, exclude) {
+			continue
+		}
+		files = append(files, m)
+	}
+	sortByModTime(files)
+	return files
+}
+
+// sortByModTime sorts files oldest first
+func sortByModTime(files []string) {
+	modTime := func(path string) time.Time {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}
+		}
+		return fi.ModTime()
+	}
+	sort.SliceStable(files, func(i, j int) bool { return modTime(files[i]).Before(modTime(files[j])) })
+}
+
+// excluded reports whether path is one of paths, by name or as the same
+// file
+func excluded(path string, paths []string) bool {
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if filepath.Clean(p) == filepath.Clean(path) {
+			return true
+		}
+		a, aerr := os.Stat(p)
+		b, berr := os.Stat(path)
+		if aerr == nil && berr == nil && os.SameFile(a, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// check measures the log and the error log with their rotated files and
+// sidecars, removes
+// rotated files until it fits and pauses Debug when it still does not
+func (b *diskBudget) check() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	size := func(path string) int64 {
+		var n int64
+		for _, p := range []string{path, path + IndexSuffix} {
+			if fi, err := os.Stat(p); err == nil {
+				n += fi.Size()
+			}
+		}
+		return n
+	}
+
+	rotated := b.rotated()
+	var total int64
+	for _, p := range b.live() {
+		total += size(p)
+	}
+	for _, p := range rotated {
+		total += size(p)
+	}
+	for _, p := range rotated {
+		if total <= b.limit {
+			break
+		}
+		n := size(p)
+		if os.Remove(p) == nil {
+			os.Remove(p + IndexSuffix)
+			total -= n
+		}
+	}
+	b.paused.Store(total > b.limit)
+}
+
+func (b *diskBudget) Close() {
+	close(b.stop)
+	<-b.done
+}
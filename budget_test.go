@@ -0,0 +1,77 @@
+package applogger
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskBudget(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	old := time.Now().Add(-3 * time.Hour)
+	for i, day := range []string{"2020-08-21", "2020-08-22", "2020-08-23"} {
+		name := "./tmp/app." + day + ".ndjson"
+		os.WriteFile(name, []byte(strings.Repeat("x", 400)), 0666)
+		os.Chtimes(name, old.Add(time.Duration(i)*time.Hour), old.Add(time.Duration(i)*time.Hour))
+	}
+
+	logger := AppLogger{Path: "./tmp/app.ndjson", DiskBudget: 1000}
+	logger.Initialise()
+	defer logger.Close()
+
+	if fileExists("./tmp/app.2020-08-21.ndjson") || !fileExists("./tmp/app.2020-08-22.ndjson") || !fileExists("./tmp/app.2020-08-23.ndjson") {
+		t.Fatal("expected only the oldest rotated file to be removed")
+	}
+
+	logger.LogFields("INFO", "main", "app", "big", map[string]interface{}{"blob": strings.Repeat("y", 1200)})
+	logger.budget.check()
+	if fileExists("./tmp/app.2020-08-22.ndjson") || fileExists("./tmp/app.2020-08-23.ndjson") {
+		t.Fatal("expected every rotated file to be removed")
+	}
+
+	logger.Log("DEBUG", "main", "app", "paused")
+	logger.Log("INFO", "main", "app", "still written")
+	if logger.Dropped() != 1 {
+		t.Fatalf("expected the debug entry to be dropped, got %d drops", logger.Dropped())
+	}
+	b, _ := os.ReadFile("./tmp/app.ndjson")
+	if strings.Contains(string(b), "paused") || !strings.Contains(string(b), "still written") {
+		t.Fatalf("unexpected log content %q", b)
+	}
+}
+
+func TestDiskBudgetErrorPath(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	old := time.Now().Add(-3 * time.Hour)
+	for i, name := range []string{"./tmp/errors.2020-08-21.ndjson", "./tmp/app.2020-08-22.ndjson"} {
+		os.WriteFile(name, []byte(strings.Repeat("x", 400)), 0666)
+		os.Chtimes(name, old.Add(time.Duration(i)*time.Hour), old.Add(time.Duration(i)*time.Hour))
+	}
+	os.WriteFile("./tmp/errors.ndjson", []byte(strings.Repeat("e", 300)), 0666)
+
+	logger := AppLogger{Path: "./tmp/app.ndjson", ErrorPath: "./tmp/errors.ndjson", DiskBudget: 1000}
+	logger.Initialise()
+	defer logger.Close()
+
+	if fileExists("./tmp/errors.2020-08-21.ndjson") || !fileExists("./tmp/app.2020-08-22.ndjson") {
+		t.Fatal("expected the oldest rotated error file to be removed")
+	}
+	if !fileExists("./tmp/errors.ndjson") {
+		t.Fatal("expected the live error file to be kept")
+	}
+
+	os.WriteFile("./tmp/errors.ndjson", []byte(strings.Repeat("e", 1200)), 0666)
+	logger.budget.check()
+	if fileExists("./tmp/app.2020-08-22.ndjson") {
+		t.Fatal("expected the rotated log file to be removed")
+	}
+	logger.Log("DEBUG", "main", "app", "paused")
+	if logger.Dropped() != 1 {
+		t.Fatalf("expected the error file to pause debug entries, got %d drops", logger.Dropped())
+	}
+}
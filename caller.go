@@ -0,0 +1,106 @@
+package applogger
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CallerKey is the attribute written by Caller
+const CallerKey = "caller"
+
+// callerCache maps a program counter to its package and function, so a
+// call site only pays for runtime.FuncForPC and the parsing once. A map
+// behind a lock, unlike a sync.Map, does not box the program counter on
+// every lookup.
+var callerCache = struct {
+	sync.RWMutex
+	m map[uintptr]callerInfo
+}{m: map[uintptr]callerInfo{}}
+
+type callerInfo struct {
+	pkg, fn string
+}
+
+// getCallerInfo returns the package path and the function name of the
+// caller skip frames above it, e.g. github.com/acme/svc and (*Server).Run,
+// for the methods that do not take them as arguments
+func getCallerInfo(skip int) (string, string) {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return "", ""
+	}
+	pc := pcs[0]
+	callerCache.RLock()
+	info, ok := callerCache.m[pc]
+	callerCache.RUnlock()
+	if ok {
+		return info.pkg, info.fn
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	pkg, fn := splitFuncName(frame.Function)
+	callerCache.Lock()
+	callerCache.m[pc] = callerInfo{pkg, fn}
+	callerCache.Unlock()
+	return pkg, fn
+}
+
+// splitFuncName splits a qualified function name at the first dot after
+// the last slash
+func splitFuncName(name string) (string, string) {
+	slash := strings.LastIndexByte(name, '/')
+	dot := strings.IndexByte(name[slash+1:], '.')
+	if dot < 0 {
+		return name, ""
+	}
+	return name[:slash+1+dot], name[slash+2+dot:]
+}
+
+// callerLines maps a program counter to the file and line of Caller, ""
+// when all its frames are in the package, so a call site only pays for
+// the symbolization once like with callerCache
+var callerLines = struct {
+	sync.RWMutex
+	m map[uintptr]string
+}{m: map[uintptr]string{}}
+
+// callerLine returns the file, with its directory, and the line of the
+// first frame outside the package, the tests of the package count as
+// outside
+func callerLine() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	for _, pc := range pcs[:n] {
+		callerLines.RLock()
+		line, ok := callerLines.m[pc]
+		callerLines.RUnlock()
+		if !ok {
+			line = frameLine(pc)
+			callerLines.Lock()
+			callerLines.m[pc] = line
+			callerLines.Unlock()
+		}
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// frameLine returns the file and line of the first of the frames at pc,
+// more than one when calls were inlined, that is outside the package
+func frameLine(pc uintptr) string {
+	frames := runtime.CallersFrames([]uintptr{pc})
+	for {
+		frame, more := frames.Next()
+		if pkg, _ := splitFuncName(frame.Function); pkg != "github.com/junkd0g/applogger" || strings.HasSuffix(frame.File, "_test.go") {
+			dir := filepath.Base(filepath.Dir(frame.File))
+			return dir + "/" + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package applogger
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGetCallerInfo(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		pkg, fn := getCallerInfo(0)
+		if pkg != "github.com/junkd0g/applogger" || fn != "TestGetCallerInfo" {
+			t.Fatalf("got %s %s", pkg, fn)
+		}
+	}
+	cases := map[string][2]string{
+		"github.com/acme/svc/payments.(*Server).Charge": {"github.com/acme/svc/payments", "(*Server).Charge"},
+		"main.main.func1": {"main", "main.func1"},
+	}
+	for name, want := range cases {
+		if pkg, fn := splitFuncName(name); pkg != want[0] || fn != want[1] {
+			t.Errorf("splitFuncName(%s) = %s %s", name, pkg, fn)
+		}
+	}
+}
+
+func BenchmarkGetCallerInfo(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		getCallerInfo(0)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Caller: true, Sinks: []Sink{mem}}
+	logger.Initialise()
+	defer logger.Close()
+
+	_, _, line, _ := runtime.Caller(0)
+	logger.Log("INFO", "main", "app", "log")
+	logger.Info(context.Background(), "kv")
+	logger.Infof(context.Background(), "printf %d", 1)
+
+	if len(mem.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(mem.entries))
+	}
+	for i, e := range mem.entries {
+		caller, _ := e.Lookup(CallerKey)
+		if want := "/caller_test.go:" + strconv.Itoa(line+1+i); !strings.HasSuffix(caller.(string), want) {
+			t.Fatalf("expected caller ending in %s, got %v", want, caller)
+		}
+	}
+}
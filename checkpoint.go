@@ -0,0 +1,112 @@
+package applogger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CheckpointSuffix is appended to the log path to name the sidecar holding
+// the offsets of its consumers
+const CheckpointSuffix = ".offsets"
+
+// checkpointMu serializes read-modify-write cycles of the sidecars within
+// the process
+var checkpointMu sync.Mutex
+
+// Checkpoint stores how far a named consumer has read a log, so a shipper
+// built on Follow can resume exactly where it left off:
+//
+//	cp := applogger.NewCheckpoint("/var/log/app.ndjson", "s3-shipper")
+//	offset, _ := cp.Offset()
+//	f, _ := applogger.Follow("/var/log/app.ndjson", offset)
+//	for {
+//		e, err := f.Next(ctx)
+//		...ship e...
+//		cp.Commit(f.Offset())
+//	}
+type Checkpoint struct {
+	path     string
+	consumer string
+}
+
+// NewCheckpoint returns the checkpoint of consumer for the log at path
+func NewCheckpoint(path string, consumer string) *Checkpoint {
+	return &Checkpoint{path: path + CheckpointSuffix, consumer: consumer}
+}
+
+// Offset returns the committed offset, 0 when nothing was committed yet
+func (c *Checkpoint) Offset() (int64, error) {
+	checkpointMu.Lock()
+	defer checkpointMu.Unlock()
+	offsets, err := c.load()
+	return offsets[c.consumer], err
+}
+
+// Commit saves the offset. The sidecar is synced and replaced atomically
+// so a crash never leaves it half written or loses a committed offset.
+func (c *Checkpoint) Commit(offset int64) error {
+	checkpointMu.Lock()
+	defer checkpointMu.Unlock()
+	offsets, err := c.load()
+	if err != nil {
+		return err
+	}
+	offsets[c.consumer] = offset
+
+	b, err := json.Marshal(offsets)
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := writeSynced(tmp, b); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	// not every system can sync a directory, the rename stands either way
+	syncDir(filepath.Dir(c.path))
+	return nil
+}
+
+// writeSynced writes b to the file at path and syncs it to disk
+func writeSynced(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir syncs the directory at path, so a rename in it survives a crash
+func syncDir(path string) error {
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (c *Checkpoint) load() (map[string]int64, error) {
+	offsets := make(map[string]int64)
+	b, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return offsets, nil
+	}
+	if err != nil {
+		return offsets, err
+	}
+	err = json.Unmarshal(b, &offsets)
+	return offsets, err
+}
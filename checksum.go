@@ -0,0 +1,68 @@
+package applogger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+)
+
+// ChecksumKey is the last key of a line written with Checksum set
+const ChecksumKey = "checksum"
+
+// Checksum selects the integrity checksum added to every NDJSON line
+type Checksum int
+
+const (
+	ChecksumNone Checksum = iota
+	// ChecksumCRC32 is cheap and catches truncated and torn lines
+	ChecksumCRC32
+	// ChecksumSHA256 also makes deliberate edits hard to hide
+	ChecksumSHA256
+)
+
+// checksumPrefix starts the checksum field at the end of a line
+var checksumPrefix = []byte(`,"` + ChecksumKey + `":"`)
+
+// appendChecksum adds the checksum of line, an encoded entry, as its last
+// field. The checksum covers the line exactly as it would be without that
+// field.
+func appendChecksum(line []byte, kind Checksum) []byte {
+	sum := checksumOf(line, kind)
+	out := make([]byte, 0, len(line)+len(checksumPrefix)+len(sum)+2)
+	out = append(out, line[:len(line)-1]...)
+	out = append(out, checksumPrefix...)
+	out = append(out, sum...)
+	return append(out, '"', '}')
+}
+
+func checksumOf(line []byte, kind Checksum) string {
+	if kind == ChecksumSHA256 {
+		sum := sha256.Sum256(line)
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return fmt.Sprintf("crc32:%08x", crc32.ChecksumIEEE(line))
+}
+
+// VerifyChecksum checks the checksum of a line written with Checksum set.
+// It returns an error when the line has no checksum or it does not match,
+// which happens to lines cut short by a crash or a full disk.
+func VerifyChecksum(line []byte) error {
+	line = bytes.TrimRight(line, "\r\n")
+	i := bytes.LastIndex(line, checksumPrefix)
+	if i < 0 || !bytes.HasSuffix(line, []byte(`"}`)) || i+len(checksumPrefix) > len(line)-2 {
+		return fmt.Errorf("applogger: line has no checksum")
+	}
+	sum := string(line[i+len(checksumPrefix) : len(line)-2])
+
+	canonical := append(line[:i:i], '}')
+	kind := ChecksumCRC32
+	if bytes.HasPrefix([]byte(sum), []byte("sha256:")) {
+		kind = ChecksumSHA256
+	}
+	if got := checksumOf(canonical, kind); got != sum {
+		return fmt.Errorf("applogger: checksum mismatch, line has %s but is %s", sum, got)
+	}
+	return nil
+}
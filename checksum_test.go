@@ -0,0 +1,44 @@
+package applogger
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	for _, kind := range []Checksum{ChecksumCRC32, ChecksumSHA256} {
+		logger := AppLogger{Path: "./tmp/checksum.ndjson", Checksum: kind}
+		logger.Initialise()
+		logger.LogFields("INFO", "main", "app", "hello", map[string]interface{}{"user_id": 42})
+		logger.Close()
+	}
+
+	b, err := os.ReadFile("./tmp/checksum.ndjson")
+	if err != nil {
+		t.Fatalf("Couldn't read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if !strings.Contains(lines[0], `"checksum":"crc32:`) || !strings.Contains(lines[1], `"checksum":"sha256:`) {
+		t.Fatalf("unexpected lines:\n%s", b)
+	}
+	for _, l := range lines {
+		if err := isJSON(l); err != nil {
+			t.Fatalf("%q is not json: %v", l, err)
+		}
+		if err := VerifyChecksum([]byte(l)); err != nil {
+			t.Fatalf("valid line failed verification: %v", err)
+		}
+		corrupted := bytes.Replace([]byte(l), []byte("hello"), []byte("hellO"), 1)
+		if err := VerifyChecksum(corrupted); err == nil {
+			t.Fatalf("corrupted line passed verification: %s", corrupted)
+		}
+		if err := VerifyChecksum([]byte(l[:len(l)/2])); err == nil {
+			t.Fatal("truncated line passed verification")
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package applogger
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+)
+
+// ForwardFDEnv tells a child process started by StartChild which inherited
+// file descriptor to forward its entries to
+const ForwardFDEnv = "APPLOGGER_FORWARD_FD"
+
+// ChildPIDKey is the attribute holding the pid of the child process an
+// ingested entry comes from
+const ChildPIDKey = "child_pid"
+
+// maxForwardFrame bounds the size of a forwarded entry, a larger length
+// means the stream is corrupt
+const maxForwardFrame = 16 << 20
+
+// ForwardSink writes every entry as a frame of a 4 byte big endian length
+// followed by the JSON entry, the protocol read by Ingest
+type ForwardSink struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewForwardSink returns a sink framing entries onto w
+func NewForwardSink(w io.Writer) *ForwardSink {
+	return &ForwardSink{w: w}
+}
+
+// Write encodes the entry and writes it as one frame
+func (s *ForwardSink) Write(e Entry) error {
+	b, err := JSONEncoder{}.Encode(e)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 4+len(b))
+	binary.BigEndian.PutUint32(frame, uint32(len(b)))
+	copy(frame[4:], b)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(frame)
+	return err
+}
+
+// Close closes the writer when it is an io.Closer
+func (s *ForwardSink) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// NewChildLogger returns the logger of a worker process. Started by
+// StartChild, it forwards every entry to the parent through the inherited
+// pipe, otherwise it is NewLogger("") writing to stdout.
+func NewChildLogger() (*AppLogger, error) {
+	env := os.Getenv(ForwardFDEnv)
+	if env == "" {
+		return NewLogger("")
+	}
+	fd, err := strconv.Atoi(env)
+	if err != nil {
+		return nil, fmt.Errorf("applogger: %s: %w", ForwardFDEnv, err)
+	}
+	logger := &AppLogger{Path: os.DevNull, Format: FormatJSON, Sinks: []Sink{NewForwardSink(os.NewFile(uintptr(fd), "applogger-forward"))}}
+	if err := logger.open(); err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+// StartChild starts cmd with a pipe for NewChildLogger in the child and
+// ingests what comes through it, see Ingest. The returned wait waits for
+// the process and for its last entries to be written.
+func (r AppLogger) StartChild(cmd *exec.Cmd) (wait func() error, err error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, pw)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%d", ForwardFDEnv, 2+len(cmd.ExtraFiles)))
+	if err := cmd.Start(); err != nil {
+		pr.Close()
+		pw.Close()
+		return nil, err
+	}
+	pw.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Ingest(pr, cmd.Process.Pid)
+		pr.Close()
+	}()
+	return func() error {
+		err := cmd.Wait()
+		if ierr := <-done; err == nil {
+			err = ierr
+		}
+		return err
+	}, nil
+}
+
+// Ingest reads frames written by a ForwardSink until EOF and logs each
+// entry again with the child_pid attribute, keeping its pid, level, time
+// and attributes
+func (r AppLogger) Ingest(rd io.Reader, childPID int) error {
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(rd, size[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n > maxForwardFrame {
+			return fmt.Errorf("applogger: forwarded entry of %d bytes, stream is corrupt", n)
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(rd, b); err != nil {
+			return err
+		}
+		var le LogEntry
+		if err := json.Unmarshal(b, &le); err != nil {
+			return fmt.Errorf("applogger: forwarded entry: %w", err)
+		}
+		e := Entry{PID: le.PID, Level: le.Level, Package: le.Package, Func: le.Func, Message: le.Message, Time: le.Time,
+			HTTP: le.Code != 0, Code: le.Code, Duration: le.Duration}
+		r.logInternal(e, append(appendMap(nil, le.Attributes), attr{ChildPIDKey, childPID}))
+	}
+}
@@ -0,0 +1,70 @@
+package applogger
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestForwardAndIngest(t *testing.T) {
+	var buf bytes.Buffer
+	parent := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	parent.Initialise()
+
+	var pipe bytes.Buffer
+	child := AppLogger{Path: "/dev/null", Sinks: []Sink{NewForwardSink(&pipe)}}
+	child.Initialise()
+	child.LogFields("WARN", "worker", "run", "slow batch", map[string]interface{}{"batch": 3})
+	child.LogHTTP("INFO", "worker", "fetch", "fetched", 200, 0.1)
+
+	if err := parent.Ingest(&pipe, 4242); err != nil {
+		t.Fatal(err)
+	}
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %v", entries)
+	}
+	first := entries[0]["attributes"].(map[string]interface{})
+	if entries[0]["level"] != "WARN" || first[ChildPIDKey] != float64(4242) || first["batch"] != float64(3) {
+		t.Fatalf("unexpected entry %v", entries[0])
+	}
+	if entries[1]["code"] != float64(200) {
+		t.Fatalf("HTTP fields lost %v", entries[1])
+	}
+}
+
+// TestChildProcess is run as the child by TestStartChild
+func TestChildProcess(t *testing.T) {
+	if os.Getenv(ForwardFDEnv) == "" {
+		t.Skip("only run as a child process")
+	}
+	logger, err := NewChildLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Log("INFO", "worker", "main", "hello from the child")
+	logger.Close()
+}
+
+func TestStartChild(t *testing.T) {
+	var buf bytes.Buffer
+	parent := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	parent.Initialise()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestChildProcess$")
+	wait, err := parent.StartChild(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+	entries := decodeLines(t, &buf)
+	if len(entries) != 1 || entries[0]["message"] != "hello from the child" {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	if entries[0]["attributes"].(map[string]interface{})[ChildPIDKey] != float64(cmd.Process.Pid) {
+		t.Fatalf("missing child pid %v", entries[0])
+	}
+}
@@ -0,0 +1,278 @@
+// Command applogger works with the ndjson logs written by the applogger
+// package.
+//
+//	applogger convert -to csv|parquet [-columns time,level,attributes.user_id] [-o out] [file]
+//	applogger filter [-since time] [-until time] 'level>=warn && message~"timeout"' [file]
+//	applogger replay -sink name [-options json] [-filter expression] [-speed n] [file]
+//	applogger doctor [-json] [-timeout d] config.json
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/junkd0g/applogger"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "convert":
+		err = convert(os.Args[2:])
+	case "filter":
+		err = filter(os.Args[2:])
+	case "replay":
+		err = replay(os.Args[2:])
+	case "doctor":
+		err = doctor(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "applogger:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: applogger convert -to csv|parquet [-columns a,b] [-o out] [file]")
+	fmt.Fprintln(os.Stderr, "       applogger filter [-since time] [-until time] [-skip-corrupt] expression [file]")
+	fmt.Fprintln(os.Stderr, "       applogger replay -sink name [-options json] [-filter expression] [-speed n] [file]")
+	fmt.Fprintln(os.Stderr, "       applogger doctor [-json] [-timeout d] config.json")
+	os.Exit(2)
+}
+
+func convert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := fs.String("to", "csv", "output format, csv or parquet")
+	columns := fs.String("columns", "", "comma separated columns, attributes as attributes.<key>")
+	output := fs.String("o", "", "output file, stdout when empty")
+	fs.Parse(args)
+
+	in, err := openInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var cols []string
+	if *columns != "" {
+		cols = strings.Split(*columns, ",")
+	}
+
+	switch *to {
+	case "csv":
+		return applogger.ConvertCSV(out, in, cols)
+	case "parquet":
+		return applogger.ConvertParquet(out, in, cols)
+	}
+	return fmt.Errorf("unknown output format %q", *to)
+}
+
+// filter prints the lines of the log matching the expression. With -since
+// and an index sidecar next to the file, reading starts close to the first
+// matching entry instead of at the start of the file.
+func filter(args []string) error {
+	fs := flag.NewFlagSet("filter", flag.ExitOnError)
+	since := fs.String("since", "", "only entries at or after this RFC 3339 time")
+	until := fs.String("until", "", "only entries before this RFC 3339 time")
+	skipCorrupt := fs.Bool("skip-corrupt", false, "report lines that are not entries on stderr and go on")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		usage()
+	}
+
+	var conditions []string
+	if *since != "" {
+		conditions = append(conditions, "time>="+*since)
+	}
+	if *until != "" {
+		conditions = append(conditions, "time<"+*until)
+	}
+	if expr := strings.TrimSpace(fs.Arg(0)); expr != "" {
+		conditions = append(conditions, "("+expr+")")
+	}
+
+	in, err := openInput(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if f, ok := in.(*os.File); ok && *since != "" {
+		t, err := time.Parse(time.RFC3339Nano, *since)
+		if err != nil {
+			return err
+		}
+		offset, err := applogger.IndexOffset(f.Name(), t)
+		if err != nil {
+			return err
+		}
+		if offset > 0 {
+			if _, err := f.Seek(offset, io.SeekStart); err != nil {
+				return err
+			}
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	reader := applogger.NewReader(in)
+	if len(conditions) > 0 {
+		f, err := applogger.ParseFilter(strings.Join(conditions, " && "))
+		if err != nil {
+			return err
+		}
+		reader.SetFilter(f)
+	}
+	if *skipCorrupt {
+		reader.SkipCorrupt(func(err *applogger.CorruptLineError) {
+			fmt.Fprintln(os.Stderr, err)
+		})
+	}
+	for {
+		_, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		out.Write(reader.Line())
+		out.WriteByte('\n')
+	}
+}
+
+// replay re-emits the entries of a log into a registered sink
+func replay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	sinkName := fs.String("sink", "", "registered sink to replay into, e.g. stdout or file")
+	options := fs.String("options", "{}", "JSON options of the sink")
+	expr := fs.String("filter", "", "only replay entries matching this expression")
+	speed := fs.Float64("speed", 0, "pace relative to the original timing, 0 for no pacing")
+	fs.Parse(args)
+	if *sinkName == "" {
+		usage()
+	}
+
+	var opts map[string]interface{}
+	if err := json.Unmarshal([]byte(*options), &opts); err != nil {
+		return err
+	}
+	sink, err := applogger.NewSink(*sinkName, opts)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	replayOptions := applogger.ReplayOptions{Speed: *speed}
+	if *expr != "" {
+		if replayOptions.Filter, err = applogger.ParseFilter(*expr); err != nil {
+			return err
+		}
+	}
+
+	in, err := openInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	n, err := applogger.Replay(context.Background(), in, sink, replayOptions)
+	fmt.Fprintf(os.Stderr, "replayed %d entries\n", n)
+	return err
+}
+
+// doctor validates a config file and prints the outcome of every check,
+// failing when one of them did
+func doctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	timeout := fs.Duration("timeout", 10*time.Second, "time allowed for reaching the remote sinks")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	cfg, err := applogger.LoadConfig(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	report := applogger.Doctor(ctx, cfg)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range report.Checks {
+			switch {
+			case !c.OK:
+				fmt.Printf("FAIL  %s: %s\n", c.Name, c.Error)
+			case c.Detail != "":
+				fmt.Printf("ok    %s: %s\n", c.Name, c.Detail)
+			default:
+				fmt.Printf("ok    %s\n", c.Name)
+			}
+		}
+	}
+	if !report.OK {
+		return fmt.Errorf("doctor: %s failed", fs.Arg(0))
+	}
+	return nil
+}
+
+// openInput opens the file at path, stdin when path is empty or "-". Files
+// ending in .gz are decompressed.
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "" || path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	f, err := os.Open(path)
+	if err != nil || !strings.HasSuffix(path, ".gz") {
+		return f, err
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return gzipInput{gz, f}, nil
+}
+
+// gzipInput closes the decompressor and the file under it
+type gzipInput struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g gzipInput) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
@@ -0,0 +1,90 @@
+package applogger
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String returns the attribute key as a string. Numbers are written
+// without an exponent when they are whole, objects and arrays as JSON.
+func (e LogEntry) String(key string) (string, bool) {
+	v, ok := e.Attributes[key]
+	if !ok || v == nil {
+		return "", false
+	}
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(x), true
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// Int returns the attribute key as an int, from a whole number or a string
+// holding one
+func (e LogEntry) Int(key string) (int, bool) {
+	f, ok := e.Float(key)
+	if !ok || f != math.Trunc(f) || f > math.MaxInt || f < math.MinInt {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// Float returns the attribute key as a float64, from a number or a string
+// holding one
+func (e LogEntry) Float(key string) (float64, bool) {
+	switch x := e.Attributes[key].(type) {
+	case float64:
+		return x, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Bool returns the attribute key as a bool, from a bool or a string like
+// "true" or "0"
+func (e LogEntry) Bool(key string) (bool, bool) {
+	switch x := e.Attributes[key].(type) {
+	case bool:
+		return x, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(x))
+		return b, err == nil
+	}
+	return false, false
+}
+
+// DurationAttr returns the attribute key as a duration, from a string like
+// "1.5s" or a number in the unit of a _ns, _us, _ms or _s key suffix, else ns
+func (e LogEntry) DurationAttr(key string) (time.Duration, bool) {
+	if s, ok := e.Attributes[key].(string); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		return d, err == nil
+	}
+	f, ok := e.Float(key)
+	if !ok {
+		return 0, false
+	}
+	unit := time.Nanosecond
+	switch {
+	case strings.HasSuffix(key, "_us"):
+		unit = time.Microsecond
+	case strings.HasSuffix(key, "_ms"):
+		unit = time.Millisecond
+	case strings.HasSuffix(key, "_s"), strings.HasSuffix(key, "_seconds"):
+		unit = time.Second
+	}
+	return time.Duration(f * float64(unit)), true
+}
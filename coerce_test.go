@@ -0,0 +1,40 @@
+package applogger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLogEntryGetters(t *testing.T) {
+	var e LogEntry
+	line := `{"attributes":{"user_id":42,"order":"17","ok":"true","elapsed_ms":1.5,"timeout":"2s","wait":3000,"tags":["a"],"ratio":0.25}}`
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, ok := e.String("user_id"); !ok || s != "42" {
+		t.Fatalf("String(user_id) = %q %v", s, ok)
+	}
+	if s, ok := e.String("tags"); !ok || s != `["a"]` {
+		t.Fatalf("String(tags) = %q %v", s, ok)
+	}
+	if n, ok := e.Int("order"); !ok || n != 17 {
+		t.Fatalf("Int(order) = %d %v", n, ok)
+	}
+	if _, ok := e.Int("ratio"); ok {
+		t.Fatal("Int of a fraction")
+	}
+	if b, ok := e.Bool("ok"); !ok || !b {
+		t.Fatalf("Bool(ok) = %v %v", b, ok)
+	}
+	durations := map[string]time.Duration{"elapsed_ms": 1500 * time.Microsecond, "timeout": 2 * time.Second, "wait": 3 * time.Microsecond}
+	for key, want := range durations {
+		if d, ok := e.DurationAttr(key); !ok || d != want {
+			t.Fatalf("DurationAttr(%s) = %s %v, want %s", key, d, ok, want)
+		}
+	}
+	if _, ok := e.String("missing"); ok {
+		t.Fatal("String of a missing attribute")
+	}
+}
@@ -0,0 +1,39 @@
+package applogger
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// occurrences counts the calls to Once and Every by key, for the whole
+// process
+var occurrences sync.Map // map[string]*atomic.Uint64
+
+func occurrence(key string) uint64 {
+	c, ok := occurrences.Load(key)
+	if !ok {
+		c, _ = occurrences.LoadOrStore(key, new(atomic.Uint64))
+	}
+	return c.(*atomic.Uint64).Add(1)
+}
+
+// Once returns r the first time it is called with key in the process and
+// a logger that writes nothing but Fatal entries, and does not close the
+// outputs it shares with r, afterwards, for warnings that should only appear once:
+//
+//	logger.Once("deprecated-config").Log("WARN", "config", "load", "legacy key used")
+func (r AppLogger) Once(key string) AppLogger {
+	if occurrence("once\x00"+key) != 1 {
+		r.muted, r.derived = true, true
+	}
+	return r
+}
+
+// Every returns r on the first and then every n-th call with key in the
+// process and a logger that writes nothing otherwise, like Once
+func (r AppLogger) Every(key string, n int) AppLogger {
+	if n > 1 && (occurrence("every\x00"+key)-1)%uint64(n) != 0 {
+		r.muted, r.derived = true, true
+	}
+	return r
+}
@@ -0,0 +1,68 @@
+package applogger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOnceAndEvery(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+
+	for i := 0; i < 5; i++ {
+		logger.Once("TestOnceAndEvery").Log("WARN", "main", "app", "once")
+		logger.WithFields(map[string]interface{}{"i": i}).Every("TestOnceAndEvery", 2).Log("INFO", "main", "app", "every")
+	}
+
+	out := buf.String()
+	if n := strings.Count(out, `"message":"once"`); n != 1 {
+		t.Fatalf("Once wrote %d entries", n)
+	}
+	if n := strings.Count(out, `"message":"every"`); n != 3 {
+		t.Fatalf("Every wrote %d entries, want 3", n)
+	}
+	if !strings.Contains(out, `"i":0`) || !strings.Contains(out, `"i":2`) || !strings.Contains(out, `"i":4`) {
+		t.Fatalf("Every wrote the wrong occurrences %s", out)
+	}
+}
+
+func TestOnceMutedCopyDoesNotClose(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}}
+	logger.Initialise()
+	defer logger.Close()
+
+	logger.Once("TestOnceMutedCopyDoesNotClose")
+	logger.Once("TestOnceMutedCopyDoesNotClose").Close()
+	logger.Every("TestOnceMutedCopyDoesNotClose", 2)
+	logger.Every("TestOnceMutedCopyDoesNotClose", 2).Close()
+	if mem.closed || logger.out.isClosed() {
+		t.Fatal("closing a muted copy closed the outputs of the logger")
+	}
+	logger.Log("INFO", "main", "app", "still open")
+	if mem.len() != 1 {
+		t.Fatalf("expected the entry written after the copies were closed, got %d", mem.len())
+	}
+}
+
+func TestMutedCopyWritesFatal(t *testing.T) {
+	code := -1
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}}
+	logger.Initialise()
+	logger.Once("fatal-copy")
+	logger.Once("fatal-copy").Fatal("main", "app", "cannot continue")
+	logger.Every("fatalf-copy", 2)
+	logger.Every("fatalf-copy", 2).Fatalf(context.Background(), "cannot open %s", "db")
+
+	if code != 1 || mem.len() != 2 || mem.entries[0].Message != "cannot continue" || mem.entries[1].Message != "cannot open db" {
+		t.Fatalf("unexpected exit %d and entries %+v", code, mem.entries)
+	}
+}
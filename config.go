@@ -0,0 +1,136 @@
+package applogger
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Config describes a logger in a config file, for example:
+//
+//	{
+//	  "path": "/var/log/app/app.ndjson",
+//	  "format": "json",
+//	  "levels": "info,github.com/acme/svc/payments=debug",
+//	  "sinks": [
+//	    {"type": "stderr", "options": {"encoder": "console"}},
+//	    {"type": "file", "options": {"path": "/var/log/app/audit.ndjson"},
+//	     "delivery": "at-least-once", "queue": "/var/lib/app/audit.queue"}
+//	  ]
+//	}
+type Config struct {
+	Path   string       `json:"path"`
+	Format string       `json:"format"`
+	Sinks  []SinkConfig `json:"sinks"`
+	// Levels is the minimum level per package in the form of ParseLevels
+	Levels string `json:"levels"`
+	// Routes are sinks by name for entries sent there with To or OnlyTo
+	Routes map[string]SinkConfig `json:"routes"`
+	// Rules are the routing, sampling and redaction policy, see Rule
+	Rules []Rule `json:"rules"`
+	// Resource is written on every entry with the otel format
+	Resource map[string]interface{} `json:"resource"`
+}
+
+// SinkConfig names a registered sink and the options given to its factory.
+// Delivery is "at-most-once" (the default) or "at-least-once" with Queue
+// as the on-disk queue, see Delivery.
+type SinkConfig struct {
+	Type     string                 `json:"type"`
+	Options  map[string]interface{} `json:"options"`
+	Delivery string                 `json:"delivery"`
+	Queue    string                 `json:"queue"`
+}
+
+// LoadConfig reads a JSON config file
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(b, &cfg)
+	return cfg, err
+}
+
+// NewFromConfig builds the sinks of the config and returns an initialised
+// logger
+func NewFromConfig(cfg Config) (*AppLogger, error) {
+	format, err := ParseFormat(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := &AppLogger{Path: cfg.Path, Format: format, Resource: cfg.Resource, Rules: cfg.Rules}
+	if cfg.Levels != "" {
+		if logger.Levels, err = ParseLevels(cfg.Levels); err != nil {
+			return nil, err
+		}
+	}
+	for _, sc := range cfg.Sinks {
+		s, err := newConfiguredSink(sc)
+		if err != nil {
+			closeSinks(logger.Sinks)
+			return nil, err
+		}
+		logger.Sinks = append(logger.Sinks, s)
+	}
+	for name, sc := range cfg.Routes {
+		s, err := newConfiguredSink(sc)
+		if err != nil {
+			closeSinks(logger.Sinks)
+			closeRoutes(logger.Routes)
+			return nil, err
+		}
+		if logger.Routes == nil {
+			logger.Routes = make(map[string]Sink)
+		}
+		logger.Routes[name] = s
+	}
+
+	if err := logger.open(); err != nil {
+		closeSinks(logger.Sinks)
+		closeRoutes(logger.Routes)
+		return nil, err
+	}
+	return logger, nil
+}
+
+// newConfiguredSink builds the sink of sc with its delivery guarantee
+func newConfiguredSink(sc SinkConfig) (Sink, error) {
+	delivery, err := ParseDelivery(sc.Delivery)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSink(sc.Type, sc.Options)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := WithDelivery(s, delivery, sc.Queue)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	return ds, nil
+}
+
+// MustNewFromConfig is like NewFromConfig but panics on error, for main and
+// tests
+func MustNewFromConfig(cfg Config) *AppLogger {
+	logger, err := NewFromConfig(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return logger
+}
+
+func closeSinks(sinks []Sink) {
+	for _, s := range sinks {
+		s.Close()
+	}
+}
+
+func closeRoutes(routes map[string]Sink) {
+	for _, s := range routes {
+		s.Close()
+	}
+}
@@ -0,0 +1,78 @@
+package applogger
+
+import (
+	"context"
+	"time"
+)
+
+// DeadlineKey is the attribute holding the milliseconds left until the
+// context deadline, negative once it has passed
+const DeadlineKey = "ctx_deadline_ms"
+
+type loggerKey struct{}
+
+// IntoContext returns a copy of ctx carrying logger, for FromContext
+func IntoContext(ctx context.Context, logger AppLogger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by IntoContext, e.g. the
+// request scoped logger of the HTTP middleware
+func FromContext(ctx context.Context) (AppLogger, bool) {
+	logger, ok := ctx.Value(loggerKey{}).(AppLogger)
+	return logger, ok
+}
+
+// DetachFields returns a background context with only what applogger keeps
+// in ctx: the logger of IntoContext, the correlation ID, the baggage and
+// the current Operation. Fire-and-forget goroutines started by a request
+// keep its log correlation without being cancelled with it.
+func DetachFields(ctx context.Context) context.Context {
+	detached := context.Background()
+	if ctx == nil {
+		return detached
+	}
+	for _, key := range []interface{}{loggerKey{}, correlationKey{}, baggageKey{}, operationKey{}} {
+		if v := ctx.Value(key); v != nil {
+			detached = context.WithValue(detached, key, v)
+		}
+	}
+	return detached
+}
+
+// LogContext writes an entry like Log, taking attributes from the context
+func (r AppLogger) LogContext(ctx context.Context, level string, logPackage string, logFunc string, message string) {
+	r.LogFieldsContext(ctx, level, logPackage, logFunc, message, nil)
+}
+
+// LogFieldsContext writes an entry like LogFields, taking attributes from
+// the context
+func (r AppLogger) LogFieldsContext(ctx context.Context, level string, logPackage string, logFunc string, message string, fields map[string]interface{}) {
+	if r.disabled(levelOf(level), logPackage) {
+		return
+	}
+
+	s1 := r.now()
+
+	e := Entry{PID: r.newID(), Level: level, Package: logPackage, Func: logFunc, Message: message, Time: s1}
+	r.logInternal(e, r.contextAttrs(ctx, s1, appendMap(nil, fields)))
+}
+
+// contextAttrs appends the attributes derived from ctx to attrs
+func (r AppLogger) contextAttrs(ctx context.Context, now time.Time, attrs []attr) []attr {
+	if ctx == nil {
+		return attrs
+	}
+	if id := CorrelationID(ctx); id != "" {
+		attrs = append(attrs, attr{CorrelationIDKey, id})
+	}
+	for k, v := range Baggage(ctx) {
+		attrs = append(attrs, attr{k, v})
+	}
+	if r.ContextDeadline {
+		if deadline, ok := ctx.Deadline(); ok {
+			attrs = append(attrs, attr{DeadlineKey, deadline.Sub(now).Milliseconds()})
+		}
+	}
+	return attrs
+}
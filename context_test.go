@@ -0,0 +1,70 @@
+package applogger
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContextDeadline(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/deadline.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, ContextDeadline: true}
+	logger.Initialise()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	logger.LogContext(ctx, "INFO", "controller", "perform", "slow query")
+	logger.LogContext(context.Background(), "INFO", "controller", "perform", "no deadline")
+	logger.Close()
+
+	b, _ := os.ReadFile(filePath)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+
+	var first struct {
+		Attributes map[string]float64 `json:"attributes"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if ms := first.Attributes[DeadlineKey]; ms <= 50000 || ms > 60000 {
+		t.Fatalf("unexpected %s %v", DeadlineKey, ms)
+	}
+	if strings.Contains(lines[1], DeadlineKey) {
+		t.Fatalf("entry without a deadline has %s: %s", DeadlineKey, lines[1])
+	}
+}
+
+func TestDetachFields(t *testing.T) {
+	logger := AppLogger{Path: "/dev/null"}
+	logger.Initialise()
+	defer logger.Close()
+
+	type otherKey struct{}
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = IntoContext(ctx, logger.WithFields(map[string]interface{}{"request_id": "r1"}))
+	ctx = WithCorrelationID(ctx, "c1")
+	ctx = WithBaggage(ctx, "tenant_id", "t1")
+	ctx = context.WithValue(ctx, otherKey{}, "x")
+	cancel()
+
+	detached := DetachFields(ctx)
+	if detached.Err() != nil {
+		t.Fatalf("detached context is cancelled: %v", detached.Err())
+	}
+	if _, ok := FromContext(detached); !ok {
+		t.Fatal("logger not carried over")
+	}
+	if CorrelationID(detached) != "c1" || Baggage(detached)["tenant_id"] != "t1" {
+		t.Fatalf("fields not carried over: %q %v", CorrelationID(detached), Baggage(detached))
+	}
+	if detached.Value(otherKey{}) != nil {
+		t.Fatal("unrelated value carried over")
+	}
+}
@@ -0,0 +1,112 @@
+package applogger
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// DefaultColumns are the columns written by the converters when none are
+// given, attributes are added as attributes.<key>
+var DefaultColumns = []string{"time", "level", "package", "func", "message", "code", "duration"}
+
+// ConvertCSV writes the entries of the ndjson log r to w as CSV with a
+// header row, one column per name in columns (DefaultColumns when empty)
+func ConvertCSV(w io.Writer, r io.Reader, columns []string) error {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	reader := NewReader(r)
+	record := make([]string, len(columns))
+	for {
+		e, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for i, c := range columns {
+			record[i] = ""
+			if v, ok := e.Field(c); ok {
+				record[i] = columnString(v)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ConvertParquet writes the entries of the ndjson log r to w as a Parquet
+// file with one row group. time is a TIMESTAMP_MICROS column, code an
+// INT64, duration a DOUBLE and every other column an optional UTF8 string.
+func ConvertParquet(w io.Writer, r io.Reader, columns []string) error {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	pw := newParquetWriter(columns)
+
+	reader := NewReader(r)
+	for {
+		e, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		row := make([]interface{}, len(columns))
+		for i, c := range columns {
+			v, ok := e.Field(c)
+			if !ok {
+				continue
+			}
+			switch pw.columns[i].kind {
+			case parquetTimestamp:
+				row[i] = v.(time.Time).UnixMicro()
+			case parquetInt64:
+				row[i] = int64(v.(int))
+			case parquetDouble:
+				row[i] = v.(float64)
+			default:
+				row[i] = columnString(v)
+			}
+		}
+		pw.add(row)
+	}
+	_, err := w.Write(pw.bytes())
+	return err
+}
+
+// columnString formats a value for a text column, non string attributes
+// are written as JSON
+func columnString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,225 @@
+package applogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testLog = `{"pid":"1","level":"INFO","package":"main","func":"app","message":"started","time":"2020-08-23T10:00:00Z","attributes":{"user_id":"42"}}
+{"pid":"2","level":"ERROR","package":"controller","func":"perform","message":"failed, badly","time":"2020-08-23T10:00:01Z","code":500,"duration":1.5}
+`
+
+func TestConvertCSV(t *testing.T) {
+	var out bytes.Buffer
+	if err := ConvertCSV(&out, strings.NewReader(testLog), []string{"time", "level", "message", "code", "attributes.user_id"}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"time", "level", "message", "code", "attributes.user_id"},
+		{"2020-08-23T10:00:00Z", "INFO", "started", "", "42"},
+		{"2020-08-23T10:00:01Z", "ERROR", "failed, badly", "500", ""},
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Fatalf("row %d: got %v want %v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestConvertParquet(t *testing.T) {
+	var out bytes.Buffer
+	if err := ConvertParquet(&out, strings.NewReader(testLog), nil); err != nil {
+		t.Fatal(err)
+	}
+	b := out.Bytes()
+	if !bytes.HasPrefix(b, []byte("PAR1")) || !bytes.HasSuffix(b, []byte("PAR1")) {
+		t.Fatal("output is not framed as a parquet file")
+	}
+}
+
+// thriftReader reads the thrift compact protocol back, structs as maps
+// from field id to value, for checking what parquetWriter wrote
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) int() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case thriftI32, thriftI64:
+		return r.int()
+	case thriftBinary:
+		n := int(r.uvarint())
+		r.pos += n
+		return r.b[r.pos-n : r.pos]
+	case thriftList:
+		h := r.b[r.pos]
+		r.pos++
+		size := int(h >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(h & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		fields := make(map[int16]interface{})
+		var id int16
+		for {
+			h := r.b[r.pos]
+			r.pos++
+			if h == 0 {
+				return fields
+			}
+			if delta := int16(h >> 4); delta > 0 {
+				id += delta
+			} else {
+				id = int16(r.int())
+			}
+			fields[id] = r.value(h & 0x0f)
+		}
+	}
+	panic("unexpected thrift type")
+}
+
+// readParquetColumn reads back the values of the column chunk at offset,
+// nil for nulls
+func readParquetColumn(t *testing.T, file []byte, offset int64, physical int64) []interface{} {
+	t.Helper()
+	r := &thriftReader{b: file, pos: int(offset)}
+	header := r.value(thriftStruct).(map[int16]interface{})
+	if header[1] != int64(0) {
+		t.Fatalf("expected a data page, got %v", header)
+	}
+	rows := int(header[5].(map[int16]interface{})[1].(int64))
+	page := file[r.pos : r.pos+int(header[3].(int64))]
+
+	n := int(binary.LittleEndian.Uint32(page))
+	levels := &thriftReader{b: page[4 : 4+n]}
+	var defined []bool
+	for levels.pos < n {
+		run := levels.uvarint()
+		if run&1 != 0 {
+			t.Fatal("unexpected bit-packed run of definition levels")
+		}
+		v := levels.b[levels.pos] == 1
+		levels.pos++
+		for i := uint64(0); i < run>>1; i++ {
+			defined = append(defined, v)
+		}
+	}
+	if len(defined) != rows {
+		t.Fatalf("expected %d definition levels, got %d", rows, len(defined))
+	}
+
+	values := page[4+n:]
+	column := make([]interface{}, rows)
+	for i, ok := range defined {
+		if !ok {
+			continue
+		}
+		switch physical {
+		case parquetTypeInt64:
+			column[i] = int64(binary.LittleEndian.Uint64(values))
+			values = values[8:]
+		case parquetTypeDouble:
+			column[i] = math.Float64frombits(binary.LittleEndian.Uint64(values))
+			values = values[8:]
+		case parquetTypeByteArray:
+			l := binary.LittleEndian.Uint32(values)
+			column[i] = string(values[4 : 4+l])
+			values = values[4+l:]
+		}
+	}
+	if len(values) != 0 {
+		t.Fatalf("%d bytes left after the values", len(values))
+	}
+	return column
+}
+
+func TestConvertParquetRoundTrip(t *testing.T) {
+	var out bytes.Buffer
+	if err := ConvertParquet(&out, strings.NewReader(testLog), nil); err != nil {
+		t.Fatal(err)
+	}
+	file := out.Bytes()
+	n := binary.LittleEndian.Uint32(file[len(file)-8:])
+	meta := (&thriftReader{b: file[len(file)-8-int(n) : len(file)-8]}).value(thriftStruct).(map[int16]interface{})
+
+	if meta[3] != int64(2) {
+		t.Fatalf("expected 2 rows, got %v", meta[3])
+	}
+	schema := meta[2].([]interface{})
+	if len(schema) != len(DefaultColumns)+1 || schema[0].(map[int16]interface{})[5] != int64(len(DefaultColumns)) {
+		t.Fatalf("unexpected schema %v", schema)
+	}
+	for i, name := range DefaultColumns {
+		element := schema[i+1].(map[int16]interface{})
+		if string(element[4].([]byte)) != name || element[3] != int64(parquetOptional) {
+			t.Fatalf("unexpected schema element %v for %s", element, name)
+		}
+		if name == "time" && (element[1] != int64(parquetTypeInt64) || element[6] != int64(parquetConvertedTimestampMicros)) {
+			t.Fatalf("time is not a TIMESTAMP_MICROS column: %v", element)
+		}
+	}
+
+	first := time.Date(2020, 8, 23, 10, 0, 0, 0, time.UTC).UnixMicro()
+	want := map[string][]interface{}{
+		"time":     {first, first + 1e6},
+		"level":    {"INFO", "ERROR"},
+		"package":  {"main", "controller"},
+		"func":     {"app", "perform"},
+		"message":  {"started", "failed, badly"},
+		"code":     {nil, int64(500)},
+		"duration": {nil, 1.5},
+	}
+	chunks := meta[4].([]interface{})[0].(map[int16]interface{})[1].([]interface{})
+	for i, name := range DefaultColumns {
+		md := chunks[i].(map[int16]interface{})[3].(map[int16]interface{})
+		if string(md[3].([]interface{})[0].([]byte)) != name || md[5] != int64(2) {
+			t.Fatalf("unexpected column metadata %v for %s", md, name)
+		}
+		got := readParquetColumn(t, file, md[9].(int64), md[1].(int64))
+		for j := range got {
+			if got[j] != want[name][j] {
+				t.Fatalf("column %s: got %v want %v", name, got, want[name])
+			}
+		}
+	}
+}
+
+func TestThriftCompactFieldHeaders(t *testing.T) {
+	var w thriftWriter
+	w.i32(1, 1)
+	w.i64(20, -1)
+	w.stop()
+	// delta header, long form header with zigzag id 20, stop
+	want := []byte{0x15, 0x02, 0x06, 0x28, 0x01, 0x00}
+	if !bytes.Equal(w.buf.Bytes(), want) {
+		t.Fatalf("got % x want % x", w.buf.Bytes(), want)
+	}
+}
@@ -0,0 +1,100 @@
+package applogger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CorrelationIDKey is the attribute holding the correlation ID of the
+// context an entry was logged with
+const CorrelationIDKey = "correlation_id"
+
+// CorrelationIDHeader carries the correlation ID between services
+const CorrelationIDHeader = "X-Correlation-ID"
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	ulidMu   sync.Mutex
+	ulidLast [16]byte
+	ulidMs   uint64
+)
+
+// NewCorrelationID returns a new ULID: 26 characters that sort by creation
+// time, monotonic within the same millisecond
+func NewCorrelationID() string {
+	ms := uint64(time.Now().UnixMilli())
+
+	ulidMu.Lock()
+	var id [16]byte
+	if ms == ulidMs {
+		// same millisecond: increment the random part of the last ID
+		id = ulidLast
+		for i := 15; i >= 6; i-- {
+			id[i]++
+			if id[i] != 0 {
+				break
+			}
+		}
+	} else {
+		binary.BigEndian.PutUint16(id[0:2], uint16(ms>>32))
+		binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+		rand.Read(id[6:])
+	}
+	ulidMs, ulidLast = ms, id
+	ulidMu.Unlock()
+
+	return encodeULID(id)
+}
+
+// encodeULID writes the 128 bits of id as 26 Crockford base32 characters
+func encodeULID(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[0:8])
+	lo := binary.BigEndian.Uint64(id[8:16])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying id, which entries logged
+// with the context record as correlation_id
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID of ctx, "" when there is none
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// InjectCorrelationID sets the correlation ID of the request context on
+// an outgoing request
+func InjectCorrelationID(req *http.Request) {
+	if id := CorrelationID(req.Context()); id != "" {
+		req.Header.Set(CorrelationIDHeader, id)
+	}
+}
+
+// ExtractCorrelationID returns the context of an incoming request with the
+// correlation ID of its header, or a new one when it has none
+func ExtractCorrelationID(req *http.Request) context.Context {
+	id := req.Header.Get(CorrelationIDHeader)
+	if id == "" {
+		id = NewCorrelationID()
+	}
+	return WithCorrelationID(req.Context(), id)
+}
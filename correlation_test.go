@@ -0,0 +1,55 @@
+package applogger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewCorrelationID(t *testing.T) {
+	prev := NewCorrelationID()
+	for i := 0; i < 1000; i++ {
+		id := NewCorrelationID()
+		if len(id) != 26 {
+			t.Fatalf("unexpected length %q", id)
+		}
+		if id <= prev {
+			t.Fatalf("ids are not increasing: %s after %s", id, prev)
+		}
+		prev = id
+	}
+}
+
+func TestCorrelationIDPropagation(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+
+	var seen string
+	h := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = CorrelationID(r.Context())
+		out, _ := http.NewRequestWithContext(r.Context(), "GET", "http://billing/charge", nil)
+		InjectCorrelationID(out)
+		if out.Header.Get(CorrelationIDHeader) != seen {
+			t.Errorf("outgoing header %q want %q", out.Header.Get(CorrelationIDHeader), seen)
+		}
+		logger.LogContext(r.Context(), "INFO", "main", "handler", "handled")
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(CorrelationIDHeader, "01HZX3Q4W5E6R7T8Y9U0I1O2P3")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen != "01HZX3Q4W5E6R7T8Y9U0I1O2P3" {
+		t.Fatalf("correlation ID not read from the header, got %q", seen)
+	}
+	for _, e := range decodeLines(t, &buf) {
+		if e["attributes"].(map[string]interface{})[CorrelationIDKey] != seen {
+			t.Fatalf("entry without the correlation ID %v", e)
+		}
+	}
+	if CorrelationID(context.Background()) != "" {
+		t.Fatal("expected no correlation ID")
+	}
+}
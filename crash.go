@@ -0,0 +1,86 @@
+package applogger
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// runtimeCrashOutput is debug.SetCrashOutput, replaced in tests
+var runtimeCrashOutput = debug.SetCrashOutput
+
+// crashOutput is the file given to the runtime by SetCrashOutput, kept so
+// that RedirectStderr can give it back when it is done
+var crashOutput struct {
+	sync.Mutex
+	file *os.File
+}
+
+// SetCrashOutput sends the runtime's own report of fatal errors and panics
+// no RecoverCrash caught, on any goroutine, to the file at path as well as
+// stderr. The report is the runtime's text, not an entry.
+func SetCrashOutput(path string) error {
+	f, err := openLogFile(path)
+	if err != nil {
+		return err
+	}
+	crashOutput.Lock()
+	defer crashOutput.Unlock()
+	if err := runtimeCrashOutput(f, debug.CrashOptions{}); err != nil {
+		f.Close()
+		return err
+	}
+	if crashOutput.file != nil {
+		crashOutput.file.Close()
+	}
+	crashOutput.file = f
+	return nil
+}
+
+// resetCrashOutput gives the runtime the file of SetCrashOutput back, or
+// fallback when it was never called
+func resetCrashOutput(fallback *os.File) {
+	crashOutput.Lock()
+	defer crashOutput.Unlock()
+	f := fallback
+	if crashOutput.file != nil {
+		f = crashOutput.file
+	}
+	runtimeCrashOutput(f, debug.CrashOptions{})
+}
+
+// RecoverCrash, deferred at the top of main or of a goroutine, writes an
+// unhandled panic as a FATAL entry with its stack to the crash file at path
+// and to the logger, closes the logger so nothing buffered is lost and
+// panics again with the same value
+//
+//	defer logger.RecoverCrash("/var/log/app.crash")
+func (r AppLogger) RecoverCrash(path string) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	e := Entry{PID: r.newID(), Level: "FATAL", Package: "applogger", Func: "RecoverCrash", Message: fmt.Sprint(v), Time: time.Now()}
+	attrs := []attr{{"panic", fmt.Sprintf("%T", v)}, {"stack", string(debug.Stack())}}
+
+	crash := e
+	crash.attrs = attrs
+	if line, err := encodeJSON(crash); err != nil {
+		fmt.Fprintln(os.Stderr, "Error encoding crash:", err)
+	} else if f, err := openLogFile(path); err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening crash file:", err)
+	} else {
+		f.Write(append(line, '\n'))
+		f.Sync()
+		f.Close()
+	}
+
+	if r.out != nil && !r.out.isClosed() {
+		r.logInternal(e, attrs)
+		r.Close()
+	}
+	panic(v)
+}
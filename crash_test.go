@@ -0,0 +1,61 @@
+package applogger
+
+import (
+	"encoding/json"
+	"os"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestRecoverCrash(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	mem := &memorySink{}
+	logger := AppLogger{Path: "./tmp/crash.ndjson", Sinks: []Sink{mem}}
+	logger.Initialise()
+
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Fatalf("expected the panic to continue, got %v", v)
+			}
+		}()
+		defer logger.RecoverCrash("./tmp/app.crash")
+		panic("boom")
+	}()
+
+	b, err := os.ReadFile("./tmp/app.crash")
+	if err != nil {
+		t.Fatalf("Couldn't read crash file: %v", err)
+	}
+	var crash map[string]interface{}
+	if err := json.Unmarshal(b, &crash); err != nil {
+		t.Fatalf("crash file is not an entry: %v", err)
+	}
+	attrs := crash["attributes"].(map[string]interface{})
+	if crash["level"] != "FATAL" || crash["message"] != "boom" || !strings.Contains(attrs["stack"].(string), "TestRecoverCrash") {
+		t.Fatalf("unexpected crash entry %v", crash)
+	}
+	if len(mem.entries) != 1 || !mem.closed {
+		t.Fatalf("expected the crash logged and the logger closed")
+	}
+}
+
+func TestSetCrashOutput(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+	defer func() {
+		crashOutput.file.Close()
+		crashOutput.file = nil
+		debug.SetCrashOutput(nil, debug.CrashOptions{})
+	}()
+
+	if err := SetCrashOutput("./tmp/runtime.crash"); err != nil {
+		t.Fatalf("SetCrashOutput failed: %v", err)
+	}
+	if _, err := os.Stat("./tmp/runtime.crash"); err != nil {
+		t.Fatalf("crash file was not created: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package applogger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// writeDeadline bounds the time a synchronous Log call spends on the
+// output. Writes are handed to a single writer goroutine: an entry it does
+// not take within the deadline, because an earlier write is stuck, is
+// dropped and counted, and a call whose own write takes longer returns
+// while the write goes on in the background.
+type writeDeadline struct {
+	timeout   time.Duration
+	requests  chan deadlineWrite
+	quit      chan struct{}
+	closeOnce sync.Once
+	dropped   atomic.Uint64
+}
+
+// deadlineWrite is an entry handed to the writer goroutine, an empty write
+// only checks that it is idle
+type deadlineWrite struct {
+	e     Entry
+	write func(e Entry)
+	done  chan struct{}
+}
+
+// timerPool and donePool keep the timers and the channels of the calls
+// that returned in time, so a write allocates neither
+var (
+	timerPool = sync.Pool{New: func() interface{} {
+		t := time.NewTimer(time.Hour)
+		t.Stop()
+		return t
+	}}
+	donePool = sync.Pool{New: func() interface{} { return make(chan struct{}, 1) }}
+)
+
+func newWriteDeadline(timeout time.Duration) *writeDeadline {
+	d := &writeDeadline{
+		timeout:  timeout,
+		requests: make(chan deadlineWrite),
+		quit:     make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// run writes the entries handed to it one at a time until Close
+func (d *writeDeadline) run() {
+	for {
+		select {
+		case w := <-d.requests:
+			if w.write != nil {
+				w.write(w.e)
+				w.done <- struct{}{}
+			}
+		case <-d.quit:
+			return
+		}
+	}
+}
+
+// write runs write(e) and returns at the latest after the timeout. After
+// Close it writes on the calling goroutine.
+func (d *writeDeadline) write(e Entry, write func(e Entry)) {
+	timer := startTimer(d.timeout)
+	done := donePool.Get().(chan struct{})
+	select {
+	case d.requests <- deadlineWrite{e: e, write: write, done: done}:
+	case <-timer.C:
+		d.dropped.Add(1)
+		donePool.Put(done)
+		stopTimer(timer)
+		return
+	case <-d.quit:
+		donePool.Put(done)
+		stopTimer(timer)
+		write(e)
+		return
+	}
+
+	select {
+	case <-done:
+		// the channel is only reused when the write signalled it in time,
+		// a late signal would otherwise reach the next caller
+		donePool.Put(done)
+	case <-timer.C:
+	}
+	stopTimer(timer)
+}
+
+// wait blocks until the write in progress, if any, is done, for at most
+// the timeout
+func (d *writeDeadline) wait() {
+	timer := startTimer(d.timeout)
+	defer stopTimer(timer)
+	select {
+	case d.requests <- deadlineWrite{}:
+	case <-timer.C:
+	case <-d.quit:
+	}
+}
+
+// Close waits for the write in progress like wait and stops the writer
+// goroutine, a write still stuck then finishes in the background
+func (d *writeDeadline) Close() {
+	d.wait()
+	d.closeOnce.Do(func() { close(d.quit) })
+}
+
+// startTimer returns a pooled timer firing after timeout
+func startTimer(timeout time.Duration) *time.Timer {
+	t := timerPool.Get().(*time.Timer)
+	t.Reset(timeout)
+	return t
+}
+
+// stopTimer stops t, draining a value it sent so that its next user does
+// not see it, and puts it back in the pool
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	timerPool.Put(t)
+}
@@ -0,0 +1,58 @@
+package applogger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWriteDeadline(t *testing.T) {
+	mem := &memorySink{}
+	sink := blockingSink{memorySink: mem, unblock: make(chan struct{})}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{sink}, WriteDeadline: 100 * time.Millisecond}
+	logger.Initialise()
+
+	start := time.Now()
+	logger.Log("INFO", "main", "app", "stuck")
+	logger.Log("INFO", "main", "app", "dropped")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Log blocked for %s", elapsed)
+	}
+	if logger.Dropped() != 1 {
+		t.Fatalf("expected 1 dropped entry, got %d", logger.Dropped())
+	}
+
+	close(sink.unblock)
+	logger.Log("INFO", "main", "app", "recovered")
+	logger.Close()
+	if mem.len() != 2 || mem.entries[0].Message != "stuck" || mem.entries[1].Message != "recovered" {
+		t.Fatalf("unexpected entries %v", mem.entries)
+	}
+}
+
+func TestWriteDeadlineClose(t *testing.T) {
+	mem := &memorySink{}
+	sink := blockingSink{memorySink: mem, unblock: make(chan struct{})}
+	defer close(sink.unblock)
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{sink}, WriteDeadline: 50 * time.Millisecond}
+	logger.Initialise()
+
+	logger.Log("INFO", "main", "app", "stuck")
+	start := time.Now()
+	logger.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Close blocked on a stuck write for %s", elapsed)
+	}
+}
+
+func TestWriteDeadlineAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	d := newWriteDeadline(time.Second)
+	defer d.Close()
+	write := func(e Entry) {}
+	e := Entry{Message: "allocation free"}
+	if n := testing.AllocsPerRun(100, func() { d.write(e, write) }); n != 0 {
+		t.Fatalf("expected no allocation per write, got %v", n)
+	}
+}
@@ -0,0 +1,184 @@
+package applogger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Delivery is the guarantee a sink gives for the entries handed to it
+type Delivery int
+
+const (
+	// AtMostOnce hands every entry to the sink once, entries it fails to
+	// write are lost. It is the default and costs nothing.
+	AtMostOnce Delivery = iota
+	// AtLeastOnce stores every entry in an on-disk queue before the sink
+	// sees it and keeps it until the sink accepted it, across restarts.
+	// An entry can reach the sink twice after a crash, its pid is the
+	// idempotency key for deduplication.
+	AtLeastOnce
+)
+
+// ParseDelivery converts "at-most-once" or "at-least-once" to a Delivery
+func ParseDelivery(name string) (Delivery, error) {
+	switch name {
+	case "", "at-most-once":
+		return AtMostOnce, nil
+	case "at-least-once":
+		return AtLeastOnce, nil
+	}
+	return AtMostOnce, fmt.Errorf("applogger: unknown delivery %q", name)
+}
+
+// WithDelivery returns s with the delivery guarantee d, queuePath is the
+// on-disk queue used for AtLeastOnce
+func WithDelivery(s Sink, d Delivery, queuePath string) (Sink, error) {
+	if d == AtMostOnce {
+		return s, nil
+	}
+	return NewDurableSink(s, queuePath)
+}
+
+// DurableSink gives a sink at-least-once delivery. Entries are appended and
+// synced to the queue file before being written to the sink, the offset up
+// to which the sink accepted them is kept with a Checkpoint next to the
+// queue. Entries the sink rejects stay queued and are retried on the next
+// write, on Close and when the queue is opened again.
+type DurableSink struct {
+	sink   Sink
+	path   string
+	file   *os.File
+	cp     *Checkpoint
+	offset int64
+
+	mu sync.Mutex
+}
+
+// NewDurableSink opens the queue at path and delivers what a previous run
+// left in it
+func NewDurableSink(s Sink, path string) (*DurableSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("applogger: at-least-once delivery needs a queue path")
+	}
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cp := NewCheckpoint(path, "delivery")
+	offset, err := cp.Offset()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if fi, err := f.Stat(); err == nil && offset > fi.Size() {
+		// the queue was truncated after the offset was committed
+		offset = 0
+	}
+	d := &DurableSink{sink: s, path: path, file: f, cp: cp, offset: offset}
+	d.mu.Lock()
+	d.deliverLocked()
+	d.mu.Unlock()
+	return d, nil
+}
+
+// Write queues the entry and delivers the queue, an error means the entry
+// is queued but the sink has not accepted it yet
+func (d *DurableSink) Write(e Entry) error {
+	line, err := encodeJSON(e)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := d.file.Sync(); err != nil {
+		return err
+	}
+	return d.deliverLocked()
+}
+
+// deliverLocked writes the queued entries from the delivered offset on and
+// empties the queue once the sink has them all
+func (d *DurableSink) deliverLocked() error {
+	f, err := os.Open(d.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(d.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	start := d.offset
+	reader := bufio.NewReader(f)
+	var deliverErr error
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// a partial last line is an entry still being written
+			break
+		}
+		var le LogEntry
+		if json.Unmarshal(line, &le) == nil {
+			if deliverErr = d.sink.Write(queuedEntry(le)); deliverErr != nil {
+				break
+			}
+		}
+		d.offset += int64(len(line))
+	}
+
+	committed := start
+	if fi, err := d.file.Stat(); err == nil && deliverErr == nil && d.offset == fi.Size() && d.offset > 0 {
+		// offset 0 is committed first: a crash before the truncate then
+		// delivers the queue again instead of skipping what is written
+		// to it after the truncate
+		if err := d.cp.Commit(0); err == nil {
+			committed = 0
+			if err := d.file.Truncate(0); err == nil {
+				d.offset = 0
+			}
+		}
+	}
+	if d.offset != committed {
+		if err := d.cp.Commit(d.offset); err != nil && deliverErr == nil {
+			deliverErr = err
+		}
+	}
+	return deliverErr
+}
+
+// queuedEntry turns an entry read back from the queue into the entry that
+// was queued
+func queuedEntry(le LogEntry) Entry {
+	return Entry{
+		PID:        le.PID,
+		Level:      le.Level,
+		Package:    le.Package,
+		Func:       le.Func,
+		Message:    le.Message,
+		Time:       le.Time,
+		HTTP:       le.Code != 0,
+		Code:       le.Code,
+		Duration:   le.Duration,
+		Attributes: le.Attributes,
+	}
+}
+
+// Close makes a last delivery attempt and closes the queue and the sink,
+// undelivered entries stay in the queue for the next run
+func (d *DurableSink) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deliverLocked()
+	err := d.file.Close()
+	if serr := d.sink.Close(); err == nil {
+		err = serr
+	}
+	return err
+}
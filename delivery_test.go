@@ -0,0 +1,99 @@
+package applogger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDurableSink(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	backend := &flakySink{failing: true}
+	s, err := WithDelivery(backend, AtLeastOnce, "./tmp/audit.queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(Entry{PID: "1", Level: "INFO", Message: "first"}); err == nil {
+		t.Fatal("expected the failure of the sink to be reported")
+	}
+	s.Write(Entry{PID: "2", Level: "INFO", Message: "second"})
+	s.Close()
+
+	// a new run delivers what the previous one could not
+	backend.failing = false
+	s, err = NewDurableSink(backend, "./tmp/audit.queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.entries) != 2 || backend.entries[0].PID != "1" || backend.entries[1].Message != "second" {
+		t.Fatalf("expected the queued entries in order, got %+v", backend.entries)
+	}
+	s.Write(Entry{PID: "3", Level: "INFO", Message: "third"})
+	s.Close()
+
+	if len(backend.entries) != 3 {
+		t.Fatalf("entries were delivered twice or not at all: %+v", backend.entries)
+	}
+	if fi, _ := os.Stat("./tmp/audit.queue"); fi.Size() != 0 {
+		t.Fatalf("the queue should be emptied once delivered, it has %d bytes", fi.Size())
+	}
+}
+
+func TestConfigDelivery(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	logger, err := NewFromConfig(Config{Path: "/dev/null", Sinks: []SinkConfig{
+		{Type: "file", Options: map[string]interface{}{"path": "./tmp/audit.ndjson"}, Delivery: "at-least-once", Queue: "./tmp/audit.queue"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := logger.Sinks[0].(*DurableSink); !ok {
+		t.Fatalf("expected a durable sink, got %T", logger.Sinks[0])
+	}
+	logger.Log("INFO", "main", "app", "audited")
+	logger.Close()
+
+	b, _ := os.ReadFile("./tmp/audit.ndjson")
+	if !strings.Contains(string(b), "audited") {
+		t.Fatalf("entry was not delivered: %q", b)
+	}
+
+	if _, err := NewFromConfig(Config{Sinks: []SinkConfig{{Type: "stdout", Delivery: "exactly-once"}}}); err == nil {
+		t.Fatal("expected an error for an unknown delivery")
+	}
+}
+
+func TestDurableSinkStaleCheckpoint(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	// a checkpoint past the end of the queue, as left by a crash between
+	// truncating the queue and committing offset 0
+	if err := NewCheckpoint("./tmp/audit.queue", "delivery").Commit(4096); err != nil {
+		t.Fatal(err)
+	}
+	backend := &flakySink{failing: true}
+	s, err := NewDurableSink(backend, "./tmp/audit.queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write(Entry{PID: "1", Level: "INFO", Message: "first"})
+	s.Close()
+
+	backend.failing = false
+	s, err = NewDurableSink(backend, "./tmp/audit.queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if len(backend.entries) != 1 || backend.entries[0].Message != "first" {
+		t.Fatalf("expected the queued entry to be delivered, got %+v", backend.entries)
+	}
+	if offset, _ := NewCheckpoint("./tmp/audit.queue", "delivery").Offset(); offset != 0 {
+		t.Fatalf("expected offset 0 once the queue is emptied, got %d", offset)
+	}
+}
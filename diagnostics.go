@@ -0,0 +1,148 @@
+package applogger
+
+import (
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DefaultRecentErrors is the number of recent errors kept for diagnostic
+// dumps when RecentErrors is zero
+const DefaultRecentErrors = 10
+
+// diagnostics keeps the recent Error and Fatal entries in a ring and
+// listens for the diagnostic signal
+type diagnostics struct {
+	mu     sync.Mutex
+	recent []Entry
+	next   int
+	full   bool
+
+	signals chan os.Signal
+	done    chan struct{}
+}
+
+func newDiagnostics(size int) *diagnostics {
+	if size <= 0 {
+		size = DefaultRecentErrors
+	}
+	return &diagnostics{recent: make([]Entry, size)}
+}
+
+// record keeps the entry when it is an error
+func (d *diagnostics) record(e Entry) {
+	if levelOf(e.Level) < LevelError {
+		return
+	}
+	d.mu.Lock()
+	d.recent[d.next] = Entry{Level: e.Level, Package: e.Package, Func: e.Func, Message: e.Message, Time: e.Time}
+	d.next = (d.next + 1) % len(d.recent)
+	if d.next == 0 {
+		d.full = true
+	}
+	d.mu.Unlock()
+}
+
+// errors returns the recent errors, oldest first
+func (d *diagnostics) errors() []map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ring := d.recent[:d.next]
+	if d.full {
+		ring = append(append([]Entry{}, d.recent[d.next:]...), d.recent[:d.next]...)
+	}
+	out := make([]map[string]interface{}, 0, len(ring))
+	for _, e := range ring {
+		out = append(out, map[string]interface{}{
+			"time":    e.Time,
+			"level":   e.Level,
+			"package": e.Package,
+			"func":    e.Func,
+			"message": e.Message,
+		})
+	}
+	return out
+}
+
+// listen writes a diagnostic dump with r on every diagnostic signal until
+// stop is called
+func (d *diagnostics) listen(r AppLogger) {
+	if diagnosticSignal == nil {
+		return
+	}
+	d.signals = make(chan os.Signal, 1)
+	d.done = make(chan struct{})
+	signal.Notify(d.signals, diagnosticSignal)
+	go func() {
+		defer close(d.done)
+		for range d.signals {
+			r.logDiagnostics()
+		}
+	}()
+}
+
+func (d *diagnostics) stop() {
+	if d.signals == nil {
+		return
+	}
+	signal.Stop(d.signals)
+	close(d.signals)
+	<-d.done
+	d.signals = nil
+}
+
+// Diagnostics returns runtime statistics, the counters of the logger, its
+// configuration and its recent errors (with DiagnosticSignal or
+// RecentErrors set), the same data written on SIGUSR1
+func (r AppLogger) Diagnostics() map[string]interface{} {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	path := r.Path
+	if path == "" {
+		path = "stdout"
+	}
+	d := map[string]interface{}{
+		"runtime": map[string]interface{}{
+			"goroutines":     runtime.NumGoroutine(),
+			"heap_alloc":     mem.HeapAlloc,
+			"heap_sys":       mem.HeapSys,
+			"num_gc":         mem.NumGC,
+			"gc_pause_total": time.Duration(mem.PauseTotalNs).String(),
+		},
+		"config": map[string]interface{}{
+			"path":          path,
+			"format":        r.format.String(),
+			"async":         r.Async,
+			"buffer_size":   r.BufferSize,
+			"rotate_every":  r.RotateEvery.String(),
+			"shared_file":   r.SharedFile,
+			"index_every":   r.IndexEvery,
+			"sinks":         len(r.Sinks),
+			"high_water":    r.HighWaterMark,
+			"development":   r.Development,
+			"copy_truncate": r.CopyTruncate,
+		},
+	}
+	metrics := map[string]interface{}{"dropped": r.Dropped()}
+	if r.async != nil {
+		metrics["queued"] = r.async.queue.len()
+	}
+	if r.life != nil {
+		metrics["entries"] = r.life.entries.Load()
+		metrics["errors"] = r.life.errors.Load()
+		metrics["uptime_ms"] = time.Since(r.life.started).Milliseconds()
+	}
+	d["logger"] = metrics
+	if r.diag != nil {
+		d["recent_errors"] = r.diag.errors()
+	}
+	return d
+}
+
+// logDiagnostics writes the diagnostic dump as an INFO entry
+func (r AppLogger) logDiagnostics() {
+	r.logInternal(Entry{PID: r.newID(), Level: "INFO", Package: "applogger", Func: "Diagnostics", Message: "diagnostic dump", Time: time.Now()}, appendMap(nil, r.Diagnostics()))
+}
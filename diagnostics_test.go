@@ -0,0 +1,34 @@
+package applogger
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	logger := AppLogger{Path: "./tmp/diagnostics.ndjson", RecentErrors: 2, Lifecycle: true}
+	logger.Initialise()
+	defer logger.Close()
+	logger.Log("ERROR", "main", "app", "first")
+	logger.Log("INFO", "main", "app", "fine")
+	logger.Log("ERROR", "main", "app", "second")
+	logger.Log("FATAL", "main", "app", "third")
+
+	d := logger.Diagnostics()
+	recent := d["recent_errors"].([]map[string]interface{})
+	if len(recent) != 2 || recent[0]["message"] != "second" || recent[1]["message"] != "third" {
+		t.Fatalf("expected the last 2 errors oldest first, got %v", recent)
+	}
+	if d["logger"].(map[string]interface{})["errors"] != uint64(3) {
+		t.Fatalf("unexpected logger metrics %v", d["logger"])
+	}
+	if d["config"].(map[string]interface{})["path"] != "./tmp/diagnostics.ndjson" {
+		t.Fatalf("unexpected config %v", d["config"])
+	}
+	if d["runtime"].(map[string]interface{})["goroutines"].(int) < 1 {
+		t.Fatalf("unexpected runtime stats %v", d["runtime"])
+	}
+}
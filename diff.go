@@ -0,0 +1,78 @@
+package applogger
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DefaultDiffKeys are the attributes ConsoleDiff groups entries by
+var DefaultDiffKeys = []string{"request_id", CorrelationIDKey, RetryIDKey}
+
+// DefaultDiffRequests is the number of requests ConsoleDiff remembers when
+// Max is not set
+const DefaultDiffRequests = 1000
+
+// ConsoleDiff makes console lines show what changed since the previous
+// entry of the same request, for following a request step by step while
+// working on a service: a changed value is written as key=old→new, a new
+// attribute as +key=value, and with colors the changes are highlighted in
+// the color of the level of the entry and the rest is dimmed. The first
+// entry of a request is written as usual.
+type ConsoleDiff struct {
+	// Keys are the attributes identifying a request, the first one an
+	// entry has is used, DefaultDiffKeys when empty
+	Keys []string
+	// Max is the number of requests remembered, the oldest is forgotten
+	// first, DefaultDiffRequests when zero
+	Max int
+
+	mu    sync.Mutex
+	last  map[string]map[string]string
+	order []string
+}
+
+// NewConsoleDiff returns a ConsoleDiff with the default keys
+func NewConsoleDiff() *ConsoleDiff {
+	return &ConsoleDiff{}
+}
+
+// swap records the attributes of the entry as the last of its request and
+// returns those of the previous entry, nil when there is none
+func (d *ConsoleDiff) swap(e Entry) map[string]string {
+	keys := d.Keys
+	if len(keys) == 0 {
+		keys = DefaultDiffKeys
+	}
+	var id string
+	for _, k := range keys {
+		if v, ok := e.Lookup(k); ok {
+			id = k + "=" + fmt.Sprint(v)
+			break
+		}
+	}
+	if id == "" {
+		return nil
+	}
+	current := make(map[string]string)
+	e.eachAttribute(func(k string, v interface{}) { current[k] = fmt.Sprint(v) })
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.last == nil {
+		d.last = make(map[string]map[string]string)
+	}
+	prev, ok := d.last[id]
+	if !ok {
+		max := d.Max
+		if max <= 0 {
+			max = DefaultDiffRequests
+		}
+		if len(d.order) >= max {
+			delete(d.last, d.order[0])
+			d.order = d.order[1:]
+		}
+		d.order = append(d.order, id)
+	}
+	d.last[id] = current
+	return prev
+}
@@ -0,0 +1,51 @@
+package applogger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConsoleDiff(t *testing.T) {
+	enc := ConsoleEncoder{Diff: NewConsoleDiff()}
+	line := func(requestID string, kvs ...interface{}) string {
+		e := Entry{Level: "INFO", Package: "main", Func: "pay", Message: "step", Time: time.Now()}
+		e.Set("request_id", requestID)
+		for i := 0; i < len(kvs); i += 2 {
+			e.Set(kvs[i].(string), kvs[i+1])
+		}
+		b, err := enc.Encode(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	first := line("r1", "status", "pending")
+	if !strings.HasSuffix(first, " request_id=r1 status=pending") {
+		t.Fatalf("first entry of a request is written as usual: %q", first)
+	}
+	line("r2", "status", "failed")
+	second := line("r1", "status", "paid", "amount", 10)
+	if !strings.HasSuffix(second, " request_id=r1 status=pending→paid +amount=10") {
+		t.Fatalf("unexpected diff %q", second)
+	}
+
+	enc.Color = true
+	third := line("r1", "status", "refunded", "amount", 10)
+	if !strings.Contains(third, colorBold+colorBlue+"refunded"+colorReset) || !strings.Contains(third, colorGray+"amount=10"+colorReset) {
+		t.Fatalf("unexpected colors %q", third)
+	}
+}
+
+func TestConsoleDiffMax(t *testing.T) {
+	d := &ConsoleDiff{Max: 2}
+	for _, id := range []string{"a", "b", "c"} {
+		e := Entry{}
+		e.Set("request_id", id)
+		d.swap(e)
+	}
+	if _, ok := d.last["request_id=a"]; ok || len(d.last) != 2 {
+		t.Fatalf("oldest request not forgotten: %v", d.last)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd)
+
+package applogger
+
+// diskFree is not known where statfs is not available, Doctor skips the
+// check
+func diskFree(dir string) (int64, bool) {
+	return 0, false
+}
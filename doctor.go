@@ -0,0 +1,229 @@
+package applogger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DoctorMinFree is the free space under which Doctor reports the file
+// system of a log file as too full
+var DoctorMinFree int64 = 100 << 20
+
+// DoctorCheck is the outcome of one check of Doctor
+type DoctorCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// DoctorReport is the outcome of Doctor, OK when every check passed
+type DoctorReport struct {
+	OK     bool          `json:"ok"`
+	Checks []DoctorCheck `json:"checks"`
+}
+
+// Checker is implemented by sinks that can check they reach their backend
+// without writing an entry
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Doctor validates a config before it is rolled out: it parses the format,
+// levels and rules, checks that every log file and delivery queue can be
+// written and has DoctorMinFree bytes free, builds every sink and route and
+// checks the ones that are a Checker reach their backend, and finally
+// writes a test entry through a logger built from the config. The test
+// entry carries doctor_id and must show up in the file at Path.
+func Doctor(ctx context.Context, cfg Config) DoctorReport {
+	var report DoctorReport
+	add := func(name string) func(detail string, err error) {
+		return func(detail string, err error) {
+			check := DoctorCheck{Name: name, OK: err == nil, Detail: detail}
+			if err != nil {
+				check.Error = err.Error()
+			}
+			report.Checks = append(report.Checks, check)
+		}
+	}
+
+	_, err := ParseFormat(cfg.Format)
+	if err == nil && cfg.Levels != "" {
+		_, err = ParseLevels(cfg.Levels)
+	}
+	if err == nil {
+		routes := make(map[string]Sink, len(cfg.Routes))
+		for name := range cfg.Routes {
+			routes[name] = nil
+		}
+		_, err = compileRules(cfg.Rules, routes)
+	}
+	add("config")("", err)
+
+	if cfg.Path != "" {
+		add("file " + cfg.Path)(checkFile(cfg.Path))
+	}
+	names := make([]string, 0, len(cfg.Sinks)+len(cfg.Routes))
+	sinks := make([]SinkConfig, 0, len(names))
+	for i, sc := range cfg.Sinks {
+		names = append(names, fmt.Sprintf("sink %d (%s)", i, sc.Type))
+		sinks = append(sinks, sc)
+	}
+	routes := make([]string, 0, len(cfg.Routes))
+	for name := range cfg.Routes {
+		routes = append(routes, name)
+	}
+	sort.Strings(routes)
+	for _, name := range routes {
+		names = append(names, fmt.Sprintf("route %s (%s)", name, cfg.Routes[name].Type))
+		sinks = append(sinks, cfg.Routes[name])
+	}
+	for i, name := range names {
+		sc := sinks[i]
+		if path, _ := sc.Options["path"].(string); path != "" && !strings.Contains(path, "{value}") {
+			add("file " + path)(checkFile(path))
+		}
+		if sc.Queue != "" {
+			add("queue " + sc.Queue)(checkFile(sc.Queue))
+		}
+		add(name)(checkSink(ctx, sc))
+	}
+
+	add("test entry")(testEntry(cfg))
+
+	report.OK = true
+	for _, c := range report.Checks {
+		report.OK = report.OK && c.OK
+	}
+	return report
+}
+
+// checkFile checks that the file at path can be appended to, or created
+// when it does not exist yet, and that its file system has room left
+func checkFile(path string) (string, error) {
+	if _, err := os.Stat(path); err == nil {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return "", err
+		}
+		f.Close()
+	} else {
+		f, err := os.CreateTemp(filepath.Dir(path), ".applogger-doctor-*")
+		if err != nil {
+			return "", err
+		}
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	free, ok := diskFree(filepath.Dir(path))
+	if !ok {
+		return "writable", nil
+	}
+	detail := fmt.Sprintf("writable, %s free", formatBytes(free))
+	if free < DoctorMinFree {
+		return detail, fmt.Errorf("applogger: only %s free, under %s", formatBytes(free), formatBytes(DoctorMinFree))
+	}
+	return detail, nil
+}
+
+// checkSink builds the sink of sc and, when it is a Checker, checks it
+// reaches its backend
+func checkSink(ctx context.Context, sc SinkConfig) (string, error) {
+	if _, err := ParseDelivery(sc.Delivery); err != nil {
+		return "", err
+	}
+	s, err := NewSink(sc.Type, sc.Options)
+	if err != nil {
+		return "", err
+	}
+	defer s.Close()
+	c, ok := s.(Checker)
+	if !ok {
+		return "built", nil
+	}
+	if err := c.Check(ctx); err != nil {
+		return "", err
+	}
+	return "reachable", nil
+}
+
+// testEntry writes an entry through a logger built from cfg and checks it
+// reached the file at Path and that no sink failed to take it
+func testEntry(cfg Config) (string, error) {
+	logger, err := NewFromConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+	id := UUIDv4()
+	logger.LogFields("INFO", "applogger", "Doctor", "applogger doctor test entry", map[string]interface{}{"doctor_id": id})
+	logger.Flush()
+	health := logger.Health()
+	if err := logger.Close(); err != nil {
+		return "", err
+	}
+
+	for _, s := range health.Sinks {
+		if s.Failed > 0 || !s.Connected {
+			return "", fmt.Errorf("applogger: sink %s: %s", s.Name, s.LastError)
+		}
+	}
+	if cfg.Path != "" {
+		b, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			return "", err
+		}
+		if !bytes.Contains(b, []byte(id)) {
+			return "", fmt.Errorf("applogger: test entry %s not found in %s", id, cfg.Path)
+		}
+	}
+	return "doctor_id " + id, nil
+}
+
+// Check sends a HEAD request to URL with the credentials of the sink. Any
+// answer short of a server error or a rejection of the credentials counts
+// as reachable, endpoints that only take POST answer 405.
+func (s *HTTPSink) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.URL, nil)
+	if err != nil {
+		return err
+	}
+	if s.Auth != nil {
+		if err := s.Auth.Authorize(req); err != nil {
+			return err
+		}
+	}
+	client, err := s.httpClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("applogger: %s returned %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+// formatBytes writes n with a binary unit, e.g. 1.5 GiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,47 @@
+package applogger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDoctor(t *testing.T) {
+	directoryPath := "./tmp"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+	DoctorMinFree = 0
+	defer func() { DoctorMinFree = 100 << 20 }()
+
+	server := httptest.NewServer(&batchServer{})
+	defer server.Close()
+	cfg := Config{Path: directoryPath + "/doctor.ndjson", Sinks: []SinkConfig{
+		{Type: "http", Options: map[string]interface{}{"url": server.URL}},
+	}}
+	report := Doctor(context.Background(), cfg)
+	if !report.OK || len(report.Checks) != 4 {
+		t.Fatalf("expected 4 passing checks, got %+v", report)
+	}
+	if c := report.Checks[3]; c.Name != "test entry" || !strings.HasPrefix(c.Detail, "doctor_id ") {
+		t.Fatalf("unexpected test entry check %+v", c)
+	}
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer down.Close()
+	cfg.Sinks[0].Options["url"] = down.URL
+	cfg.Path = directoryPath + "/missing/doctor.ndjson"
+	report = Doctor(context.Background(), cfg)
+	if report.OK {
+		t.Fatalf("expected a failing report, got %+v", report)
+	}
+	for _, c := range report.Checks {
+		if c.Name != "config" && (c.OK || c.Error == "") {
+			t.Fatalf("expected check %s to fail, got %+v", c.Name, c)
+		}
+	}
+}
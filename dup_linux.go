@@ -0,0 +1,10 @@
+package applogger
+
+import (
+	"syscall"
+)
+
+// dup2 makes newfd a copy of oldfd, Linux on arm64 only has dup3
+func dup2(oldfd int, newfd int) error {
+	return syscall.Dup3(oldfd, newfd, 0)
+}
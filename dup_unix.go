@@ -0,0 +1,12 @@
+//go:build unix && !linux
+
+package applogger
+
+import (
+	"syscall"
+)
+
+// dup2 makes newfd a copy of oldfd
+func dup2(oldfd int, newfd int) error {
+	return syscall.Dup2(oldfd, newfd)
+}
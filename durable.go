@@ -0,0 +1,114 @@
+package applogger
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// groupSync syncs a file for many writers at once: every writer waiting
+// when a sync starts is covered by it, writers arriving meanwhile share the
+// next one
+type groupSync struct {
+	window time.Duration
+	sync   func() error
+
+	mu      sync.Mutex
+	next    *syncBatch
+	running bool
+}
+
+// syncBatch is the writers covered by one sync
+type syncBatch struct {
+	done chan struct{}
+	err  error
+}
+
+// wait returns once everything written before the call is synced
+func (g *groupSync) wait() error {
+	g.mu.Lock()
+	b := g.next
+	if b == nil {
+		b = &syncBatch{done: make(chan struct{})}
+		g.next = b
+	}
+	if !g.running {
+		g.running = true
+		go g.run()
+	}
+	g.mu.Unlock()
+	<-b.done
+	return b.err
+}
+
+// run syncs batch after batch, waiting window before each one to gather
+// more writers, until no one waits
+func (g *groupSync) run() {
+	for {
+		if g.window > 0 {
+			time.Sleep(g.window)
+		}
+		g.mu.Lock()
+		b := g.next
+		g.next = nil
+		if b == nil {
+			g.running = false
+			g.mu.Unlock()
+			return
+		}
+		g.mu.Unlock()
+		b.err = g.sync()
+		close(b.done)
+	}
+}
+
+// durable makes the writer sync a file before closing it on rotation or
+// reopening and sets up group syncs for waitDurable
+func (w *fileWriter) durable(window time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncOnClose = true
+	w.group = &groupSync{window: window, sync: w.sync}
+}
+
+// waitDurable returns once everything written so far is on disk
+func (w *fileWriter) waitDurable() error {
+	return w.group.wait()
+}
+
+// sync flushes the buffer and syncs the file
+func (w *fileWriter) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if err := w.flushLocked(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// closeFile closes the current file, syncing it first when the writer is
+// durable. The caller holds w.mu.
+func (w *fileWriter) closeFile() error {
+	if w.syncOnClose {
+		if err := w.file.Sync(); err != nil {
+			w.file.Close()
+			return err
+		}
+	}
+	return w.file.Close()
+}
+
+// isAudit reports whether the entry, or one of a batch, has the
+// RetentionAudit class
+func isAudit(e Entry) bool {
+	for _, b := range e.batch {
+		if isAudit(b) {
+			return true
+		}
+	}
+	class, _ := e.Lookup(RetentionKey)
+	return class == RetentionAudit
+}
@@ -0,0 +1,94 @@
+package applogger
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDurableAudit(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/audit.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, BufferSize: 64 * 1024, FlushInterval: -1, DurableAudit: true, SyncWindow: time.Millisecond}
+	logger.Initialise()
+	defer logger.Close()
+	var syncs atomic.Int32
+	inner := logger.out.group.sync
+	logger.out.group.sync = func() error {
+		syncs.Add(1)
+		return inner()
+	}
+
+	logger.Log("INFO", "main", "app", "buffered")
+	if b, _ := os.ReadFile(filePath); len(b) != 0 {
+		t.Fatalf("plain entry should stay buffered, got %q", b)
+	}
+
+	audit := logger.WithFields(map[string]interface{}{RetentionKey: RetentionAudit})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			audit.Log("INFO", "auth", "login", "password changed")
+			if b, _ := os.ReadFile(filePath); !strings.Contains(string(b), "password changed") {
+				t.Errorf("audit entry not on disk when Log returned: %q", b)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := syncs.Load(); n == 0 || n >= 50 {
+		t.Fatalf("expected the 50 audit entries to share syncs, got %d", n)
+	}
+	if b, _ := os.ReadFile(filePath); strings.Count(string(b), "\n") != 51 {
+		t.Fatalf("expected 51 lines, got %q", b)
+	}
+}
+
+func TestDurableAuditBatch(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/audit-batch.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, BufferSize: 64 * 1024, FlushInterval: -1, DurableAudit: true}
+	logger.Initialise()
+	defer logger.Close()
+	var syncs atomic.Int32
+	inner := logger.out.group.sync
+	logger.out.group.sync = func() error {
+		syncs.Add(1)
+		return inner()
+	}
+
+	logger.LogBatch(context.Background(), []Event{
+		{Level: "INFO", Package: "main", Func: "app", Message: "plain"},
+	})
+	if syncs.Load() != 0 {
+		t.Fatalf("a batch without audit entries was synced")
+	}
+	logger.LogBatch(context.Background(), []Event{
+		{Level: "INFO", Package: "auth", Func: "login", Message: "role granted", Fields: map[string]interface{}{RetentionKey: RetentionAudit}},
+		{Level: "INFO", Package: "auth", Func: "login", Message: "plain"},
+	})
+	if syncs.Load() != 1 {
+		t.Fatalf("expected one sync for the audit batch, got %d", syncs.Load())
+	}
+	if b, _ := os.ReadFile(filePath); strings.Count(string(b), "\n") != 3 {
+		t.Fatalf("expected the batches on disk, got %q", b)
+	}
+}
+
+func TestDurableAuditNeedsSyncWrites(t *testing.T) {
+	if err := (&AppLogger{Path: "/dev/null", DurableAudit: true, Async: true}).Open(); err == nil {
+		t.Fatal("expected an error with Async")
+	}
+}
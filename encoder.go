@@ -0,0 +1,259 @@
+package applogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Encoder turns an entry into a single line of output without the trailing
+// newline
+type Encoder interface {
+	Encode(e Entry) ([]byte, error)
+}
+
+// EncoderFunc adapts a function to Encoder
+type EncoderFunc func(e Entry) ([]byte, error)
+
+// Encode calls f(e)
+func (f EncoderFunc) Encode(e Entry) ([]byte, error) {
+	return f(e)
+}
+
+// JSONEncoder writes the default ndjson layout
+type JSONEncoder struct {
+	// Rename maps the default keys (pid, level, package, func, message,
+	// time, code, duration, attributes) to the names a sink expects,
+	// e.g. {"message": "msg", "time": "ts"}
+	Rename map[string]string
+}
+
+// Encode writes the entry as a JSON object, code and duration are only
+// written for HTTP entries and attributes only when there are any
+func (j JSONEncoder) Encode(e Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	j.field(&buf, "pid", e.PID)
+	writeJSONInterned(&buf, j.key("level"), e.Level)
+	writeJSONInterned(&buf, j.key("package"), e.Package)
+	writeJSONInterned(&buf, j.key("func"), e.Func)
+	j.field(&buf, "message", e.Message)
+	j.field(&buf, "time", e.Time)
+	if e.HTTP {
+		j.field(&buf, "code", e.Code)
+		j.field(&buf, "duration", e.Duration)
+	}
+	if e.hasAttributes() {
+		attributes, err := encodeAttributes(e)
+		if err != nil {
+			return nil, err
+		}
+		writeJSONRaw(&buf, j.key("attributes"), attributes)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (j JSONEncoder) key(key string) string {
+	if name, ok := j.Rename[key]; ok {
+		return name
+	}
+	return key
+}
+
+func (j JSONEncoder) field(buf *bytes.Buffer, key string, value interface{}) error {
+	return writeJSONField(buf, j.key(key), value)
+}
+
+// writeJSONField appends "key":value to a JSON object under construction
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}) error {
+	var scratch [64]byte
+	if v, ok := appendJSONScalar(scratch[:0], value); ok {
+		writeJSONRaw(buf, key, v)
+		return nil
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	writeJSONRaw(buf, key, v)
+	return nil
+}
+
+// writeJSONRaw appends "key":raw where raw is already valid JSON
+func writeJSONRaw(buf *bytes.Buffer, key string, raw []byte) {
+	if buf.Len() > 1 {
+		buf.WriteByte(',')
+	}
+	buf.Write(quoteInterned(key))
+	buf.WriteByte(':')
+	buf.Write(raw)
+}
+
+// appendJSONScalar appends the JSON of the common attribute types, the
+// ones the Field constructors make, without the reflection of
+// json.Marshal. It gives the same bytes as json.Marshal and reports false
+// for anything it does not handle.
+func appendJSONScalar(b []byte, value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case string:
+		return appendJSONString(b, v)
+	case int:
+		return strconv.AppendInt(b, int64(v), 10), true
+	case int64:
+		return strconv.AppendInt(b, v, 10), true
+	case time.Duration:
+		return strconv.AppendInt(b, int64(v), 10), true
+	case bool:
+		return strconv.AppendBool(b, v), true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return b, false
+		}
+		// the format of encoding/json, which matches ES6
+		format := byte('f')
+		if abs := math.Abs(v); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+			format = 'e'
+		}
+		b = strconv.AppendFloat(b, v, format, -1, 64)
+		if n := len(b); format == 'e' && n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			// e-07 becomes e-7
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+		return b, true
+	case time.Time:
+		_, offset := v.Zone()
+		if y := v.Year(); y < 0 || y > 9999 || offset <= -24*60*60 || offset >= 24*60*60 {
+			return b, false
+		}
+		b = append(b, '"')
+		b = v.AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"'), true
+	}
+	return b, false
+}
+
+// appendJSONString appends s quoted with the escapes of json.Marshal,
+// reporting false for the rare control characters and invalid UTF-8 whose
+// encoding differs between Go versions
+func appendJSONString(b []byte, s string) ([]byte, bool) {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			case '<', '>', '&':
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			default:
+				return b, false
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return b, false
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"'), true
+}
+
+const hexDigits = "0123456789abcdef"
+
+// ConsoleEncoder writes human readable lines, with colors when Color is set
+// and the changes between the entries of a request when Diff is set
+type ConsoleEncoder struct {
+	Color bool
+	Diff  *ConsoleDiff
+}
+
+// Encode renders the entry as a console line
+func (c ConsoleEncoder) Encode(e Entry) ([]byte, error) {
+	return []byte(consoleLine(e, c.Color, c.Diff)), nil
+}
+
+// ECSEncoder writes entries in the Elastic Common Schema layout so they can
+// be indexed by Elasticsearch without an ingest pipeline. Attributes are
+// written as top level fields and Duration is taken to be in seconds.
+type ECSEncoder struct{}
+
+// Encode writes the entry as an ECS JSON object
+func (ECSEncoder) Encode(e Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONField(&buf, "@timestamp", e.Time)
+	writeJSONField(&buf, "log.level", strings.ToLower(e.Level))
+	writeJSONField(&buf, "message", e.Message)
+	writeJSONField(&buf, "ecs.version", "8.11.0")
+	writeJSONField(&buf, "event.id", e.PID)
+	writeJSONInterned(&buf, "log.logger", e.Package)
+	writeJSONInterned(&buf, "log.origin.function", e.Func)
+	if e.HTTP {
+		writeJSONField(&buf, "http.response.status_code", e.Code)
+		writeJSONField(&buf, "event.duration", int64(e.Duration*1e9))
+	}
+	var err error
+	e.eachAttribute(func(k string, v interface{}) {
+		if err == nil {
+			err = writeJSONField(&buf, k, v)
+		}
+	})
+	buf.WriteByte('}')
+	return buf.Bytes(), err
+}
+
+// Enricher adds or changes data on an entry before a sink receives it
+type Enricher func(e *Entry)
+
+// WithEnrichers wraps a sink so that every entry goes through the enrichers
+// before reaching it, other sinks see the entry unchanged
+func WithEnrichers(s Sink, enrichers ...Enricher) Sink {
+	return enrichedSink{Sink: s, enrichers: enrichers}
+}
+
+type enrichedSink struct {
+	Sink
+	enrichers []Enricher
+}
+
+func (s enrichedSink) Write(e Entry) error {
+	attributes := make(map[string]interface{}, len(e.Attributes))
+	for k, v := range e.Attributes {
+		attributes[k] = v
+	}
+	e.Attributes = attributes
+	e.base, e.attrs = nil, nil
+	for _, enrich := range s.enrichers {
+		enrich(&e)
+	}
+	return s.Sink.Write(e)
+}
@@ -0,0 +1,58 @@
+package applogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONEncoderRename(t *testing.T) {
+	e := Entry{PID: "1", Level: "INFO", Package: "main", Func: "app", Message: "hello", Time: time.Unix(0, 0).UTC()}
+	line, err := JSONEncoder{Rename: map[string]string{"message": "msg", "time": "ts"}}.Encode(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"pid":"1","level":"INFO","package":"main","func":"app","msg":"hello","ts":"1970-01-01T00:00:00Z"}`
+	if string(line) != want {
+		t.Fatalf("got %s want %s", line, want)
+	}
+}
+
+func TestECSEncoder(t *testing.T) {
+	e := Entry{PID: "1", Level: "ERROR", Package: "controller", Func: "perform", Message: "failed", Time: time.Now(), HTTP: true, Code: 500, Duration: 0.5}
+	line, err := ECSEncoder{}.Encode(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(line, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["log.level"] != "error" || m["http.response.status_code"] != float64(500) || m["event.duration"] != float64(5e8) {
+		t.Fatalf("unexpected ecs document %s", line)
+	}
+}
+
+func TestPerSinkEncoderAndEnrichers(t *testing.T) {
+	var ecs, console bytes.Buffer
+	hostname := func(e *Entry) { e.Attributes["host"] = "web-1" }
+
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{
+		WithEnrichers(NewWriterSink(&ecs, ECSEncoder{}), hostname),
+		NewWriterSink(&console, ConsoleEncoder{}),
+	}}
+	logger.Initialise()
+	logger.Log("WARN", "main", "app", "disk almost full")
+
+	if !strings.Contains(ecs.String(), `"host":"web-1"`) {
+		t.Fatalf("enricher was not applied %s", ecs.String())
+	}
+	if strings.Contains(console.String(), "host=") {
+		t.Fatalf("enricher leaked to another sink %s", console.String())
+	}
+	if !strings.Contains(console.String(), "WARN  main.app disk almost full") {
+		t.Fatalf("unexpected console line %s", console.String())
+	}
+}
@@ -0,0 +1,65 @@
+package applogger
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorKey is the attribute holding the text of the error given to
+// LogError
+const ErrorKey = "error"
+
+// ErrValidation marks errors caused by bad input, LogError logs errors
+// wrapping it at Warn: fmt.Errorf("user %q: %w", id, applogger.ErrValidation)
+var ErrValidation = errors.New("validation failed")
+
+// ErrorClassifier picks the level of an error for LogError, ok false
+// leaves the decision to the next classifier
+type ErrorClassifier func(err error) (level LogLevel, ok bool)
+
+// DefaultErrorLevel is the level LogError uses when no classifier of the
+// logger matches: Debug for a canceled context, the level of errors with
+// a LogLevel() LogLevel method, Warn for ErrValidation and Error for
+// everything else
+func DefaultErrorLevel(err error) LogLevel {
+	var leveled interface{ LogLevel() LogLevel }
+	switch {
+	case errors.Is(err, context.Canceled):
+		return LevelDebug
+	case errors.As(err, &leveled):
+		return leveled.LogLevel()
+	case errors.Is(err, ErrValidation):
+		return LevelWarn
+	}
+	return LevelError
+}
+
+// ErrorLevel returns the level LogError writes err at
+func (r AppLogger) ErrorLevel(err error) LogLevel {
+	for _, classify := range r.ErrorClassifiers {
+		if level, ok := classify(err); ok {
+			return level
+		}
+	}
+	return DefaultErrorLevel(err)
+}
+
+// LogError writes message with err under ErrorKey at the level picked by
+// ErrorLevel, so call sites do not choose between Warn and Error
+// themselves. Package and Func are those of the caller.
+func (r AppLogger) LogError(ctx context.Context, err error, message string) {
+	logPackage, logFunc := getCallerInfo(1)
+	level := r.ErrorLevel(err)
+	if r.disabled(level, logPackage) {
+		return
+	}
+
+	s1 := r.now()
+
+	var attrs []attr
+	if err != nil {
+		attrs = append(attrs, attr{ErrorKey, err.Error()})
+	}
+	e := Entry{PID: r.newID(), Level: level.String(), Package: logPackage, Func: logFunc, Message: message, Time: s1}
+	r.logInternal(e, r.contextAttrs(ctx, s1, attrs))
+}
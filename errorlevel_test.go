@@ -0,0 +1,52 @@
+package applogger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type retryableError struct{}
+
+func (retryableError) Error() string      { return "try again" }
+func (retryableError) LogLevel() LogLevel { return LevelInfo }
+
+func TestDefaultErrorLevel(t *testing.T) {
+	cases := map[error]LogLevel{
+		context.Canceled: LevelDebug,
+		fmt.Errorf("request: %w", context.Canceled):    LevelDebug,
+		fmt.Errorf("email %q: %w", "x", ErrValidation): LevelWarn,
+		fmt.Errorf("wrapped: %w", retryableError{}):    LevelInfo,
+		errors.New("connection refused"):               LevelError,
+		context.DeadlineExceeded:                       LevelError,
+	}
+	for err, want := range cases {
+		if got := DefaultErrorLevel(err); got != want {
+			t.Errorf("DefaultErrorLevel(%v) = %s want %s", err, got, want)
+		}
+	}
+}
+
+func TestLogError(t *testing.T) {
+	var buf bytes.Buffer
+	notFound := errors.New("not found")
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}, ErrorClassifiers: []ErrorClassifier{
+		func(err error) (LogLevel, bool) { return LevelInfo, errors.Is(err, notFound) },
+	}}
+	logger.Initialise()
+	logger.LogError(context.Background(), fmt.Errorf("user 42: %w", notFound), "lookup failed")
+	logger.LogError(context.Background(), errors.New("disk full"), "save failed")
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 || entries[0]["level"] != "INFO" || entries[1]["level"] != "ERROR" {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	if entries[0]["package"] != "github.com/junkd0g/applogger" || entries[0]["func"] != "TestLogError" {
+		t.Fatalf("expected the caller as package and func, got %v", entries[0])
+	}
+	if entries[0]["attributes"].(map[string]interface{})[ErrorKey] != "user 42: not found" {
+		t.Fatalf("expected the error text, got %v", entries[0])
+	}
+}
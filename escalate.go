@@ -0,0 +1,35 @@
+package applogger
+
+import "reflect"
+
+// OriginalLevelKey is the attribute holding the level an entry was logged
+// at before an Escalation raised it
+const OriginalLevelKey = "original_level"
+
+// Escalation raises entries carrying the attribute Key, with Value when it
+// is not nil, to at least Level, e.g. {Key: "security", Value: true,
+// Level: LevelError} makes every security entry an Error for level
+// filtering, error files, routing and shedding whatever level it was
+// logged at
+type Escalation struct {
+	Key   string
+	Value interface{}
+	Level LogLevel
+}
+
+// escalate raises the level of the entry to the highest matching
+// escalation
+func (r AppLogger) escalate(e *Entry) {
+	level := levelOf(e.Level)
+	target := level
+	for _, esc := range r.Escalations {
+		v, ok := e.Lookup(esc.Key)
+		if ok && (esc.Value == nil || reflect.DeepEqual(v, esc.Value)) && esc.Level > target {
+			target = esc.Level
+		}
+	}
+	if target > level {
+		e.attrs = append(e.attrs, attr{OriginalLevelKey, e.Level})
+		e.Level = target.String()
+	}
+}
@@ -0,0 +1,30 @@
+package applogger
+
+import "testing"
+
+func TestEscalations(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, Levels: map[string]LogLevel{"": LevelInfo}, Escalations: []Escalation{
+		{Key: "security", Value: true, Level: LevelError},
+		{Key: "payment_id", Level: LevelWarn},
+	}}
+	logger.Initialise()
+
+	logger.LogFields("DEBUG", "auth", "login", "token reuse", map[string]interface{}{"security": true})
+	logger.LogFields("INFO", "billing", "charge", "charged", map[string]interface{}{"payment_id": "p1"})
+	logger.LogFields("ERROR", "billing", "charge", "failed", map[string]interface{}{"payment_id": "p2"})
+	logger.LogFields("DEBUG", "auth", "login", "not escalated", map[string]interface{}{"security": false})
+
+	if len(mem.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(mem.entries))
+	}
+	if e := mem.entries[0]; e.Level != "ERROR" || e.Attributes[OriginalLevelKey] != "DEBUG" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if e := mem.entries[1]; e.Level != "WARN" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if e := mem.entries[2]; e.Level != "ERROR" || e.Attributes[OriginalLevelKey] != nil {
+		t.Fatalf("entries are never lowered %+v", e)
+	}
+}
@@ -0,0 +1,53 @@
+package applogger
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+)
+
+// GoroutinesKey is the attribute holding the goroutine dump of a fatal
+// entry, or the path of the file it was written to
+const GoroutinesKey = "goroutines"
+
+// exit is os.Exit, replaced in tests
+var exit = os.Exit
+
+// Fatal writes a FATAL entry, closes the logger and exits the process with
+// status 1. The loggers of Once and Every write it too.
+func (r AppLogger) Fatal(logPackage string, logFunc string, message string) {
+	r.muted = false
+
+	s1 := r.now()
+
+	r.logInternal(Entry{PID: r.newID(), Level: "FATAL", Package: logPackage, Func: logFunc, Message: message, Time: s1}, nil)
+	r.Close()
+	exit(1)
+}
+
+// goroutineDump returns the stacks of all goroutines, or the path of the
+// file they were appended to when GoroutineDumpPath is set
+func (r AppLogger) goroutineDump(e Entry) string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	if r.GoroutineDumpPath == "" {
+		return string(buf)
+	}
+
+	f, err := os.OpenFile(r.GoroutineDumpPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing goroutine dump:", err)
+		return string(buf)
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "=== %s pid=%s %s\n%s\n", e.Time.Format(time.RFC3339Nano), e.PID, e.Message, buf)
+	return r.GoroutineDumpPath
+}
@@ -0,0 +1,52 @@
+package applogger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFatalGoroutineDump(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	code := -1
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	mem := &memorySink{}
+	logger := AppLogger{Path: "./tmp/fatal.ndjson", GoroutineDump: true, Sinks: []Sink{mem}}
+	logger.Initialise()
+	logger.Log("ERROR", "main", "app", "no dump")
+	logger.Fatal("main", "app", "cannot continue")
+
+	if code != 1 {
+		t.Fatalf("expected exit status 1, got %d", code)
+	}
+	if _, ok := mem.entries[0].Attributes[GoroutinesKey]; ok {
+		t.Fatalf("only fatal entries should carry a dump")
+	}
+	dump, _ := mem.entries[1].Attributes[GoroutinesKey].(string)
+	if !strings.Contains(dump, "TestFatalGoroutineDump") {
+		t.Fatalf("expected the goroutine dump, got %q", dump)
+	}
+}
+
+func TestFatalGoroutineDumpFile(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	mem := &memorySink{}
+	logger := AppLogger{Path: "./tmp/fatal.ndjson", GoroutineDump: true, GoroutineDumpPath: "./tmp/fatal.stacks", Sinks: []Sink{mem}}
+	logger.Initialise()
+	defer logger.Close()
+	logger.Log("FATAL", "main", "app", "cannot continue")
+
+	if mem.entries[0].Attributes[GoroutinesKey] != "./tmp/fatal.stacks" {
+		t.Fatalf("expected the dump path, got %v", mem.entries[0].Attributes[GoroutinesKey])
+	}
+	b, err := os.ReadFile("./tmp/fatal.stacks")
+	if err != nil || !strings.Contains(string(b), "cannot continue") || !strings.Contains(string(b), "goroutine ") {
+		t.Fatalf("unexpected dump file %q: %v", b, err)
+	}
+}
@@ -0,0 +1,87 @@
+package applogger
+
+import (
+	"context"
+	"time"
+)
+
+// Field is a typed attribute for LogAttrs, made by String, Int, Int64,
+// Float64, Bool, Err, Duration, Time or Any. The values of the typed ones
+// are encoded without going through reflection.
+type Field struct {
+	key   string
+	value interface{}
+}
+
+// String is a string attribute
+func String(key string, value string) Field {
+	return Field{key, value}
+}
+
+// Int is an integer attribute
+func Int(key string, value int) Field {
+	return Field{key, value}
+}
+
+// Int64 is an integer attribute
+func Int64(key string, value int64) Field {
+	return Field{key, value}
+}
+
+// Float64 is a number attribute, NaN and infinities fail to encode like
+// they do in LogFields
+func Float64(key string, value float64) Field {
+	return Field{key, value}
+}
+
+// Bool is a boolean attribute
+func Bool(key string, value bool) Field {
+	return Field{key, value}
+}
+
+// Err is the text of err under ErrorKey, nothing when err is nil
+func Err(err error) Field {
+	if err == nil {
+		return Field{}
+	}
+	return Field{ErrorKey, err.Error()}
+}
+
+// Duration is a duration attribute, written in nanoseconds like a
+// time.Duration given to LogFields
+func Duration(key string, value time.Duration) Field {
+	return Field{key, value}
+}
+
+// Time is a time attribute, written in RFC 3339 with nanoseconds
+func Time(key string, value time.Time) Field {
+	return Field{key, value}
+}
+
+// Any is an attribute of any type, encoded like the values of LogFields
+func Any(key string, value interface{}) Field {
+	return Field{key, value}
+}
+
+// LogAttrs writes message at level with fields, taking attributes from the
+// context like LogContext. Package and Func are those of the caller.
+//
+//	logger.LogAttrs(ctx, applogger.LevelWarn, "slow query",
+//		applogger.String("table", "orders"), applogger.Duration("took", took))
+func (r AppLogger) LogAttrs(ctx context.Context, level LogLevel, message string, fields ...Field) {
+	logPackage, logFunc := getCallerInfo(1)
+	if r.disabled(level, logPackage) {
+		return
+	}
+
+	s1 := r.now()
+
+	attrs := make([]attr, 0, len(fields))
+	for _, f := range fields {
+		if f.key != "" {
+			attrs = append(attrs, attr{f.key, f.value})
+		}
+	}
+	e := Entry{PID: r.newID(), Level: level.String(), Package: logPackage, Func: logFunc, Message: message, Time: s1}
+	r.logInternal(e, r.contextAttrs(ctx, s1, attrs))
+}
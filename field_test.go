@@ -0,0 +1,53 @@
+package applogger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLogAttrs(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}}
+	logger.Initialise()
+	defer logger.Close()
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 5, time.UTC)
+	logger.LogAttrs(context.Background(), LevelWarn, "slow query", String("table", "orders"), Int("rows", 3), Float64("ratio", 0.5),
+		Bool("cached", false), Duration("took", time.Second), Time("at", at), Err(errors.New("timeout")), Err(nil), Any("ids", []int{1, 2}))
+
+	if len(mem.entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(mem.entries))
+	}
+	e := mem.entries[0]
+	if e.Level != "WARN" || e.Func != "TestLogAttrs" || len(e.Attributes) != 8 {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if e.Attributes["took"] != time.Second || e.Attributes[ErrorKey] != "timeout" || e.Attributes["at"] != at {
+		t.Fatalf("unexpected attributes %v", e.Attributes)
+	}
+}
+
+func TestAppendJSONScalar(t *testing.T) {
+	values := []interface{}{
+		"", "plain", `quote " and \ slash`, "new\nline\ttab\r", "<b>&amp;</b>", "café     \U0001F600",
+		0, -42, int64(math.MaxInt64), true, false, time.Duration(1500),
+		0.0, 1.5, -0.000001, 0.0000001, 1e20, 1e21, 123456789.125, math.SmallestNonzeroFloat64, math.MaxFloat64,
+		time.Date(2024, 3, 1, 12, 0, 0, 500, time.FixedZone("", 3600)), time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	for _, v := range values {
+		want, _ := json.Marshal(v)
+		got, ok := appendJSONScalar(nil, v)
+		if !ok || string(got) != string(want) {
+			t.Errorf("%#v: got %s (%v), json.Marshal gives %s", v, got, ok, want)
+		}
+	}
+	for _, v := range []interface{}{"bell \a", "bad \xff utf8", math.NaN(), math.Inf(1), time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC), []int{1}} {
+		if _, ok := appendJSONScalar(nil, v); ok {
+			t.Errorf("%#v should be left to json.Marshal", v)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package applogger
+
+import "sort"
+
+// fieldSet holds the default fields of a logger created by WithFields
+// together with their JSON encoding, so the encoder can splice the bytes
+// instead of marshaling the same fields for every entry
+type fieldSet struct {
+	attrs  []attr
+	object []byte
+}
+
+func newFieldSet(attrs []attr) *fieldSet {
+	fs := &fieldSet{attrs: attrs}
+	e := Entry{base: &fieldSet{attrs: attrs}}
+	if b, err := encodeAttributes(e); err == nil {
+		fs.object = b
+	}
+	return fs
+}
+
+// WithFields returns a logger sharing the output and sinks of r which adds
+// fields to the attributes of every entry. Closing it only flushes, r
+// still owns the outputs.
+func (r AppLogger) WithFields(fields map[string]interface{}) AppLogger {
+	m := make(map[string]interface{}, len(fields))
+	if r.fields != nil {
+		for _, a := range r.fields.attrs {
+			m[a.key] = a.value
+		}
+	}
+	for k, v := range fields {
+		m[k] = v
+	}
+
+	attrs := appendMap(make([]attr, 0, len(m)), m)
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].key < attrs[j].key })
+	r.fields = newFieldSet(scrubAttrs(r.Scrub, attrs))
+	r.derived = true
+	return r
+}
+
+// WithoutFields returns a logger like r without the default fields keys,
+// for removing a field added further up
+func (r AppLogger) WithoutFields(keys ...string) AppLogger {
+	if r.fields == nil {
+		r.derived = true
+		return r
+	}
+	attrs := make([]attr, 0, len(r.fields.attrs))
+	for _, a := range r.fields.attrs {
+		removed := false
+		for _, k := range keys {
+			removed = removed || a.key == k
+		}
+		if !removed {
+			attrs = append(attrs, a)
+		}
+	}
+	r.fields = nil
+	if len(attrs) > 0 {
+		r.fields = newFieldSet(attrs)
+	}
+	r.derived = true
+	return r
+}
+
+// Fresh returns a logger like r without any default field, sharing the
+// output and sinks of r like WithFields
+func (r AppLogger) Fresh() AppLogger {
+	r.fields = nil
+	r.derived = true
+	return r
+}
+
+// LogFields writes an entry with extra attributes for this call only
+func (r AppLogger) LogFields(level string, logPackage string, logFunc string, message string, fields map[string]interface{}) {
+	if r.disabled(levelOf(level), logPackage) {
+		return
+	}
+
+	s1 := r.now()
+
+	r.logInternal(Entry{PID: r.newID(), Level: level, Package: logPackage, Func: logFunc, Message: message, Time: s1}, appendMap(nil, fields))
+}
+
+// logInternal attaches the default fields of the logger and the attributes
+// of the call to the entry, runs it through the pipeline and writes it
+func (r AppLogger) logInternal(e Entry, attrs []attr) {
+	if r.muted {
+		return
+	}
+	if e, ok := r.prepare(e, attrs); ok {
+		r.write(e)
+	}
+}
+
+// prepare attaches the attributes to the entry and runs it through the
+// pipeline (see Stage), reporting whether it is to be written. Nothing is
+// merged here, the encoder resolves repeated keys.
+func (r AppLogger) prepare(e Entry, attrs []attr) (Entry, bool) {
+	e.base = r.fields
+	e.attrs = attrs
+	e.route, e.routeOnly = r.to, r.toOnly
+	if r.Escalations != nil {
+		r.escalate(&e)
+	}
+	if !r.enabled(levelOf(e.Level), e.Package) {
+		return e, false
+	}
+	if r.Caller {
+		e.attrs = append(e.attrs, attr{CallerKey, callerLine()})
+	}
+	if r.ErrorStack && levelOf(e.Level) == LevelError {
+		e.attrs = append(e.attrs, r.stackAttr(e))
+	}
+	if r.GoroutineDump && levelOf(e.Level) >= LevelFatal {
+		e.attrs = append(e.attrs, attr{GoroutinesKey, r.goroutineDump(e)})
+	}
+	if !r.runStage(StageEnrich, &e) {
+		return e, false
+	}
+	if r.Rules != nil && !r.applyRules(&e) {
+		return e, false
+	}
+	if r.Retention != "" {
+		if _, ok := e.Lookup(RetentionKey); !ok {
+			e.attrs = append(e.attrs, attr{RetentionKey, r.Retention})
+		}
+	}
+	if r.Scrub != nil {
+		e.attrs = scrubAttrs(r.Scrub, e.attrs)
+	}
+	if !r.runStage(StageRedact, &e) {
+		return e, false
+	}
+	if r.Schema != nil && !r.applySchema(&e) {
+		return e, false
+	}
+	if !r.runStage(StageFilter, &e) || !r.runStage(StageSample, &e) {
+		return e, false
+	}
+	if r.quotas != nil && !r.quotas.allow(&e) {
+		return e, false
+	}
+	if r.StrictSerialization {
+		dropUnmarshalable(&e)
+	}
+	if r.MaxAttributes > 0 {
+		capAttributes(&e, r.MaxAttributes)
+	}
+	if r.Development {
+		r.checkMisuse(e)
+	}
+	return e, true
+}
@@ -0,0 +1,105 @@
+package applogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWithFields(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/fields.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath}
+	logger.Initialise()
+	service := logger.WithFields(map[string]interface{}{"service": "billing", "version": 3})
+	request := service.WithFields(map[string]interface{}{"request_id": "abc"})
+
+	service.Log("INFO", "main", "app", "defaults only")
+	request.LogFields("INFO", "main", "app", "with call fields", map[string]interface{}{"user_id": 42})
+	request.LogFields("INFO", "main", "app", "override", map[string]interface{}{"service": "payments"})
+	logger.Log("INFO", "main", "app", "no fields")
+	logger.Close()
+
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	want := []map[string]interface{}{
+		{"service": "billing", "version": float64(3)},
+		{"service": "billing", "version": float64(3), "request_id": "abc", "user_id": float64(42)},
+		{"service": "payments", "version": float64(3), "request_id": "abc"},
+		nil,
+	}
+	for i, line := range lines {
+		var got struct {
+			Attributes map[string]interface{} `json:"attributes"`
+		}
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line is not in a json format %s with error %s", line, err)
+		}
+		if len(got.Attributes) != len(want[i]) {
+			t.Fatalf("line %d: got attributes %v want %v", i, got.Attributes, want[i])
+		}
+		for k, v := range want[i] {
+			if got.Attributes[k] != v {
+				t.Fatalf("line %d: got %s=%v want %v", i, k, got.Attributes[k], v)
+			}
+		}
+	}
+}
+
+func BenchmarkWithFields(b *testing.B) {
+	logger := AppLogger{Path: os.DevNull}
+	logger.Initialise()
+	defer logger.Close()
+	derived := logger.WithFields(map[string]interface{}{"service": "billing", "region": "eu-west-1", "version": 3})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		derived.Log("INFO", "main", "app", "hot path")
+	}
+}
+
+func BenchmarkLogFields(b *testing.B) {
+	logger := AppLogger{Path: os.DevNull}
+	logger.Initialise()
+	defer logger.Close()
+	derived := logger.WithFields(map[string]interface{}{"service": "billing", "region": "eu-west-1", "version": 3})
+	fields := map[string]interface{}{"user_id": 42}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		derived.LogFields("INFO", "main", "app", "hot path", fields)
+	}
+}
+
+func TestWithoutFieldsAndFresh(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: os.DevNull, Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+	derived := logger.WithFields(map[string]interface{}{"service": "billing", "user_id": 42, "session": "s1"})
+
+	derived.WithoutFields("user_id", "session").Log("INFO", "main", "app", "without")
+	derived.Fresh().LogFields("INFO", "main", "app", "fresh", map[string]interface{}{"job": "nightly"})
+	derived.Log("INFO", "main", "app", "unchanged")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], `"attributes":{"service":"billing"}`) {
+		t.Fatalf("unexpected attributes %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"attributes":{"job":"nightly"}`) {
+		t.Fatalf("unexpected attributes %s", lines[1])
+	}
+	if !strings.Contains(lines[2], `"user_id":42`) {
+		t.Fatalf("the parent logger lost its fields %s", lines[2])
+	}
+}
@@ -0,0 +1,484 @@
+package applogger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultFlushInterval is used when BufferSize is set without FlushInterval
+const DefaultFlushInterval = time.Second
+
+// MaxAtomicEntrySize is the largest entry that SharedFile mode writes
+// without FileLock and still expects other processes not to interleave
+// with. Each entry is a single O_APPEND write, which local filesystems on
+// Linux and macOS apply atomically in practice, POSIX only guarantees it
+// for pipes up to PIPE_BUF. Use FileLock for larger entries or network
+// filesystems.
+const MaxAtomicEntrySize = 4096
+
+// fileWriter is the main output of a logger. When buffered, entries are
+// collected in a bufio.Writer which is flushed when full, every flush
+// interval and on Close.
+type fileWriter struct {
+	mu     sync.Mutex
+	file   *os.File
+	owned  bool
+	buf    *bufio.Writer
+	closed bool
+
+	// writer is written to instead of file when the output is not a file
+	writer io.Writer
+
+	// path and rotation are set when the file is cut at time boundaries
+	path     string
+	rotation *timeRotation
+	onRotate func(path string)
+	hooks    sync.WaitGroup
+
+	// errorPath is the live ErrorPath, never taken for a rotated file
+	errorPath string
+
+	// maxSize is set when the file is cut by size, size counts the bytes
+	// written to it
+	maxSize    int64
+	maxBackups int
+	size       int64
+
+	// compressRotated gzips rotated files, compressing tracks the running
+	// compressions. Rotated files older than maxAge are removed.
+	compressRotated bool
+	compressing     sync.WaitGroup
+	maxAge          time.Duration
+
+	// copyTruncate makes the writer notice external truncation or
+	// replacement of the file at path, see watchLocked
+	copyTruncate bool
+	checkEvery   time.Duration
+	lastCheck    time.Time
+	lastSize     int64
+
+	// shared keeps every entry in one write call, lock also takes an
+	// advisory file lock around it
+	shared bool
+	lock   bool
+
+	index *timeIndex
+
+	// gz compresses everything written to the file, every flush ends a
+	// gzip member so the file is readable up to the last flush
+	gz      *gzip.Writer
+	gzDirty bool
+
+	// syncOnClose syncs every file before it is closed, group syncs the
+	// file for the callers waiting on a durable entry
+	syncOnClose bool
+	group       *groupSync
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newFileWriter wraps f, buffering up to size bytes when size > 0. The file
+// is closed on Close only when owned.
+func newFileWriter(f *os.File, owned bool, size int, interval time.Duration) *fileWriter {
+	w := &fileWriter{file: f, owned: owned}
+	if size <= 0 {
+		return w
+	}
+
+	w.buf = bufio.NewWriterSize(fileTarget{w}, size)
+	if interval == 0 {
+		interval = DefaultFlushInterval
+	}
+	if interval > 0 {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.flusher(interval)
+	}
+	return w
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.rotation != nil && w.rotation.due(w.rotation.now()) {
+		if err := w.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+	if w.copyTruncate {
+		if now := time.Now(); now.Sub(w.lastCheck) >= w.checkEvery {
+			w.lastCheck = now
+			if err := w.watchLocked(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if w.maxSize > 0 {
+		if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+			if err := w.rotateSizeLocked(); err != nil {
+				return 0, err
+			}
+		}
+		w.size += int64(len(p))
+	}
+	if w.index != nil {
+		w.index.add(len(p), time.Now())
+	}
+	if w.buf == nil {
+		return w.writeFile(p)
+	}
+	if w.shared && len(p) > w.buf.Available() {
+		// never let the buffer split an entry across two writes
+		if err := w.buf.Flush(); err != nil {
+			return 0, err
+		}
+		if len(p) > w.buf.Size() {
+			return w.writeFile(p)
+		}
+	}
+	return w.buf.Write(p)
+}
+
+// fileTarget lets the buffer write through writeFile
+type fileTarget struct {
+	w *fileWriter
+}
+
+func (t fileTarget) Write(p []byte) (int, error) {
+	return t.w.writeFile(p)
+}
+
+// writeFile writes to the current file, under the advisory lock when one
+// is used. The caller holds w.mu.
+func (w *fileWriter) writeFile(p []byte) (int, error) {
+	if w.gz != nil {
+		w.gzDirty = true
+		return w.gz.Write(p)
+	}
+	if w.writer != nil {
+		return w.writer.Write(p)
+	}
+	if !w.lock {
+		return w.file.Write(p)
+	}
+	if err := lockFile(w.file); err != nil {
+		return 0, err
+	}
+	defer unlockFile(w.file)
+	return w.file.Write(p)
+}
+
+// share makes the writer safe for several processes appending to the same
+// file
+func (w *fileWriter) share(lock bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.shared = true
+	w.lock = lock
+}
+
+// compress gzips the output from now on
+func (w *fileWriter) compress() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.gz = gzip.NewWriter(w.file)
+}
+
+func (w *fileWriter) isClosed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closed
+}
+
+// Flush writes out any buffered entries
+func (w *fileWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flushLocked()
+}
+
+func (w *fileWriter) flushLocked() error {
+	if w.buf == nil || w.closed {
+		return nil
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if w.gz != nil && w.gzDirty {
+		// end the member, the next write starts a new one
+		w.gzDirty = false
+		if err := w.gz.Close(); err != nil {
+			return err
+		}
+		w.gz.Reset(w.file)
+	}
+	return nil
+}
+
+// Close stops the flusher, flushes the buffer and closes the file if the
+// writer owns it. Closing twice is a no-op.
+func (w *fileWriter) Close() error {
+	if w.stop != nil {
+		w.mu.Lock()
+		select {
+		case <-w.stop:
+		default:
+			close(w.stop)
+		}
+		w.mu.Unlock()
+		<-w.done
+	}
+
+	defer w.hooks.Wait()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	err := w.flushLocked()
+	w.closed = true
+	if w.index != nil {
+		w.index.Close()
+	}
+	if w.owned {
+		if cerr := w.closeFile(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// openLogFile opens path for appending, creating it when needed
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+}
+
+// rotateTime turns on time based rotation of the file at path. A file left
+// over from a period that has already ended, because the process was not
+// running at the boundary, is rotated right away.
+func (w *fileWriter) rotateTime(path string, every time.Duration, loc *time.Location, onRotate func(path string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.path = path
+	w.onRotate = onRotate
+	w.rotation = newTimeRotation(every, loc)
+
+	fi, err := w.file.Stat()
+	if err != nil || fi.Size() == 0 {
+		return err
+	}
+	if start, _ := w.rotation.period(fi.ModTime()); start.Before(w.rotation.start) {
+		w.rotation.start = start
+		err = w.rotateLocked()
+	}
+	return err
+}
+
+// rotateLocked renames the current file after the period it covers and
+// opens a new one at the original path
+func (w *fileWriter) rotateLocked() error {
+	if err := w.flushLocked(); err != nil {
+		return err
+	}
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	stamp := w.rotation.start.In(w.rotation.loc).Format(w.rotation.layout())
+	rotated := rotatedName(w.path, stamp)
+	renameErr := os.Rename(w.path, rotated)
+	if w.index != nil && renameErr == nil {
+		os.Rename(w.path+IndexSuffix, rotated+IndexSuffix)
+	}
+	if renameErr == nil {
+		w.afterRotate(rotated)
+	}
+
+	f, err := openLogFile(w.path)
+	if err != nil {
+		return err
+	}
+	w.file = f
+	if w.gz != nil {
+		w.gz.Reset(f)
+	}
+	w.rotation.reset(w.rotation.now())
+	if err := w.resetIndexLocked(w.path); err != nil {
+		return err
+	}
+	return renameErr
+}
+
+// watchCopyTruncate makes the writer safe for logrotate's copytruncate and
+// for external rename based rotation of the file at path
+func (w *fileWriter) watchCopyTruncate(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.path = path
+	w.copyTruncate = true
+	w.checkEvery = time.Second
+	if fi, err := w.file.Stat(); err == nil {
+		w.lastSize = fi.Size()
+	}
+}
+
+// watchLocked compares the open file with the one at path. When the file
+// was truncated it seeks back to the new end; the file is opened with
+// O_APPEND so the kernel already puts every write at the end and no stale
+// offset can NUL pad the file. When the file was moved away or replaced a
+// new one is opened at path.
+func (w *fileWriter) watchLocked() error {
+	cur, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	onDisk, err := os.Stat(w.path)
+	if err != nil || !os.SameFile(cur, onDisk) {
+		if err := w.flushLocked(); err != nil {
+			return err
+		}
+		w.closeFile()
+		f, err := openLogFile(w.path)
+		if err != nil {
+			return err
+		}
+		w.file = f
+		w.lastSize = 0
+		return w.resetIndexLocked(w.path)
+	}
+
+	if cur.Size() < w.lastSize {
+		if err := w.flushLocked(); err != nil {
+			return err
+		}
+		if _, err := w.file.Seek(0, io.SeekEnd); err != nil {
+			return err
+		}
+		if err := w.resetIndexLocked(w.path); err != nil {
+			return err
+		}
+	}
+	w.lastSize = cur.Size()
+	return nil
+}
+
+// reopen opens the file now at path and closes the current one, after an
+// external tool moved or truncated it. The current file is kept when the
+// new one cannot be opened.
+func (w *fileWriter) reopen(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || !w.owned {
+		return nil
+	}
+	if err := w.flushLocked(); err != nil {
+		return err
+	}
+	f, err := openLogFile(path)
+	if err != nil {
+		return err
+	}
+	w.closeFile()
+	w.file = f
+	if w.gz != nil {
+		w.gz.Reset(f)
+	}
+	if fi, err := f.Stat(); err == nil {
+		w.size, w.lastSize = fi.Size(), fi.Size()
+	}
+	return w.resetIndexLocked(path)
+}
+
+// indexEvery writes the time index sidecar of the file at path
+func (w *fileWriter) indexEvery(path string, every int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.path = path
+	fi, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	w.index, err = openTimeIndex(path, every, fi.Size())
+	return err
+}
+
+// resetIndexLocked starts a new index for the file now at path, after it
+// was rotated, truncated or replaced
+func (w *fileWriter) resetIndexLocked(path string) error {
+	if w.index == nil {
+		return nil
+	}
+	every := w.index.every
+	w.index.Close()
+	os.Remove(path + IndexSuffix)
+	fi, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	w.index, err = openTimeIndex(path, every, fi.Size())
+	return err
+}
+
+// afterRotate compresses the rotated file, removes the rotated files over
+// MaxAge and MaxBackups and runs the hook, on its own goroutine so logging
+// goes on meanwhile
+func (w *fileWriter) afterRotate(rotated string) {
+	if !w.compressRotated && w.maxAge <= 0 && w.maxBackups <= 0 && w.onRotate == nil {
+		return
+	}
+	w.hooks.Add(1)
+	if w.compressRotated {
+		w.compressing.Add(1)
+	}
+	go func() {
+		defer w.hooks.Done()
+		path := rotated
+		if w.compressRotated {
+			gz, err := gzipFile(rotated)
+			w.compressing.Done()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "applogger: compressing %s: %v\n", rotated, err)
+			} else {
+				path = gz
+			}
+		}
+		w.pruneRotated()
+		if w.onRotate != nil {
+			w.runRotateHook(path)
+		}
+	}()
+}
+
+// runRotateHook calls the post rotation hook, a panic in the hook is
+// reported on stderr instead of crashing the process
+func (w *fileWriter) runRotateHook(path string) {
+	defer func() {
+		if p := recover(); p != nil {
+			fmt.Fprintf(os.Stderr, "applogger: rotation hook panicked for %s: %v\n", path, p)
+		}
+	}()
+	w.onRotate(path)
+}
+
+func (w *fileWriter) flusher(interval time.Duration) {
+	defer close(w.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			w.Flush()
+		case <-w.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package applogger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBufferedFlushOnClose(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/buffered.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, BufferSize: 64 * 1024, FlushInterval: -1}
+	logger.Initialise()
+	logger.Log("INFO", "main", "app", "buffered")
+
+	if b, _ := os.ReadFile(filePath); len(b) != 0 {
+		t.Fatalf("entry reached the file before a flush: %s", b)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filePath); !strings.Contains(string(b), "buffered") {
+		t.Fatalf("entry was not flushed on close: %q", b)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("second close returned %s", err)
+	}
+}
+
+func TestBufferedFlushInterval(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/interval.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, BufferSize: 64 * 1024, FlushInterval: 10 * time.Millisecond}
+	logger.Initialise()
+	defer logger.Close()
+	logger.Log("INFO", "main", "app", "flushed by interval")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if b, _ := os.ReadFile(filePath); strings.Contains(string(b), "flushed by interval") {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("entry was not flushed by the interval")
+}
+
+func TestCopyTruncate(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/copytruncate.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, CopyTruncate: true}
+	logger.Initialise()
+	defer logger.Close()
+	logger.out.checkEvery = 0
+
+	logger.Log("INFO", "main", "app", "before truncate")
+	logger.Log("INFO", "main", "app", "before truncate")
+	if err := os.Truncate(filePath, 0); err != nil {
+		t.Fatal(err)
+	}
+	logger.Log("INFO", "main", "app", "after truncate")
+
+	b, _ := os.ReadFile(filePath)
+	if strings.ContainsRune(string(b), 0) || isJSON(strings.TrimSpace(string(b))) != nil {
+		t.Fatalf("expected a single clean entry after truncation, got %q", b)
+	}
+
+	// moving the file away makes the logger open a new one
+	if err := os.Rename(filePath, filePath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Log("INFO", "main", "app", "after rename")
+	b, err := os.ReadFile(filePath)
+	if err != nil || !strings.Contains(string(b), "after rename") {
+		t.Fatalf("expected the entry in a new file, got %q %v", b, err)
+	}
+}
+
+func TestSharedFile(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/shared.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	// two loggers stand in for two processes writing the same file
+	var loggers []AppLogger
+	for i := 0; i < 2; i++ {
+		logger := AppLogger{Path: filePath, SharedFile: true, FileLock: true, BufferSize: 512, FlushInterval: -1}
+		logger.Initialise()
+		loggers = append(loggers, logger)
+	}
+
+	done := make(chan struct{})
+	for _, logger := range loggers {
+		go func(logger AppLogger) {
+			for i := 0; i < 200; i++ {
+				logger.Log("INFO", "main", "app", strings.Repeat("x", i))
+			}
+			done <- struct{}{}
+		}(logger)
+	}
+	<-done
+	<-done
+	for _, logger := range loggers {
+		logger.Close()
+	}
+
+	b, _ := os.ReadFile(filePath)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 400 {
+		t.Fatalf("expected 400 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if err := isJSON(line); err != nil {
+			t.Fatalf("interleaved line %q: %s", line, err)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	read := func() string {
+		f, err := os.Open("./tmp/app.ndjson.gz")
+		if err != nil {
+			t.Fatalf("Couldn't open log file: %v", err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("not a gzip file: %v", err)
+		}
+		b, err := io.ReadAll(gz)
+		if err != nil {
+			t.Fatalf("gzip stream is not readable: %v", err)
+		}
+		return string(b)
+	}
+
+	logger := AppLogger{Path: "./tmp/app.ndjson.gz", Compress: true, FlushInterval: -1}
+	logger.Initialise()
+	logger.Log("INFO", "main", "app", "first")
+	logger.Flush()
+	logger.Flush()
+	if got := read(); strings.Count(got, "\n") != 1 || !strings.Contains(got, "first") {
+		t.Fatalf("expected the flushed entry while still open, got %q", got)
+	}
+
+	logger.Log("INFO", "main", "app", "second")
+	logger.Close()
+	if got := read(); strings.Count(got, "\n") != 2 || !strings.Contains(got, "second") {
+		t.Fatalf("expected both entries after Close, got %q", got)
+	}
+
+	bad := AppLogger{Path: "./tmp/app.ndjson.gz", Compress: true, SharedFile: true}
+	if err := bad.open(); err == nil {
+		t.Fatal("expected an error for Compress with SharedFile")
+	}
+}
+
+func TestErrorPath(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	for _, split := range []bool{false, true} {
+		os.Remove("./tmp/app.log")
+		os.Remove("./tmp/app.error.log")
+		logger := AppLogger{Path: "./tmp/app.log", ErrorPath: "./tmp/app.error.log", SplitErrors: split}
+		logger.Initialise()
+		logger.Log("INFO", "main", "app", "fine")
+		logger.Log("ERROR", "main", "app", "broken")
+		logger.Log("FATAL", "main", "app", "dead")
+		logger.Close()
+
+		main, _ := os.ReadFile("./tmp/app.log")
+		errs, _ := os.ReadFile("./tmp/app.error.log")
+		if strings.Count(string(errs), "\n") != 2 || strings.Contains(string(errs), "fine") {
+			t.Fatalf("error file should hold the 2 errors only, got %q", errs)
+		}
+		want := 3
+		if split {
+			want = 1
+		}
+		if strings.Count(string(main), "\n") != want {
+			t.Fatalf("expected %d entries in the main file with SplitErrors=%v, got %q", want, split, main)
+		}
+	}
+}
+
+func TestErrorsToStderr(t *testing.T) {
+	stdout, stderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+	mem := &memorySink{}
+	logger := AppLogger{Format: FormatJSON, ErrorsToStderr: true, Sinks: []Sink{mem}}
+	err := logger.Open()
+	os.Stdout, os.Stderr = stdout, stderr
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Log("INFO", "main", "app", "fine")
+	logger.Log("ERROR", "main", "app", "broken")
+	logger.Close()
+	outW.Close()
+	errW.Close()
+
+	out, _ := io.ReadAll(outR)
+	errs, _ := io.ReadAll(errR)
+	if !strings.Contains(string(out), "fine") || strings.Contains(string(out), "broken") {
+		t.Fatalf("stdout should hold the info entry only, got %q", out)
+	}
+	if !strings.Contains(string(errs), "broken") || strings.Contains(string(errs), "fine") {
+		t.Fatalf("stderr should hold the error entry only, got %q", errs)
+	}
+	if len(mem.entries) != 2 {
+		t.Fatalf("sinks should get every entry, got %d", len(mem.entries))
+	}
+
+	if err := (&AppLogger{ErrorsToStderr: true, ErrorPath: "./tmp/app.error.log"}).Open(); err == nil {
+		t.Fatal("expected an error for ErrorsToStderr with ErrorPath")
+	}
+}
+
+func TestReopen(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/reopen.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, BufferSize: 64 * 1024, FlushInterval: -1}
+	logger.Initialise()
+	defer logger.Close()
+	logger.Log("INFO", "main", "app", "before")
+	if err := os.Rename(filePath, filePath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	logger.Log("INFO", "main", "app", "after")
+	logger.Flush()
+
+	if b, _ := os.ReadFile(filePath + ".1"); !strings.Contains(string(b), "before") || strings.Contains(string(b), "after") {
+		t.Fatalf("unexpected moved file %q", b)
+	}
+	if b, _ := os.ReadFile(filePath); !strings.Contains(string(b), "after") || strings.Contains(string(b), "before") {
+		t.Fatalf("unexpected reopened file %q", b)
+	}
+}
@@ -0,0 +1,302 @@
+package applogger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter is a compiled filter expression, for example
+//
+//	level>=warn && attributes.user_id=="42" && message~"timeout"
+//
+// Comparisons are field op value with the fields of LogEntry.Field and the
+// operators == != < <= > >= ~ (regexp match) and !~. Comparisons combine
+// with &&, || and ! and can be grouped with parentheses. level compares by
+// severity, time against RFC 3339 values, numbers numerically and
+// everything else as text. A comparison on a missing field is false, except
+// for != and !~.
+type Filter struct {
+	root filterNode
+	expr string
+}
+
+// ParseFilter compiles a filter expression
+func ParseFilter(expr string) (*Filter, error) {
+	p := &filterParser{tokens: nil}
+	if err := p.lex(expr); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("applogger: filter: unexpected %q", p.tokens[p.pos].text)
+	}
+	return &Filter{root: root, expr: expr}, nil
+}
+
+// Match reports whether the entry matches the filter
+func (f *Filter) Match(e LogEntry) bool {
+	return f.root.match(e)
+}
+
+// String returns the source expression
+func (f *Filter) String() string {
+	return f.expr
+}
+
+type filterNode interface {
+	match(e LogEntry) bool
+}
+
+type andNode struct{ left, right filterNode }
+type orNode struct{ left, right filterNode }
+type notNode struct{ node filterNode }
+
+func (n andNode) match(e LogEntry) bool { return n.left.match(e) && n.right.match(e) }
+func (n orNode) match(e LogEntry) bool  { return n.left.match(e) || n.right.match(e) }
+func (n notNode) match(e LogEntry) bool { return !n.node.match(e) }
+
+type compareNode struct {
+	field string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (n compareNode) match(e LogEntry) bool {
+	v, ok := e.Field(n.field)
+	if !ok || v == nil {
+		return n.op == "!=" || n.op == "!~"
+	}
+
+	switch n.op {
+	case "~":
+		return n.re.MatchString(columnString(v))
+	case "!~":
+		return !n.re.MatchString(columnString(v))
+	}
+
+	var c int
+	switch v := v.(type) {
+	case time.Time:
+		t, err := time.Parse(time.RFC3339Nano, n.value)
+		if err != nil {
+			return false
+		}
+		c = v.Compare(t)
+	default:
+		if n.field == "level" {
+			c = compareInts(int(levelOf(columnString(v))), int(levelOf(n.value)))
+			break
+		}
+		s := columnString(v)
+		a, aerr := strconv.ParseFloat(s, 64)
+		b, berr := strconv.ParseFloat(n.value, 64)
+		if aerr == nil && berr == nil {
+			c = compareFloats(a, b)
+		} else {
+			c = strings.Compare(s, n.value)
+		}
+	}
+
+	switch n.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+func compareInts(a, b int) int {
+	return compareFloats(float64(a), float64(b))
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+type filterToken struct {
+	kind string // ident, string, op, (, ), &&, ||, !
+	text string
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+var filterOps = []string{"&&", "||", "==", "!=", "<=", ">=", "!~", "<", ">", "~", "!", "(", ")"}
+
+func (p *filterParser) lex(expr string) error {
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			s, n, err := unquoteFilterString(expr[i:])
+			if err != nil {
+				return err
+			}
+			p.tokens = append(p.tokens, filterToken{"string", s})
+			i += n
+		case c == '_' || c == '-' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			j := i
+			for j < len(expr) && (expr[j] == '_' || expr[j] == '-' || expr[j] == '.' || expr[j] == ':' || expr[j] == '+' || unicode.IsLetter(rune(expr[j])) || unicode.IsDigit(rune(expr[j]))) {
+				j++
+			}
+			p.tokens = append(p.tokens, filterToken{"ident", expr[i:j]})
+			i = j
+		default:
+			matched := false
+			for _, op := range filterOps {
+				if strings.HasPrefix(expr[i:], op) {
+					kind := "op"
+					switch op {
+					case "&&", "||", "!", "(", ")":
+						kind = op
+					}
+					p.tokens = append(p.tokens, filterToken{kind, op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Errorf("applogger: filter: unexpected %q at %d", c, i)
+			}
+		}
+	}
+	return nil
+}
+
+// unquoteFilterString reads a double quoted string with backslash escapes
+// and returns it with the number of bytes consumed
+func unquoteFilterString(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("applogger: filter: unterminated string")
+}
+
+func (p *filterParser) peek() filterToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return filterToken{}
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	switch p.peek().kind {
+	case "!":
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	case "(":
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != ")" {
+			return nil, fmt.Errorf("applogger: filter: missing )")
+		}
+		return node, nil
+	}
+
+	field := p.next()
+	if field.kind != "ident" {
+		return nil, fmt.Errorf("applogger: filter: expected a field, got %q", field.text)
+	}
+	op := p.next()
+	if op.kind != "op" {
+		return nil, fmt.Errorf("applogger: filter: expected an operator after %s", field.text)
+	}
+	value := p.next()
+	if value.kind != "ident" && value.kind != "string" {
+		return nil, fmt.Errorf("applogger: filter: expected a value after %s%s", field.text, op.text)
+	}
+
+	n := compareNode{field: field.text, op: op.text, value: value.text}
+	if n.op == "~" || n.op == "!~" {
+		re, err := regexp.Compile(n.value)
+		if err != nil {
+			return nil, fmt.Errorf("applogger: filter: %w", err)
+		}
+		n.re = re
+	}
+	return n, nil
+}
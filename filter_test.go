@@ -0,0 +1,67 @@
+package applogger
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	entries := []LogEntry{}
+	r := NewReader(strings.NewReader(testLog))
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+
+	cases := map[string][]bool{
+		`level>=warn`: {false, true},
+		`level>=warn && attributes.user_id=="42"`:     {false, false},
+		`level<warn && attributes.user_id=="42"`:      {true, false},
+		`message~"fail" || package==main`:             {true, true},
+		`!(code>=500)`:                                {true, false},
+		`code>=500 && duration<2`:                     {false, true},
+		`attributes.user_id!="42"`:                    {false, true},
+		`time>2020-08-23T10:00:00Z`:                   {false, true},
+		`message!~"^start" && (level==ERROR || x==1)`: {false, true},
+	}
+	for expr, want := range cases {
+		f, err := ParseFilter(expr)
+		if err != nil {
+			t.Fatalf("%s: %s", expr, err)
+		}
+		for i, e := range entries {
+			if got := f.Match(e); got != want[i] {
+				t.Fatalf("%s on entry %d: got %v want %v", expr, i, got, want[i])
+			}
+		}
+	}
+
+	for _, bad := range []string{`level>=`, `level warn`, `(level==warn`, `message~"("`, `message=="open`} {
+		if _, err := ParseFilter(bad); err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestReaderSetFilter(t *testing.T) {
+	f, _ := ParseFilter(`level==error`)
+	r := NewReader(strings.NewReader(testLog))
+	r.SetFilter(f)
+	e, err := r.Next()
+	if err != nil || e.PID != "2" {
+		t.Fatalf("unexpected entry %v %v", e, err)
+	}
+	if !strings.Contains(string(r.Line()), `"pid":"2"`) {
+		t.Fatalf("unexpected raw line %s", r.Line())
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
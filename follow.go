@@ -0,0 +1,176 @@
+package applogger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultPollInterval is how often a Follower checks a log for new entries
+const DefaultPollInterval = 200 * time.Millisecond
+
+// DefaultRotationGrace is how long a Follower keeps reading a log rotated
+// away once nothing more is written to it
+const DefaultRotationGrace = time.Second
+
+// Follower reads the entries of a log as they are written, like tail -f.
+// Only complete lines are returned, a line still being written is picked up
+// once its newline arrives. When the file is truncated the follower starts
+// over from the beginning, when it is rotated away it finishes the old
+// file and continues with the new one.
+type Follower struct {
+	path string
+	// PollInterval defaults to DefaultPollInterval
+	PollInterval time.Duration
+	// RotationGrace is how long the old file has to stay idle at its end
+	// after a rotation before the follower moves on to the new one, so the
+	// entries a writer still adds to it are not lost. It defaults to
+	// DefaultRotationGrace.
+	RotationGrace time.Duration
+
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64
+	pending []byte
+	line    []byte
+	// idleSince is when the follower last found the old file rotated away
+	// and read to its end, zero while not draining one
+	idleSince time.Time
+}
+
+// Follow starts following the log at path from the byte offset, usually 0
+// or a value saved with a Checkpoint
+func Follow(path string, offset int64) (*Follower, error) {
+	f := &Follower{path: path}
+	if err := f.open(offset); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Follower) open(offset int64) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	if fi, err := file.Stat(); err == nil && fi.Size() < offset {
+		// the file was truncated or replaced since the offset was saved
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file = file
+	f.reader = bufio.NewReader(file)
+	f.offset = offset
+	f.pending = f.pending[:0]
+	f.idleSince = time.Time{}
+	return nil
+}
+
+// Offset returns the byte offset just after the last entry returned by
+// Next, which is where following should resume after a restart
+func (f *Follower) Offset() int64 {
+	return f.offset
+}
+
+// Line returns the raw bytes of the last entry returned by Next
+func (f *Follower) Line() []byte {
+	return f.line
+}
+
+// Next waits for the next entry until ctx is done
+func (f *Follower) Next(ctx context.Context) (LogEntry, error) {
+	var e LogEntry
+	for {
+		chunk, err := f.reader.ReadBytes('\n')
+		f.pending = append(f.pending, chunk...)
+		if len(chunk) > 0 {
+			f.idleSince = time.Time{}
+		}
+		if err == nil {
+			line := f.pending
+			f.offset += int64(len(line))
+			f.pending = nil
+			f.line = bytes.TrimRight(line, "\r\n")
+			if len(bytes.TrimSpace(f.line)) == 0 {
+				continue
+			}
+			if err := json.Unmarshal(f.line, &e); err != nil {
+				return e, fmt.Errorf("applogger: %s at offset %d: %w", f.path, f.offset-int64(len(line)), err)
+			}
+			return e, nil
+		}
+		if err != io.EOF {
+			return e, err
+		}
+
+		if err := f.checkFile(); err != nil {
+			return e, err
+		}
+		if f.reader.Buffered() > 0 {
+			continue
+		}
+
+		interval := f.PollInterval
+		if interval <= 0 {
+			interval = DefaultPollInterval
+		}
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return e, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// checkFile reopens the log when the file at path was truncated, or
+// replaced and the old file was idle for the RotationGrace
+func (f *Follower) checkFile() error {
+	cur, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	onDisk, err := os.Stat(f.path)
+	if err != nil {
+		// rotated away and not recreated yet
+		return nil
+	}
+	if !os.SameFile(cur, onDisk) {
+		grace := f.RotationGrace
+		if grace <= 0 {
+			grace = DefaultRotationGrace
+		}
+		if f.idleSince.IsZero() {
+			f.idleSince = time.Now()
+		}
+		if time.Since(f.idleSince) < grace {
+			return nil
+		}
+		if len(f.pending) > 0 {
+			// the old file ends with a partial line, it is never finished
+			f.pending = f.pending[:0]
+		}
+		return f.open(0)
+	}
+	if cur.Size() < f.offset+int64(len(f.pending)) {
+		return f.open(0)
+	}
+	return nil
+}
+
+// Close closes the followed file
+func (f *Follower) Close() error {
+	return f.file.Close()
+}
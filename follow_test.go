@@ -0,0 +1,123 @@
+package applogger
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFollowWithCheckpoint(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/follow.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath}
+	logger.Initialise()
+	defer logger.Close()
+	logger.Log("INFO", "main", "app", "one")
+	logger.Log("INFO", "main", "app", "two")
+
+	cp := NewCheckpoint(filePath, "shipper")
+	offset, err := cp.Offset()
+	if err != nil || offset != 0 {
+		t.Fatalf("expected a fresh checkpoint, got %d %v", offset, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	f, err := Follow(filePath, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.PollInterval = 5 * time.Millisecond
+	e, err := f.Next(ctx)
+	if err != nil || e.Message != "one" {
+		t.Fatalf("unexpected entry %v %v", e, err)
+	}
+	if err := cp.Commit(f.Offset()); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// a restarted shipper resumes after "one"
+	offset, _ = cp.Offset()
+	f, err = Follow(filePath, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.PollInterval = 5 * time.Millisecond
+	if e, err := f.Next(ctx); err != nil || e.Message != "two" {
+		t.Fatalf("unexpected entry after resume %v %v", e, err)
+	}
+
+	// entries written later are picked up, including a partial line once
+	// it is completed
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		logger.Log("INFO", "main", "app", "three")
+	}()
+	if e, err := f.Next(ctx); err != nil || e.Message != "three" {
+		t.Fatalf("unexpected followed entry %v %v", e, err)
+	}
+
+	raw, _ := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0666)
+	raw.WriteString(`{"message":"fo`)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		raw.WriteString(`ur"}` + "\n")
+		raw.Close()
+	}()
+	if e, err := f.Next(ctx); err != nil || e.Message != "four" {
+		t.Fatalf("unexpected entry from a partial line %v %v", e, err)
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	if _, err := f.Next(short); err != context.DeadlineExceeded {
+		t.Fatalf("expected the context to end the wait, got %v", err)
+	}
+}
+
+func TestFollowRotation(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/follow-rotate.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	os.WriteFile(filePath, []byte(`{"message":"old"}`+"\n"), 0666)
+	f, err := Follow(filePath, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.PollInterval = 5 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if e, _ := f.Next(ctx); e.Message != "old" {
+		t.Fatalf("unexpected entry %v", e)
+	}
+
+	f.RotationGrace = 200 * time.Millisecond
+	os.Rename(filePath, filePath+".1")
+	os.WriteFile(filePath, []byte(`{"message":"new"}`+"\n"), 0666)
+	short, cancelShort := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelShort()
+	if _, err := f.Next(short); err != context.DeadlineExceeded {
+		t.Fatalf("expected the old file to be drained first, got %v", err)
+	}
+
+	old, _ := os.OpenFile(filePath+".1", os.O_APPEND|os.O_WRONLY, 0666)
+	old.WriteString(`{"message":"late"}` + "\n")
+	old.Close()
+	if e, err := f.Next(ctx); err != nil || e.Message != "late" {
+		t.Fatalf("expected the entry written to the old file after the rotation, got %v %v", e, err)
+	}
+	if e, err := f.Next(ctx); err != nil || e.Message != "new" {
+		t.Fatalf("expected the entry of the new file, got %v %v", e, err)
+	}
+}
@@ -0,0 +1,172 @@
+package applogger
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Format selects how entries are encoded on the output
+type Format int
+
+const (
+	// FormatAuto writes console-pretty lines when the output is a terminal
+	// and NDJSON otherwise (files, pipes, containers without a tty)
+	FormatAuto Format = iota
+	// FormatJSON always writes one JSON object per line
+	FormatJSON
+	// FormatConsole always writes human readable lines
+	FormatConsole
+	// FormatOTel writes OTLP/JSON lines as the OpenTelemetry Collector file
+	// exporter does, see OTelEncoder
+	FormatOTel
+	// FormatGCP writes the structured logging layout of Google Cloud
+	// Logging, see GCPEncoder
+	FormatGCP
+)
+
+// String returns the name of the format
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatConsole:
+		return "console"
+	case FormatOTel:
+		return "otel"
+	case FormatGCP:
+		return "gcp"
+	default:
+		return "auto"
+	}
+}
+
+// ParseFormat converts a format name (auto, json, ndjson, console, pretty,
+// otel, gcp) to a Format
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "auto":
+		return FormatAuto, nil
+	case "json", "ndjson":
+		return FormatJSON, nil
+	case "console", "pretty", "text":
+		return FormatConsole, nil
+	case "otel", "otlp":
+		return FormatOTel, nil
+	case "gcp", "google":
+		return FormatGCP, nil
+	}
+	return FormatAuto, fmt.Errorf("applogger: unknown format %q", name)
+}
+
+// isTerminal reports whether f is attached to a character device such as a tty
+func isTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// resolveFormat turns FormatAuto into a concrete format for the given output
+// and reports whether the output supports colors
+func resolveFormat(f Format, out *os.File) (Format, bool) {
+	tty := isTerminal(out)
+	if f == FormatAuto {
+		if tty {
+			return FormatConsole, true
+		}
+		return FormatJSON, false
+	}
+	return f, tty && f == FormatConsole
+}
+
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorGray   = "\033[90m"
+)
+
+func levelColor(level string) string {
+	switch strings.ToUpper(level) {
+	case "ERROR", "FATAL", "PANIC":
+		return colorRed
+	case "WARN", "WARNING":
+		return colorYellow
+	case "INFO":
+		return colorBlue
+	default:
+		return colorGray
+	}
+}
+
+// consoleLine renders an entry as a single human readable line, with the
+// changes since the previous entry of its request when diff is set. A
+// stack attribute follows on its own indented lines, see consoleStack.
+func consoleLine(e Entry, color bool, diff *ConsoleDiff) string {
+	var b strings.Builder
+	b.WriteString(e.Time.Format("2006-01-02 15:04:05.000"))
+	b.WriteByte(' ')
+	level := fmt.Sprintf("%-5s", strings.ToUpper(e.Level))
+	if color {
+		b.WriteString(levelColor(e.Level) + level + colorReset)
+	} else {
+		b.WriteString(level)
+	}
+	b.WriteByte(' ')
+	b.WriteString(e.Package + "." + e.Func)
+	b.WriteString(" ")
+	b.WriteString(e.Message)
+	if e.HTTP {
+		fmt.Fprintf(&b, " code=%d duration=%v", e.Code, e.Duration)
+	}
+	v, _ := e.Lookup(StackKey)
+	stack, hasStack := v.(string)
+	var prev map[string]string
+	if diff != nil {
+		prev = diff.swap(e)
+	}
+	highlight, dim, reset := "", "", ""
+	if color {
+		highlight, dim, reset = colorBold+levelColor(e.Level), colorGray, colorReset
+	}
+	e.eachAttribute(func(k string, v interface{}) {
+		if hasStack && k == StackKey {
+			return
+		}
+		if prev == nil {
+			fmt.Fprintf(&b, " %s=%v", k, v)
+			return
+		}
+		value := fmt.Sprint(v)
+		old, seen := prev[k]
+		switch {
+		case !seen:
+			fmt.Fprintf(&b, " %s+%s=%s%s", highlight, k, value, reset)
+		case old != value:
+			fmt.Fprintf(&b, " %s=%s%s%s→%s%s%s", k, dim, old, reset, highlight, value, reset)
+		default:
+			fmt.Fprintf(&b, " %s%s=%s%s", dim, k, value, reset)
+		}
+	})
+	if hasStack {
+		consoleStack(&b, stack, color)
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,73 @@
+package applogger
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFormatAutoOnFileIsJSON(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/auto.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath}
+	logger.Initialise()
+	if logger.format != FormatJSON {
+		t.Fatalf("expected json format for a file, got %s", logger.format)
+	}
+	logger.Log("INFO", "main", "app", "auto format")
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if err := isJSON(scanner.Text()); err != nil {
+			t.Fatalf("line is not in a json format %s with error %s", scanner.Text(), err)
+		}
+	}
+}
+
+func TestFormatConsoleOverride(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/console.log"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, Format: FormatConsole}
+	logger.Initialise()
+	logger.LogHTTP("ERROR", "controller", "perform", "failed", 500, 1.5)
+
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(b)
+	if isJSON(line) == nil {
+		t.Fatalf("expected console line, got json %s", line)
+	}
+	if !strings.Contains(line, "ERROR controller.perform failed code=500") {
+		t.Fatalf("unexpected console line %q", line)
+	}
+	if strings.Contains(line, "\033[") {
+		t.Fatalf("colors must not be written to a file %q", line)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := map[string]Format{"": FormatAuto, "JSON": FormatJSON, "ndjson": FormatJSON, "pretty": FormatConsole, "otel": FormatOTel, "gcp": FormatGCP}
+	for name, want := range cases {
+		got, err := ParseFormat(name)
+		if err != nil || got != want {
+			t.Fatalf("ParseFormat(%q) = %s, %v want %s", name, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
@@ -0,0 +1,99 @@
+package applogger
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Attributes read by GCPEncoder for the trace of an entry
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// GCPEncoder writes entries in the structured logging layout of Google
+// Cloud Logging, so stdout logs on Cloud Run and GKE get the right
+// severity, trace links and request details without an agent config:
+// severity, the trace_id and span_id attributes as
+// logging.googleapis.com/trace and spanId, Package and Func as
+// sourceLocation and HTTP entries, with the method, path and client_ip
+// attributes of the HTTP middleware, as httpRequest. Other attributes are
+// written under "attributes".
+type GCPEncoder struct {
+	// ProjectID qualifies trace IDs as projects/ProjectID/traces/ID, which
+	// Cloud Logging needs to link them
+	ProjectID string
+}
+
+// gcpSeverity maps a level to a Cloud Logging severity
+func gcpSeverity(l LogLevel) string {
+	switch {
+	case l <= LevelDebug:
+		return "DEBUG"
+	case l == LevelInfo:
+		return "INFO"
+	case l == LevelWarn:
+		return "WARNING"
+	case l == LevelError:
+		return "ERROR"
+	}
+	return "CRITICAL"
+}
+
+// Encode writes the entry as a Cloud Logging JSON payload
+func (g GCPEncoder) Encode(e Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONInterned(&buf, "severity", gcpSeverity(levelOf(e.Level)))
+	writeJSONField(&buf, "message", e.Message)
+	writeJSONField(&buf, "time", e.Time)
+	writeJSONField(&buf, "logging.googleapis.com/insertId", e.PID)
+	writeJSONField(&buf, "logging.googleapis.com/sourceLocation", map[string]string{"function": e.Package + "." + e.Func})
+
+	special := map[string]interface{}{}
+	rest := make(map[string]interface{})
+	e.eachAttribute(func(k string, v interface{}) {
+		switch k {
+		case TraceIDKey, SpanIDKey:
+			special[k] = v
+		case "method", "path", "client_ip":
+			if e.HTTP {
+				special[k] = v
+				return
+			}
+			rest[k] = v
+		default:
+			rest[k] = v
+		}
+	})
+
+	if trace, ok := special[TraceIDKey].(string); ok && trace != "" {
+		if g.ProjectID != "" && !strings.HasPrefix(trace, "projects/") {
+			trace = "projects/" + g.ProjectID + "/traces/" + trace
+		}
+		writeJSONField(&buf, "logging.googleapis.com/trace", trace)
+	}
+	if span, ok := special[SpanIDKey].(string); ok && span != "" {
+		writeJSONField(&buf, "logging.googleapis.com/spanId", span)
+	}
+	if e.HTTP {
+		request := map[string]interface{}{
+			"status":  e.Code,
+			"latency": fmt.Sprintf("%.9fs", e.Duration),
+		}
+		for key, name := range map[string]string{"method": "requestMethod", "path": "requestUrl", "client_ip": "remoteIp"} {
+			if v, ok := special[key]; ok {
+				request[name] = v
+			}
+		}
+		writeJSONField(&buf, "httpRequest", request)
+	}
+	if len(rest) > 0 {
+		if err := writeJSONField(&buf, "attributes", rest); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,34 @@
+package applogger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestGCPEncoder(t *testing.T) {
+	e := Entry{PID: "1", Level: "WARN", Package: "http", Func: "ServeHTTP", Message: "GET /users/{id}", Time: time.Now(), HTTP: true, Code: 404, Duration: 0.25,
+		Attributes: map[string]interface{}{"method": "GET", "path": "/users/42", "client_ip": "203.0.113.9", TraceIDKey: "abc123", "user_id": 42}}
+	line, err := GCPEncoder{ProjectID: "shop"}.Encode(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(line, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["severity"] != "WARNING" || m["logging.googleapis.com/trace"] != "projects/shop/traces/abc123" {
+		t.Fatalf("unexpected document %s", line)
+	}
+	req := m["httpRequest"].(map[string]interface{})
+	if req["status"] != float64(404) || req["latency"] != "0.250000000s" || req["requestMethod"] != "GET" || req["requestUrl"] != "/users/42" || req["remoteIp"] != "203.0.113.9" {
+		t.Fatalf("unexpected httpRequest %v", req)
+	}
+	if loc := m["logging.googleapis.com/sourceLocation"].(map[string]interface{}); loc["function"] != "http.ServeHTTP" {
+		t.Fatalf("unexpected sourceLocation %v", loc)
+	}
+	attributes := m["attributes"].(map[string]interface{})
+	if len(attributes) != 1 || attributes["user_id"] != float64(42) {
+		t.Fatalf("special fields left in attributes %v", attributes)
+	}
+}
@@ -0,0 +1,147 @@
+package applogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SinkHealth is the state of one sink of a logger
+type SinkHealth struct {
+	// Name is the type of the sink
+	Name string `json:"name"`
+	// Connected is false when the last write failed or the sink reports
+	// it cannot reach its backend
+	Connected     bool       `json:"connected"`
+	Written       uint64     `json:"written"`
+	Failed        uint64     `json:"failed"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorTime *time.Time `json:"last_error_time,omitempty"`
+	// QueueDepth is the number of entries the sink holds and has not
+	// delivered yet
+	QueueDepth int `json:"queue_depth"`
+	// Dropped is the number of entries the sink gave up on
+	Dropped uint64 `json:"dropped"`
+}
+
+// HealthReporter is implemented by sinks that know more about their state
+// than whether their writes fail, the logger fills in Name, Written, Failed
+// and the last error
+type HealthReporter interface {
+	Health() SinkHealth
+}
+
+// Health is the state of a logger and its sinks
+type Health struct {
+	// Healthy is false when a sink is not connected
+	Healthy bool `json:"healthy"`
+	// Queued is the number of entries waiting for the async writer
+	Queued  int          `json:"queued"`
+	Dropped uint64       `json:"dropped"`
+	Sinks   []SinkHealth `json:"sinks"`
+}
+
+// sinkStats counts the writes to one sink
+type sinkStats struct {
+	written atomic.Uint64
+	failed  atomic.Uint64
+
+	mu        sync.Mutex
+	ok        bool
+	lastError string
+	lastTime  time.Time
+}
+
+func newSinkStats(n int) []sinkStats {
+	stats := make([]sinkStats, n)
+	for i := range stats {
+		stats[i].ok = true
+	}
+	return stats
+}
+
+func (s *sinkStats) record(err error) {
+	if err == nil {
+		s.written.Add(1)
+	} else {
+		s.failed.Add(1)
+	}
+	s.mu.Lock()
+	s.ok = err == nil
+	if err != nil {
+		s.lastError = err.Error()
+		s.lastTime = time.Now()
+	}
+	s.mu.Unlock()
+}
+
+// Health returns the state of the logger and its sinks
+func (r AppLogger) Health() Health {
+	h := Health{Healthy: true, Dropped: r.Dropped(), Sinks: make([]SinkHealth, 0, len(r.Sinks))}
+	if r.async != nil {
+		h.Queued = r.async.queue.len()
+	}
+	for i, s := range r.Sinks {
+		sh := SinkHealth{Connected: true}
+		if hr, ok := s.(HealthReporter); ok {
+			sh = hr.Health()
+		}
+		sh.Name = fmt.Sprintf("%T", s)
+		if i < len(r.stats) {
+			st := &r.stats[i]
+			sh.Written, sh.Failed = st.written.Load(), st.failed.Load()
+			st.mu.Lock()
+			sh.Connected = sh.Connected && st.ok
+			if st.lastError != "" {
+				t := st.lastTime
+				sh.LastError, sh.LastErrorTime = st.lastError, &t
+			}
+			st.mu.Unlock()
+		}
+		h.Healthy = h.Healthy && sh.Connected
+		h.Sinks = append(h.Sinks, sh)
+	}
+	return h
+}
+
+// HealthHandler serves Health as JSON, with status 503 when the logger is
+// not healthy, for orchestrator probes and dashboards
+func (r AppLogger) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h := r.Health()
+		w.Header().Set("Content-Type", "application/json")
+		if !h.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(h)
+	})
+}
+
+// Health reports the circuit state and the spilled entries
+func (b *CircuitBreakerSink) Health() SinkHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return SinkHealth{Connected: b.stateLocked() == CircuitClosed, QueueDepth: len(b.spilled)}
+}
+
+// Health reports the entries of the current and the queued batches, and
+// the batches dropped after retrying together with the entries dropped on
+// a full queue
+func (s *HTTPSink) Health() SinkHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SinkHealth{Connected: true, QueueDepth: s.count + int(s.queued.Load()), Dropped: s.Retry.Dropped() + s.dropped.Load()}
+}
+
+// Health reports the entries dropped after retrying
+func (s NATSSink) Health() SinkHealth {
+	return SinkHealth{Connected: true, Dropped: s.Retry.Dropped()}
+}
+
+// Health reports the entries dropped after retrying
+func (s *SQLiteSink) Health() SinkHealth {
+	return SinkHealth{Connected: true, Dropped: s.Retry.Dropped()}
+}
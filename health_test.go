@@ -0,0 +1,45 @@
+package applogger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	mem := &memorySink{}
+	flaky := &flakySink{}
+	breaker := &CircuitBreakerSink{Sink: &flakySink{failing: true}, Threshold: 1, Spill: 10}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem, flaky, breaker}}
+	logger.Initialise()
+	defer logger.Close()
+
+	logger.Log("INFO", "main", "app", "fine")
+	flaky.failing = true
+	logger.Log("INFO", "main", "app", "failing")
+
+	h := logger.Health()
+	if h.Healthy || len(h.Sinks) != 3 {
+		t.Fatalf("expected an unhealthy logger with 3 sinks, got %+v", h)
+	}
+	if s := h.Sinks[0]; !s.Connected || s.Written != 2 || s.Name != "*applogger.memorySink" {
+		t.Fatalf("unexpected memory sink health %+v", s)
+	}
+	if s := h.Sinks[1]; s.Connected || s.Written != 1 || s.Failed != 1 || s.LastError != "backend down" || s.LastErrorTime == nil {
+		t.Fatalf("unexpected flaky sink health %+v", s)
+	}
+	if s := h.Sinks[2]; s.Connected || s.QueueDepth != 2 {
+		t.Fatalf("expected the open breaker with 2 spilled entries, got %+v", s)
+	}
+
+	rec := httptest.NewRecorder()
+	logger.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz/logging", nil))
+	var served Health
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil {
+		t.Fatalf("handler did not serve json: %v", err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || served.Healthy || len(served.Sinks) != 3 {
+		t.Fatalf("unexpected handler response %d %s", rec.Code, rec.Body)
+	}
+}
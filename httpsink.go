@@ -0,0 +1,382 @@
+package applogger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of a BatchConfig
+const (
+	DefaultBatchEntries = 100
+	DefaultBatchBytes   = 1 << 20
+	DefaultBatchLatency = time.Second
+)
+
+// Defaults of an HTTPSink
+const (
+	// DefaultHTTPTimeout bounds a request of the client the sink builds
+	DefaultHTTPTimeout = 10 * time.Second
+	// DefaultHTTPQueue is the number of batches waiting to be sent
+	DefaultHTTPQueue = 64
+)
+
+// BatchConfig bounds the batches of a remote sink: a batch is sent as soon
+// as it holds MaxEntries entries or MaxBytes bytes, and at the latest
+// MaxLatency after its first entry
+type BatchConfig struct {
+	MaxEntries int
+	MaxBytes   int
+	MaxLatency time.Duration
+}
+
+func (b BatchConfig) entries() int {
+	if b.MaxEntries <= 0 {
+		return DefaultBatchEntries
+	}
+	return b.MaxEntries
+}
+
+func (b BatchConfig) bytes() int {
+	if b.MaxBytes <= 0 {
+		return DefaultBatchBytes
+	}
+	return b.MaxBytes
+}
+
+func (b BatchConfig) latency() time.Duration {
+	if b.MaxLatency <= 0 {
+		return DefaultBatchLatency
+	}
+	return b.MaxLatency
+}
+
+// HTTPSink posts entries in batches to an HTTP endpoint as ndjson, one
+// entry per line. Compression is "" or "gzip"; zstd is not available in the
+// standard library. A 4xx response other than 429 is not retried.
+//
+// Write never waits on the network: full batches are queued, up to
+// QueueSize of them (DefaultHTTPQueue when zero), and sent in order with
+// their retries by a background goroutine. A batch that finds the queue
+// full is dropped and counted in Health. The error of a batch is returned
+// by the next Write or Flush.
+//
+// Client defaults to a client built from TLS and Proxy with a timeout of
+// DefaultHTTPTimeout. Without Proxy the HTTPS_PROXY, HTTP_PROXY and
+// NO_PROXY environment variables are honored.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+	TLS    *TLSConfig
+	// Auth adds credentials to every request
+	Auth HTTPAuth
+	// Proxy is the URL of the proxy to send through, e.g.
+	// "http://proxy.corp:3128", ignoring the environment
+	Proxy string
+	// Encoder defaults to JSONEncoder
+	Encoder     Encoder
+	Batch       BatchConfig
+	Compression string
+	Retry       *RetryPolicy
+	QueueSize   int
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	count int
+	timer *time.Timer
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	queue     chan httpBatch
+	quit      chan struct{}
+	done      chan struct{}
+	// queued counts the entries of the queued batches, dropped those of
+	// the batches that found the queue full
+	queued  atomic.Int64
+	dropped atomic.Uint64
+	// err is the first error of a batch not returned yet
+	errMu sync.Mutex
+	err   error
+
+	clientOnce sync.Once
+	client     *http.Client
+	clientErr  error
+}
+
+// NewHTTPSink returns a sink posting to url with the default batching
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{URL: url}
+}
+
+// httpBatch is a batch waiting to be sent, or with done a Flush waiting
+// for the batches before it
+type httpBatch struct {
+	body    []byte
+	entries int
+	done    chan error
+}
+
+// Write adds the entry to the current batch, queueing it when full
+func (s *HTTPSink) Write(e Entry) error {
+	enc := s.Encoder
+	if enc == nil {
+		enc = JSONEncoder{}
+	}
+	line, err := enc.Encode(e)
+	if err != nil {
+		return err
+	}
+
+	var queueErr error
+	s.mu.Lock()
+	if s.count > 0 && s.buf.Len()+len(line)+1 > s.Batch.bytes() {
+		// the entry does not fit, queue what is there first
+		queueErr = s.enqueue(s.takeLocked())
+	}
+	s.buf.Write(line)
+	s.buf.WriteByte('\n')
+	s.count++
+	if s.count >= s.Batch.entries() || s.buf.Len() >= s.Batch.bytes() {
+		if err := s.enqueue(s.takeLocked()); queueErr == nil {
+			queueErr = err
+		}
+	} else if s.timer == nil {
+		s.timer = time.AfterFunc(s.Batch.latency(), s.flushLate)
+	}
+	s.mu.Unlock()
+
+	if err := s.takeErr(); err != nil {
+		return err
+	}
+	return queueErr
+}
+
+// takeLocked returns the current batch and starts a new one
+func (s *HTTPSink) takeLocked() httpBatch {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.count == 0 {
+		return httpBatch{}
+	}
+	b := httpBatch{body: append([]byte(nil), s.buf.Bytes()...), entries: s.count}
+	s.buf.Reset()
+	s.count = 0
+	return b
+}
+
+// start starts the goroutine sending the queued batches
+func (s *HTTPSink) start() {
+	s.startOnce.Do(func() {
+		size := s.QueueSize
+		if size <= 0 {
+			size = DefaultHTTPQueue
+		}
+		s.queue = make(chan httpBatch, size)
+		s.quit = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.run()
+	})
+}
+
+// enqueue queues a batch without blocking, dropping it when the queue is
+// full
+func (s *HTTPSink) enqueue(b httpBatch) error {
+	if b.body == nil {
+		return nil
+	}
+	s.start()
+	select {
+	case <-s.quit:
+		return os.ErrClosed
+	default:
+	}
+	select {
+	case s.queue <- b:
+		s.queued.Add(int64(b.entries))
+		return nil
+	default:
+		s.dropped.Add(uint64(b.entries))
+		return fmt.Errorf("applogger: %s: send queue full, dropped %d entries", s.URL, b.entries)
+	}
+}
+
+// run sends the queued batches in order until Close
+func (s *HTTPSink) run() {
+	defer close(s.done)
+	for {
+		select {
+		case b := <-s.queue:
+			if b.body != nil {
+				err := s.send(b.body)
+				s.queued.Add(-int64(b.entries))
+				if err != nil {
+					s.errMu.Lock()
+					if s.err == nil {
+						s.err = err
+					}
+					s.errMu.Unlock()
+				}
+			}
+			if b.done != nil {
+				b.done <- s.takeErr()
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// takeErr returns the first error of a batch since the last call
+func (s *HTTPSink) takeErr() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// flushLate queues a batch that reached MaxLatency
+func (s *HTTPSink) flushLate() {
+	s.mu.Lock()
+	err := s.enqueue(s.takeLocked())
+	s.mu.Unlock()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing to sink:", err)
+	}
+}
+
+// Flush sends the current batch and waits for the queued ones, returning
+// the first error of a batch not returned by Write yet
+func (s *HTTPSink) Flush() error {
+	s.start()
+	s.mu.Lock()
+	b := s.takeLocked()
+	s.mu.Unlock()
+	b.done = make(chan error, 1)
+	select {
+	case <-s.quit:
+		return os.ErrClosed
+	default:
+	}
+	select {
+	case s.queue <- b:
+		s.queued.Add(int64(b.entries))
+	case <-s.quit:
+		return os.ErrClosed
+	}
+	return <-b.done
+}
+
+func (s *HTTPSink) send(batch []byte) error {
+	body := batch
+	switch s.Compression {
+	case "":
+	case "gzip":
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(batch)
+		gz.Close()
+		body = buf.Bytes()
+	default:
+		return fmt.Errorf("applogger: unsupported compression %q", s.Compression)
+	}
+	return s.Retry.Do(func() error { return s.post(body) })
+}
+
+func (s *HTTPSink) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.Compression != "" {
+		req.Header.Set("Content-Encoding", s.Compression)
+	}
+	if s.Auth != nil {
+		if err := s.Auth.Authorize(req); err != nil {
+			return err
+		}
+	}
+
+	client, err := s.httpClient()
+	if err != nil {
+		return Permanent(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("applogger: %s returned %s", s.URL, resp.Status)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
+
+// httpClient returns Client or builds the client of the sink once
+func (s *HTTPSink) httpClient() (*http.Client, error) {
+	if s.Client != nil {
+		return s.Client, nil
+	}
+	s.clientOnce.Do(func() {
+		if s.TLS == nil && s.Proxy == "" {
+			s.client = &http.Client{Timeout: DefaultHTTPTimeout}
+			return
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if s.TLS != nil {
+			if transport.TLSClientConfig, s.clientErr = s.TLS.Build(); s.clientErr != nil {
+				return
+			}
+		}
+		if s.Proxy != "" {
+			proxy, err := parseProxy(s.Proxy)
+			if err != nil {
+				s.clientErr = err
+				return
+			}
+			transport.Proxy = http.ProxyURL(proxy)
+		}
+		s.client = &http.Client{Transport: transport, Timeout: DefaultHTTPTimeout}
+	})
+	return s.client, s.clientErr
+}
+
+// parseProxy checks a proxy URL
+func parseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("applogger: proxy: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	}
+	return nil, fmt.Errorf("applogger: proxy %q needs an http, https or socks5 scheme", raw)
+}
+
+// Close sends the last batch, waits for the queued ones and stops the
+// background goroutine
+func (s *HTTPSink) Close() error {
+	err := s.Flush()
+	s.closeOnce.Do(func() {
+		close(s.quit)
+		<-s.done
+	})
+	return err
+}
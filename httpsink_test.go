@@ -0,0 +1,150 @@
+package applogger
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchServer records the ndjson batches posted to it
+type batchServer struct {
+	mu      sync.Mutex
+	batches []string
+	status  int
+}
+
+func (b *batchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+	data, _ := io.ReadAll(body)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batches = append(b.batches, string(data))
+	if b.status != 0 {
+		w.WriteHeader(b.status)
+	}
+}
+
+func (b *batchServer) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.batches)
+}
+
+func TestHTTPSinkBatching(t *testing.T) {
+	backend := &batchServer{}
+	srv := httptest.NewServer(backend)
+	defer srv.Close()
+
+	s, err := NewSink("http", map[string]interface{}{"url": srv.URL, "max_entries": float64(2), "max_latency": "50ms", "compression": "gzip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []string{"1", "2", "3"} {
+		if err := s.Write(Entry{Level: "INFO", Message: m}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for backend.len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if strings.Count(backend.batches[0], "\n") != 2 {
+		t.Fatalf("expected a full batch of 2 entries, got %q", backend.batches)
+	}
+	if backend.len() != 2 || !strings.Contains(backend.batches[1], `"message":"3"`) {
+		t.Fatalf("expected the last entry after max_latency, got %q", backend.batches)
+	}
+	s.Close()
+}
+
+func TestHTTPSinkMaxBytesAndErrors(t *testing.T) {
+	backend := &batchServer{status: http.StatusBadRequest}
+	srv := httptest.NewServer(backend)
+	defer srv.Close()
+
+	retry := &RetryPolicy{}
+	s := &HTTPSink{URL: srv.URL, Batch: BatchConfig{MaxBytes: 250}, Retry: retry}
+	s.Write(Entry{Level: "INFO", Message: strings.Repeat("a", 60)})
+	s.Write(Entry{Level: "INFO", Message: strings.Repeat("b", 60)})
+	if err := s.Flush(); err == nil {
+		t.Fatal("expected the rejected batch to be reported")
+	}
+	if backend.len() != 2 || retry.Dropped() != 2 {
+		t.Fatalf("a 400 should not be retried, got %d requests", backend.len())
+	}
+	s.Write(Entry{Level: "INFO", Message: "last"})
+	s.Close()
+	if backend.len() != 3 {
+		t.Fatalf("Close should send the last batch, got %d requests", backend.len())
+	}
+
+	if _, err := NewSink("http", map[string]interface{}{"url": srv.URL, "compression": "zstd"}); err == nil {
+		t.Fatal("expected an error for zstd")
+	}
+}
+
+func TestHTTPSinkHungBackend(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := &HTTPSink{URL: srv.URL, Batch: BatchConfig{MaxEntries: 1}, QueueSize: 2}
+	start := time.Now()
+	var queueErr error
+	for i := 0; i < 10; i++ {
+		if err := s.Write(Entry{Level: "INFO"}); err != nil && queueErr == nil {
+			queueErr = err
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Write blocked on a hung backend for %s", elapsed)
+	}
+	if queueErr == nil || s.Health().Dropped == 0 {
+		t.Fatalf("expected batches dropped on the full queue, got %v %+v", queueErr, s.Health())
+	}
+	if c, _ := s.httpClient(); c.Timeout != DefaultHTTPTimeout {
+		t.Fatalf("expected the default client to time out after %s, got %s", DefaultHTTPTimeout, c.Timeout)
+	}
+}
+
+func TestHTTPSinkProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a forward proxy receives the absolute URL of the target
+		proxied = append(proxied, r.URL.String())
+	}))
+	defer proxy.Close()
+
+	s, err := NewSink("http", map[string]interface{}{"url": "http://logs.internal.example/ingest", "max_entries": float64(1), "proxy": proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write(Entry{Level: "INFO"})
+	if err := s.Close(); err != nil {
+		t.Fatalf("write through the proxy failed: %v", err)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://logs.internal.example/ingest" {
+		t.Fatalf("expected the request to go through the proxy, got %v", proxied)
+	}
+
+	if _, err := NewSink("http", map[string]interface{}{"url": "http://logs", "proxy": "proxy.corp:3128"}); err == nil {
+		t.Fatal("expected an error for a proxy without scheme")
+	}
+}
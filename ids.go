@@ -0,0 +1,77 @@
+package applogger
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// IDGenerator returns a new unique ID. AppLogger.EntryIDs makes the pid of
+// entries, HTTPMiddleware.RequestIDs and CorrelationIDs the IDs of the
+// requests it serves, so they can follow an organization's ID standard or
+// be predictable in tests.
+type IDGenerator func() string
+
+// UUIDv4 returns a random UUID, the default ID of entries and requests
+func UUIDv4() string {
+	return uuid.Must(uuid.NewV4()).String()
+}
+
+// ULID returns a new ULID, the default correlation ID, see
+// NewCorrelationID
+func ULID() string {
+	return NewCorrelationID()
+}
+
+// SnowflakeEpoch is the epoch of NewSnowflake IDs, 2020-01-01 UTC
+var SnowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NewSnowflake returns a generator of Snowflake IDs: 41 bits of
+// milliseconds since SnowflakeEpoch, 10 bits of node and a 12 bit sequence
+// within the millisecond, as a decimal string. Every process generating IDs
+// needs its own node, from 0 to 1023.
+func NewSnowflake(node int64) IDGenerator {
+	var mu sync.Mutex
+	var last, seq int64
+	node &= 1023
+	return func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		ms := time.Since(SnowflakeEpoch).Milliseconds()
+		if ms < last {
+			// the clock went back, keep counting from the last millisecond
+			ms = last
+		}
+		if ms == last {
+			seq = (seq + 1) & 4095
+			if seq == 0 {
+				// sequence exhausted, borrow the next millisecond
+				ms++
+			}
+		} else {
+			seq = 0
+		}
+		last = ms
+		return strconv.FormatInt(ms<<22|node<<12|seq, 10)
+	}
+}
+
+// NewSequence returns a generator of prefix1, prefix2, ..., for tests
+// comparing entries with their expected IDs
+func NewSequence(prefix string) IDGenerator {
+	var n atomic.Uint64
+	return func() string {
+		return prefix + strconv.FormatUint(n.Add(1), 10)
+	}
+}
+
+// newID returns the ID of a new entry
+func (r AppLogger) newID() string {
+	if r.EntryIDs != nil {
+		return r.EntryIDs()
+	}
+	return UUIDv4()
+}
@@ -0,0 +1,55 @@
+package applogger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestEntryIDs(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, EntryIDs: NewSequence("e-")}
+	logger.Initialise()
+	defer logger.Close()
+
+	logger.Log("INFO", "main", "app", "first")
+	logger.WithFields(map[string]interface{}{"k": 1}).Log("INFO", "main", "app", "second")
+	if mem.entries[0].PID != "e-1" || mem.entries[1].PID != "e-2" {
+		t.Fatalf("unexpected IDs %s %s", mem.entries[0].PID, mem.entries[1].PID)
+	}
+}
+
+func TestMiddlewareIDs(t *testing.T) {
+	logger := AppLogger{Path: "/dev/null"}
+	logger.Initialise()
+	defer logger.Close()
+
+	m := NewHTTPMiddleware(logger)
+	m.RequestIDs = NewSequence("req-")
+	m.CorrelationIDs = NewSequence("corr-")
+	h := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Header().Get(RequestIDHeader) != "req-1" || rec.Header().Get(CorrelationIDHeader) != "corr-1" {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	}
+}
+
+func TestSnowflake(t *testing.T) {
+	next := NewSnowflake(7)
+	var last int64
+	for i := 0; i < 10000; i++ {
+		id, err := strconv.ParseInt(next(), 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("ID %d not after %d", id, last)
+		}
+		if node := id >> 12 & 1023; node != 7 {
+			t.Fatalf("node %d, want 7", node)
+		}
+		last = id
+	}
+}
@@ -0,0 +1,73 @@
+package applogger
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+)
+
+// IndexSuffix is appended to the log path to name its index sidecar
+const IndexSuffix = ".idx"
+
+// timeIndex writes a sidecar file with one "unix_nanos offset" line every
+// n entries. The time is when the entry was written, which is never before
+// the time of the entry itself, so seeking to the last index point before t
+// cannot skip an entry logged at or after t.
+type timeIndex struct {
+	file   *os.File
+	every  int
+	count  int
+	offset int64
+}
+
+func openTimeIndex(path string, every int, offset int64) (*timeIndex, error) {
+	f, err := os.OpenFile(path+IndexSuffix, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &timeIndex{file: f, every: every, offset: offset}, nil
+}
+
+// add records an entry of n bytes about to be written at the current offset
+func (ix *timeIndex) add(n int, now time.Time) {
+	if ix.count%ix.every == 0 {
+		fmt.Fprintf(ix.file, "%d %d\n", now.UnixNano(), ix.offset)
+	}
+	ix.count++
+	ix.offset += int64(n)
+}
+
+func (ix *timeIndex) Close() error {
+	return ix.file.Close()
+}
+
+// IndexOffset returns the byte offset in the log at path from which every
+// entry logged at or after t can be read, using the index sidecar written
+// with IndexEvery. It returns 0 when there is no index.
+func IndexOffset(path string, t time.Time) (int64, error) {
+	f, err := os.Open(path + IndexSuffix)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	target := t.UnixNano()
+	var best int64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var nanos, offset int64
+		if _, err := fmt.Sscanf(scanner.Text(), "%d %d", &nanos, &offset); err != nil {
+			// a torn last line after a crash, keep what we have
+			break
+		}
+		if nanos >= target {
+			break
+		}
+		best = offset
+	}
+	return best, scanner.Err()
+}
@@ -0,0 +1,65 @@
+package applogger
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIndexOffset(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/indexed.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, IndexEvery: 10}
+	logger.Initialise()
+	for i := 0; i < 50; i++ {
+		logger.Log("INFO", "main", "app", "before")
+	}
+	time.Sleep(10 * time.Millisecond)
+	middle := time.Now()
+	for i := 0; i < 50; i++ {
+		logger.Log("INFO", "main", "app", "after")
+	}
+	logger.Close()
+
+	offset, err := IndexOffset(filePath, middle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset == 0 {
+		t.Fatal("expected the index to skip the first entries")
+	}
+
+	f, _ := os.Open(filePath)
+	defer f.Close()
+	f.Seek(offset, io.SeekStart)
+	r := NewReader(f)
+	after := 0
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("seek did not land on an entry boundary: %s", err)
+		}
+		if e.Message == "after" {
+			after++
+		}
+	}
+	if after != 50 {
+		t.Fatalf("expected all 50 later entries after the offset, got %d", after)
+	}
+
+	if offset, _ := IndexOffset(directoryPath+"/missing.ndjson", middle); offset != 0 {
+		t.Fatalf("expected 0 without an index, got %d", offset)
+	}
+	b, _ := os.ReadFile(filePath + IndexSuffix)
+	if lines := strings.Count(string(b), "\n"); lines != 10 {
+		t.Fatalf("expected 10 index points, got %d", lines)
+	}
+}
@@ -0,0 +1,48 @@
+package applogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// maxInternedLen is the longest string worth interning, longer ones
+	// are rarely repeated
+	maxInternedLen = 64
+	// maxInterned bounds the table, once full new strings are encoded
+	// every time
+	maxInterned = 4096
+)
+
+// interned maps strings that repeat across entries, like keys, level and
+// package names, to their JSON encoding so it is computed once per process
+// instead of once per entry
+var (
+	interned      sync.Map
+	internedCount atomic.Int64
+)
+
+// quoteInterned returns the JSON string for s, the result must not be
+// modified
+func quoteInterned(s string) []byte {
+	if b, ok := interned.Load(s); ok {
+		return b.([]byte)
+	}
+	b, _ := json.Marshal(s)
+	if len(s) <= maxInternedLen && internedCount.Load() < maxInterned {
+		// clone so the table does not keep a larger backing string alive
+		if _, loaded := interned.LoadOrStore(strings.Clone(s), b); !loaded {
+			internedCount.Add(1)
+		}
+	}
+	return b
+}
+
+// writeJSONInterned appends "key":"value" with an interned value, for
+// fields with few distinct values
+func writeJSONInterned(buf *bytes.Buffer, key string, value string) {
+	writeJSONRaw(buf, key, quoteInterned(value))
+}
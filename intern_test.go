@@ -0,0 +1,45 @@
+package applogger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestQuoteInterned(t *testing.T) {
+	for _, s := range []string{"INFO", "pkg<html>", "quote\"d", strings.Repeat("x", 200)} {
+		first := string(quoteInterned(s))
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		writeJSONField(&buf, "k", s)
+		if want := buf.String()[len(`{"k":`):]; first != want || string(quoteInterned(s)) != want {
+			t.Fatalf("expected %s, got %s", want, first)
+		}
+	}
+
+	if _, ok := interned.Load("INFO"); !ok {
+		t.Fatal("short strings should be interned")
+	}
+	if _, ok := interned.Load(strings.Repeat("x", 200)); ok {
+		t.Fatal("long strings should not be interned")
+	}
+}
+
+func TestInternedEncodeAllocations(t *testing.T) {
+	e := Entry{PID: "id", Level: "INFO", Package: "main", Func: "handler", Message: "hello"}
+	JSONEncoder{}.Encode(e)
+	with := testing.AllocsPerRun(100, func() { JSONEncoder{}.Encode(e) })
+
+	interned.Range(func(k, v interface{}) bool {
+		interned.Delete(k)
+		internedCount.Add(-1)
+		return true
+	})
+	internedCount.Store(maxInterned)
+	defer internedCount.Store(0)
+	without := testing.AllocsPerRun(100, func() { JSONEncoder{}.Encode(e) })
+
+	if with >= without {
+		t.Fatalf("interning should save allocations, %v with and %v without", with, without)
+	}
+}
@@ -0,0 +1,74 @@
+package applogger
+
+import (
+	"context"
+)
+
+// BadKey is the key of a value in the key/value arguments of Info and the
+// others that has no string key before it, as in log/slog
+const BadKey = "!BADKEY"
+
+// Debug writes a DEBUG entry with attributes given as alternating keys and
+// values, like log/slog, taking attributes from the context like
+// LogContext. A Field can stand in for a key and its value. Package and
+// Func are those of the caller.
+//
+//	logger.Info(ctx, "order paid", "order_id", id, "amount", 1250)
+func (r AppLogger) Debug(ctx context.Context, message string, args ...interface{}) {
+	r.logKV(ctx, LevelDebug, message, args)
+}
+
+// Info writes an INFO entry like Debug
+func (r AppLogger) Info(ctx context.Context, message string, args ...interface{}) {
+	r.logKV(ctx, LevelInfo, message, args)
+}
+
+// Warn writes a WARN entry like Debug
+func (r AppLogger) Warn(ctx context.Context, message string, args ...interface{}) {
+	r.logKV(ctx, LevelWarn, message, args)
+}
+
+// Error writes an ERROR entry like Debug
+func (r AppLogger) Error(ctx context.Context, message string, args ...interface{}) {
+	r.logKV(ctx, LevelError, message, args)
+}
+
+// logKV writes the entry of the methods above, the arguments are only
+// turned into attributes when the level is enabled
+func (r AppLogger) logKV(ctx context.Context, level LogLevel, message string, args []interface{}) {
+	logPackage, logFunc := getCallerInfo(2)
+	if r.disabled(level, logPackage) {
+		return
+	}
+
+	s1 := r.now()
+
+	e := Entry{PID: r.newID(), Level: level.String(), Package: logPackage, Func: logFunc, Message: message, Time: s1}
+	r.logInternal(e, r.contextAttrs(ctx, s1, keyValueAttrs(args)))
+}
+
+// keyValueAttrs pairs up alternating keys and values, a value without a
+// string key before it is kept under BadKey
+func keyValueAttrs(args []interface{}) []attr {
+	attrs := make([]attr, 0, len(args)/2+1)
+	for len(args) > 0 {
+		switch key := args[0].(type) {
+		case Field:
+			if key.key != "" {
+				attrs = append(attrs, attr{key.key, key.value})
+			}
+			args = args[1:]
+		case string:
+			if len(args) == 1 {
+				attrs = append(attrs, attr{BadKey, key})
+				return attrs
+			}
+			attrs = append(attrs, attr{key, args[1]})
+			args = args[2:]
+		default:
+			attrs = append(attrs, attr{BadKey, key})
+			args = args[1:]
+		}
+	}
+	return attrs
+}
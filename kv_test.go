@@ -0,0 +1,46 @@
+package applogger
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestKeyValueMethods(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, Levels: map[string]LogLevel{"": LevelInfo}}
+	logger.Initialise()
+	defer logger.Close()
+
+	ctx := context.Background()
+	logger.Debug(ctx, "filtered", "k", 1)
+	logger.Info(ctx, "order paid", "order_id", "o-1", "amount", 1250)
+	logger.Warn(ctx, "retrying", Err(errors.New("timeout")), "attempt", 2)
+	logger.Error(ctx, "odd", "dangling")
+
+	if len(mem.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(mem.entries))
+	}
+	want := []map[string]interface{}{
+		{"order_id": "o-1", "amount": 1250},
+		{ErrorKey: "timeout", "attempt": 2},
+		{BadKey: "dangling"},
+	}
+	for i, e := range mem.entries {
+		if e.Func != "TestKeyValueMethods" || !reflect.DeepEqual(e.Attributes, want[i]) {
+			t.Fatalf("entry %d: unexpected %+v", i, e)
+		}
+	}
+	if mem.entries[0].Level != "INFO" || mem.entries[1].Level != "WARN" || mem.entries[2].Level != "ERROR" {
+		t.Fatalf("unexpected levels %+v", mem.entries)
+	}
+}
+
+func TestKeyValueAttrs(t *testing.T) {
+	got := keyValueAttrs([]interface{}{"a", 1, 2, "b", true, Int("c", 3), "d"})
+	want := []attr{{"a", 1}, {BadKey, 2}, {"b", true}, {"c", 3}, {BadKey, "d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
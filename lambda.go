@@ -0,0 +1,55 @@
+package applogger
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// lambdaInvoked is set by the first invocation of the process, later ones
+// are warm starts
+var lambdaInvoked atomic.Bool
+
+// LambdaInvocation returns a logger for one AWS Lambda invocation, with
+// the request ID (lambdacontext.FromContext(ctx).AwsRequestID), the
+// function name, version and memory limit, whether this is the cold start
+// of the instance and the time left at the start as default fields. Entries
+// logged with ctx also get the time left, see ContextDeadline. Call done
+// before returning from the handler, it flushes buffered and queued entries
+// before Lambda freezes the instance:
+//
+//	logger, done := base.LambdaInvocation(ctx, lc.AwsRequestID)
+//	defer done()
+func (r AppLogger) LambdaInvocation(ctx context.Context, requestID string) (AppLogger, func()) {
+	fields := map[string]interface{}{
+		"aws_request_id": requestID,
+		"cold_start":     !lambdaInvoked.Swap(true),
+	}
+	if name := os.Getenv("AWS_LAMBDA_FUNCTION_NAME"); name != "" {
+		fields["function_name"] = name
+	}
+	if version := os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"); version != "" {
+		fields["function_version"] = version
+	}
+	if mb, err := strconv.Atoi(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE")); err == nil {
+		fields["memory_limit_mb"] = mb
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		fields["remaining_time_ms"] = time.Until(deadline).Milliseconds()
+	}
+
+	logger := r.WithFields(fields)
+	logger.ContextDeadline = true
+	return logger, func() { r.flushAll() }
+}
+
+// flushAll writes out the entries queued for the async writer and the
+// buffered ones
+func (r AppLogger) flushAll() error {
+	if r.async != nil {
+		r.async.drain()
+	}
+	return r.Flush()
+}
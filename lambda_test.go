@@ -0,0 +1,46 @@
+package applogger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLambdaInvocation(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "resize")
+	t.Setenv("AWS_LAMBDA_FUNCTION_VERSION", "7")
+	t.Setenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE", "512")
+	path := filepath.Join(t.TempDir(), "lambda.ndjson")
+	base := AppLogger{Path: path, Async: true, BufferSize: 64 << 10, FlushInterval: -1}
+	base.Initialise()
+	defer base.Close()
+
+	for i, id := range []string{"req-1", "req-2"} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		logger, done := base.LambdaInvocation(ctx, id)
+		logger.LogContext(ctx, "INFO", "main", "handler", "resized")
+		done()
+		cancel()
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		if len(lines) != i+1 {
+			t.Fatalf("done did not flush the entry, got %q", b)
+		}
+		line := lines[i]
+		for _, want := range []string{`"aws_request_id":"` + id + `"`, `"function_name":"resize"`, `"function_version":"7"`, `"memory_limit_mb":512`, `"remaining_time_ms":`, `"ctx_deadline_ms":`} {
+			if !strings.Contains(line, want) {
+				t.Fatalf("missing %s in %s", want, line)
+			}
+		}
+		if cold := strings.Contains(line, `"cold_start":true`); cold != (i == 0) {
+			t.Fatalf("unexpected cold_start in %s", line)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package applogger
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// LogLevel orders the level strings given to Log, the zero value is
+// LevelInfo
+type LogLevel int
+
+const (
+	LevelTrace LogLevel = iota - 2
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+// String returns the level name as written in entries
+func (l LogLevel) String() string {
+	switch {
+	case l <= LevelTrace:
+		return "TRACE"
+	case l == LevelDebug:
+		return "DEBUG"
+	case l == LevelInfo:
+		return "INFO"
+	case l == LevelWarn:
+		return "WARN"
+	case l == LevelError:
+		return "ERROR"
+	default:
+		return "FATAL"
+	}
+}
+
+// ParseLevel converts a level name, in any case, to a LogLevel
+func ParseLevel(name string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "TRACE":
+		return LevelTrace, nil
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO", "":
+		return LevelInfo, nil
+	case "WARN", "WARNING":
+		return LevelWarn, nil
+	case "ERROR":
+		return LevelError, nil
+	case "FATAL", "PANIC", "CRITICAL":
+		return LevelFatal, nil
+	}
+	return LevelInfo, fmt.Errorf("applogger: unknown level %q", name)
+}
+
+// levelOf returns the LogLevel of a level string, unknown levels count as
+// LevelInfo
+func levelOf(level string) LogLevel {
+	l, _ := ParseLevel(level)
+	return l
+}
+
+// LevelsEnv is the environment variable read for Levels when a logger has
+// none, e.g. APPLOGGER_LEVELS="info,github.com/acme/svc/payments=debug"
+const LevelsEnv = "APPLOGGER_LEVELS"
+
+// ParseLevels parses a comma separated list of package=level pairs, a
+// level without a package sets the default for every other package
+func ParseLevels(s string) (map[string]LogLevel, error) {
+	levels := make(map[string]LogLevel)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		pkg, name, found := strings.Cut(part, "=")
+		if !found {
+			pkg, name = "", part
+		}
+		l, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		levels[strings.TrimSpace(pkg)] = l
+	}
+	return levels, nil
+}
+
+// levelFor returns the minimum level of pkg: the level of the longest
+// configured package that is pkg or one of its parents, so
+// github.com/acme/svc covers github.com/acme/svc/payments, or the default
+func levelFor(levels map[string]LogLevel, pkg string) LogLevel {
+	return levelOrDefault(levels, pkg, levels[""])
+}
+
+// levelOrDefault is levelFor with def in place of the "" level
+func levelOrDefault(levels map[string]LogLevel, pkg string, def LogLevel) LogLevel {
+	best, bestLen := def, -1
+	for k, l := range levels {
+		if k != "" && len(k) > bestLen && (pkg == k || strings.HasPrefix(pkg, k+"/")) {
+			best, bestLen = l, len(k)
+		}
+	}
+	return best
+}
+
+// levelSwitch holds the level set by SetLevel, shared by a logger and the
+// loggers derived from it
+type levelSwitch struct {
+	set   atomic.Bool
+	level atomic.Int64
+}
+
+// SetLevel changes the minimum level of every package without one of its
+// own in Levels, while the logger runs, e.g. to turn on Debug entries of a
+// service for a while. Entries below it are dropped before anything is
+// encoded. The logger and every logger derived from it see the change.
+func (r *AppLogger) SetLevel(l LogLevel) {
+	if r.level == nil {
+		r.level = &levelSwitch{}
+	}
+	r.level.level.Store(int64(l))
+	r.level.set.Store(true)
+}
+
+// Level returns the minimum level set by SetLevel, or else the default of
+// Levels, LevelTrace when there are no Levels
+func (r AppLogger) Level() LogLevel {
+	if r.level != nil && r.level.set.Load() {
+		return LogLevel(r.level.level.Load())
+	}
+	if r.Levels == nil {
+		return LevelTrace
+	}
+	return r.Levels[""]
+}
+
+// enabled reports whether entries of the level and package are written
+// disabled reports whether an entry can be skipped before it is built: the
+// logger is muted, or level is off for pkg and no Escalation could raise it
+func (r AppLogger) disabled(level LogLevel, pkg string) bool {
+	return r.muted || r.Escalations == nil && !r.enabled(level, pkg)
+}
+
+func (r AppLogger) enabled(level LogLevel, pkg string) bool {
+	if r.level != nil && r.level.set.Load() {
+		return level >= levelOrDefault(r.Levels, pkg, LogLevel(r.level.level.Load()))
+	}
+	return r.Levels == nil || level >= levelFor(r.Levels, pkg)
+}
@@ -0,0 +1,151 @@
+package applogger
+
+import (
+	"context"
+	"runtime"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]LogLevel{"trace": LevelTrace, "DEBUG": LevelDebug, "": LevelInfo, "Warning": LevelWarn, "ERROR": LevelError, "panic": LevelFatal}
+	for name, want := range cases {
+		got, err := ParseLevel(name)
+		if err != nil || got != want {
+			t.Fatalf("ParseLevel(%q) = %s, %v want %s", name, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+	if LevelWarn.String() != "WARN" {
+		t.Fatalf("unexpected level name %s", LevelWarn)
+	}
+}
+
+func TestLoadShedding(t *testing.T) {
+	mem := &memorySink{}
+	block := make(chan struct{})
+	logger := AppLogger{Path: "/dev/null", Async: true, HighWaterMark: 2, Sinks: []Sink{blockingSink{mem, block}}}
+	logger.Initialise()
+
+	// the writer goroutine is stuck on the first entry, the rest queue up
+	logger.Log("INFO", "main", "app", "first")
+	for logger.async.queue.len() != 0 {
+		runtime.Gosched()
+	}
+	logger.Log("INFO", "main", "app", "queued 1")
+	logger.Log("INFO", "main", "app", "queued 2")
+	logger.Log("DEBUG", "main", "app", "shed")
+	logger.Log("TRACE", "main", "app", "shed")
+	logger.Log("WARN", "main", "app", "kept")
+	close(block)
+	logger.Close()
+
+	if logger.Dropped() != 2 {
+		t.Fatalf("expected 2 dropped entries, got %d", logger.Dropped())
+	}
+	if len(mem.entries) != 4 {
+		t.Fatalf("expected 4 written entries, got %d", len(mem.entries))
+	}
+}
+
+// blockingSink waits for unblock before the first write goes through
+type blockingSink struct {
+	*memorySink
+	unblock chan struct{}
+}
+
+func (b blockingSink) Write(e Entry) error {
+	<-b.unblock
+	return b.memorySink.Write(e)
+}
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels("info, github.com/acme/svc=warn,github.com/acme/svc/payments=debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]LogLevel{
+		"main":                          LevelInfo,
+		"github.com/acme/svc":           LevelWarn,
+		"github.com/acme/svc/poller":    LevelWarn,
+		"github.com/acme/svc/payments":  LevelDebug,
+		"github.com/acme/svc/paymentsx": LevelWarn,
+		"github.com/acme/service":       LevelInfo,
+	}
+	for pkg, want := range cases {
+		if got := levelFor(levels, pkg); got != want {
+			t.Fatalf("level of %s = %s, want %s", pkg, got, want)
+		}
+	}
+	if _, err := ParseLevels("info,main=loud"); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+}
+
+func TestLevelsFromEnv(t *testing.T) {
+	t.Setenv(LevelsEnv, "warn,payments=debug")
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}}
+	logger.Initialise()
+	defer logger.Close()
+
+	logger.Log("INFO", "main", "app", "filtered")
+	logger.Log("WARN", "main", "app", "kept")
+	logger.Log("DEBUG", "payments", "charge", "kept")
+	logger.Log("TRACE", "payments", "charge", "filtered")
+
+	if len(mem.entries) != 2 || mem.entries[0].Message != "kept" || mem.entries[1].Message != "kept" {
+		t.Fatalf("unexpected entries %+v", mem.entries)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, Levels: map[string]LogLevel{"": LevelInfo, "payments": LevelError}}
+	logger.Initialise()
+	defer logger.Close()
+	derived := logger.WithFields(map[string]interface{}{"k": "v"})
+
+	derived.Log("DEBUG", "main", "app", "filtered")
+	logger.SetLevel(LevelDebug)
+	derived.Log("DEBUG", "main", "app", "kept")
+	derived.Log("WARN", "payments", "charge", "filtered")
+	logger.SetLevel(LevelWarn)
+	derived.Log("INFO", "main", "app", "filtered")
+
+	if len(mem.entries) != 1 || mem.entries[0].Message != "kept" {
+		t.Fatalf("unexpected entries %+v", mem.entries)
+	}
+	if derived.Level() != LevelWarn {
+		t.Fatalf("Level() = %s", derived.Level())
+	}
+}
+
+func TestDisabledLevelAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	logger := AppLogger{Path: "/dev/null", Levels: map[string]LogLevel{"": LevelWarn}, EntryIDs: func() string {
+		t.Fatal("an ID was generated for a disabled entry")
+		return ""
+	}}
+	logger.Initialise()
+	defer logger.Close()
+
+	ctx := context.Background()
+	fields := map[string]interface{}{"user_id": 42}
+	calls := map[string]func(){
+		"Log":              func() { logger.Log("DEBUG", "main", "app", "hidden") },
+		"LogHTTP":          func() { logger.LogHTTP("DEBUG", "main", "app", "hidden", 200, 0.1) },
+		"LogFields":        func() { logger.LogFields("DEBUG", "main", "app", "hidden", fields) },
+		"LogFieldsContext": func() { logger.LogFieldsContext(ctx, "DEBUG", "main", "app", "hidden", fields) },
+		"LogAttrs":         func() { logger.LogAttrs(ctx, LevelDebug, "hidden") },
+		"Debug":            func() { logger.Debug(ctx, "hidden") },
+	}
+	for name, call := range calls {
+		if n := testing.AllocsPerRun(100, call); n != 0 {
+			t.Errorf("%s allocated %v times for a disabled level", name, n)
+		}
+	}
+}
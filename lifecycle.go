@@ -0,0 +1,77 @@
+package applogger
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// lifecycle counts what a logger wrote between its startup and shutdown
+// entries
+type lifecycle struct {
+	started time.Time
+	entries atomic.Uint64
+	errors  atomic.Uint64
+	stopped atomic.Bool
+}
+
+// count records an entry handed to the logger
+func (l *lifecycle) count(e Entry) {
+	l.entries.Add(1)
+	if levelOf(e.Level) >= LevelError {
+		l.errors.Add(1)
+	}
+}
+
+// logStartup writes the startup entry with a summary of the configuration,
+// the build and the host
+func (r AppLogger) logStartup() {
+	path := r.Path
+	if path == "" {
+		path = "stdout"
+	}
+	fields := map[string]interface{}{
+		"path":        path,
+		"format":      r.format.String(),
+		"async":       r.Async,
+		"buffer_size": r.BufferSize,
+		"sinks":       len(r.Sinks),
+		"go_version":  runtime.Version(),
+		"os_pid":      os.Getpid(),
+		"goos":        runtime.GOOS,
+		"goarch":      runtime.GOARCH,
+	}
+	if host, err := os.Hostname(); err == nil {
+		fields["host"] = host
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fields["main_module"] = info.Main.Path
+		fields["main_version"] = info.Main.Version
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				fields["vcs_revision"] = s.Value
+			}
+		}
+	}
+	r.logLifecycle("Initialise", "logger started", fields)
+}
+
+// logShutdown writes the shutdown entry with the uptime and the totals,
+// only the first time it is called
+func (r AppLogger) logShutdown() {
+	if !r.life.stopped.CompareAndSwap(false, true) {
+		return
+	}
+	r.logLifecycle("Close", "logger stopped", map[string]interface{}{
+		"uptime_ms": time.Since(r.life.started).Milliseconds(),
+		"entries":   r.life.entries.Load(),
+		"errors":    r.life.errors.Load(),
+		"dropped":   r.Dropped(),
+	})
+}
+
+func (r AppLogger) logLifecycle(logFunc string, message string, fields map[string]interface{}) {
+	r.logInternal(Entry{PID: r.newID(), Level: "INFO", Package: "applogger", Func: logFunc, Message: message, Time: time.Now()}, appendMap(nil, fields))
+}
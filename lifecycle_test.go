@@ -0,0 +1,40 @@
+package applogger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLifecycle(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	mem := &memorySink{}
+	logger := AppLogger{Path: "./tmp/lifecycle.ndjson", Lifecycle: true, Sinks: []Sink{mem}}
+	logger.Initialise()
+	logger.Log("INFO", "main", "app", "working")
+	logger.Log("ERROR", "main", "app", "failed")
+	logger.Close()
+	logger.Close()
+
+	if len(mem.entries) != 4 {
+		t.Fatalf("expected startup, 2 entries and shutdown, got %d entries", len(mem.entries))
+	}
+	start, stop := mem.entries[0], mem.entries[3]
+	if start.Message != "logger started" || start.Attributes["path"] != "./tmp/lifecycle.ndjson" || start.Attributes["go_version"] == nil {
+		t.Fatalf("unexpected startup entry %+v", start)
+	}
+	if stop.Message != "logger stopped" || stop.Attributes["entries"] != uint64(3) || stop.Attributes["errors"] != uint64(1) {
+		t.Fatalf("unexpected shutdown entry %+v", stop)
+	}
+
+	b, err := os.ReadFile("./tmp/lifecycle.ndjson")
+	if err != nil {
+		t.Fatalf("Couldn't read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if !strings.Contains(lines[0], "logger started") || !strings.Contains(lines[len(lines)-1], "logger stopped") {
+		t.Fatalf("log should begin and end with the lifecycle entries:\n%s", b)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package applogger
+
+import (
+	"os"
+)
+
+// lockFile is a no-op where flock is not available, writes still rely on
+// O_APPEND
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,33 @@
+package applogger
+
+import (
+	"encoding/json"
+)
+
+// MarshalErrorKey is the attribute listing, by key, the attributes removed
+// from an entry because they could not be serialized
+const MarshalErrorKey = "_marshal_error"
+
+// dropUnmarshalable removes the attributes that cannot be serialized from
+// the entry and records them under _marshal_error, so the rest of the entry
+// is still written
+func dropUnmarshalable(e *Entry) {
+	if !e.hasAttributes() {
+		return
+	}
+	if _, err := encodeAttributes(*e); err == nil {
+		return
+	}
+
+	var kept []attr
+	failed := make(map[string]string)
+	e.eachAttribute(func(k string, v interface{}) {
+		if _, err := json.Marshal(v); err != nil {
+			failed[k] = err.Error()
+			return
+		}
+		kept = append(kept, attr{k, v})
+	})
+	e.base = nil
+	e.attrs = append(kept, attr{MarshalErrorKey, failed})
+}
@@ -0,0 +1,34 @@
+package applogger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStrictSerialization(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	logger := AppLogger{Path: "./tmp/strict.ndjson", StrictSerialization: true}
+	logger.Initialise()
+	logger.WithFields(map[string]interface{}{"service": "api"}).LogFields("INFO", "main", "app", "partly bad", map[string]interface{}{
+		"ch":      make(chan int),
+		"user_id": 42,
+	})
+	logger.Close()
+
+	b, err := os.ReadFile("./tmp/strict.ndjson")
+	if err != nil {
+		t.Fatalf("Couldn't read log file: %v", err)
+	}
+	line := strings.TrimSpace(string(b))
+	if isJSON(line) != nil {
+		t.Fatalf("entry should still be written: %q", line)
+	}
+	for _, want := range []string{`"service":"api"`, `"user_id":42`, `"_marshal_error":{"ch":"json: unsupported type: chan int"}`} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("expected %s in %s", want, line)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package applogger
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// HTTPMiddleware writes an HTTP entry for every request served by the
+// handlers it wraps: the status as code, the duration in seconds and the
+// method, route and raw path as attributes
+type HTTPMiddleware struct {
+	Logger AppLogger
+	// Package is the package of the entries, "http" when empty
+	Package string
+	// Route returns the route template that matched the request, e.g.
+	// /users/{id}, so entries of one route share a key instead of one per
+	// URL. By default it is the pattern set by http.ServeMux. Routers that
+	// keep it elsewhere are plugged in here, with the middleware installed
+	// inside the router so the route is known:
+	//
+	//	// chi
+	//	func(r *http.Request) string { return chi.RouteContext(r.Context()).RoutePattern() }
+	//	// gorilla/mux, with router.Use(m.Wrap)
+	//	func(r *http.Request) string { t, _ := mux.CurrentRoute(r).GetPathTemplate(); return t }
+	Route func(r *http.Request) string
+	// TrustedProxies are the networks of the load balancers and proxies in
+	// front of the service. Only when the peer is in one of them is the
+	// client IP taken from X-Forwarded-For or X-Real-IP, otherwise anyone
+	// could set it. Both client_ip and the peer, remote_addr, are logged.
+	TrustedProxies []netip.Prefix
+	// RequestIDs generates the request ID of requests without one, UUIDv4
+	// by default, and CorrelationIDs their correlation ID, ULID by default
+	RequestIDs     IDGenerator
+	CorrelationIDs IDGenerator
+	// Summary, when set, collects the latency and status of the requests
+	// per route and writes summary entries, see LatencySummary
+	Summary *LatencySummary
+}
+
+// NewHTTPMiddleware returns a middleware writing to logger
+func NewHTTPMiddleware(logger AppLogger) *HTTPMiddleware {
+	return &HTTPMiddleware{Logger: logger}
+}
+
+// Middleware wraps next with an HTTPMiddleware with the default settings
+func (r AppLogger) Middleware(next http.Handler) http.Handler {
+	return NewHTTPMiddleware(r).Wrap(next)
+}
+
+// RequestIDHeader is the header the middleware takes the request ID from,
+// and sets on the response
+const RequestIDHeader = "X-Request-ID"
+
+// Wrap returns a handler serving next and logging every request. The
+// handlers get a logger with the request_id, route and method of the
+// request from FromContext, and the correlation ID of the caller, or a new
+// one, and its baggage in the request context. With a Summary it starts
+// writing summary windows, until Close.
+func (m *HTTPMiddleware) Wrap(next http.Handler) http.Handler {
+	if m.Summary != nil {
+		m.Summary.startTicker(m.Logger, m.pkg())
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		requestID := req.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = m.newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		route := m.route(req, next)
+		fields := map[string]interface{}{"request_id": requestID, "method": req.Method}
+		if route != "" {
+			fields["route"] = route
+		}
+		logger := m.Logger.WithFields(fields)
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		ctx := WithCorrelationID(req.Context(), m.correlationID(req))
+		ctx = ExtractBaggage(req.WithContext(ctx))
+		w.Header().Set(CorrelationIDHeader, CorrelationID(ctx))
+		inner := req.WithContext(IntoContext(ctx, logger))
+		next.ServeHTTP(sw, inner)
+
+		attrs := []attr{{"path", req.URL.Path}, {"client_ip", m.ClientIP(req)}, {"remote_addr", req.RemoteAddr}}
+		if route == "" {
+			// a ServeMux deeper down only sets the pattern while serving
+			if route = m.route(inner, nil); route != "" {
+				attrs = append(attrs, attr{"route", route})
+			}
+		}
+		target := req.URL.Path
+		if route != "" {
+			target = route
+		}
+		pkg := m.pkg()
+		elapsed := time.Since(start)
+		e := Entry{PID: logger.newID(), Level: statusLevel(sw.status).String(), Package: pkg, Func: "ServeHTTP", Message: req.Method + " " + target,
+			Time: start, HTTP: true, Code: sw.status, Duration: elapsed.Seconds()}
+		logger.logInternal(e, logger.contextAttrs(ctx, start, attrs))
+		if m.Summary != nil {
+			if route == "" {
+				// raw paths would make a summary per URL
+				route = "unmatched"
+			}
+			m.Summary.record(m.Logger, pkg, req.Method, route, sw.status, elapsed)
+		}
+	})
+}
+
+// FlushSummary writes the summary of the requests of the current window,
+// e.g. before the logger is closed on shutdown
+func (m *HTTPMiddleware) FlushSummary() {
+	if m.Summary != nil {
+		m.Summary.flush(m.Logger, m.pkg())
+	}
+}
+
+// Close stops writing summary windows and writes the current one, call it
+// on shutdown before the logger is closed
+func (m *HTTPMiddleware) Close() {
+	if m.Summary != nil {
+		m.Summary.stopTicker()
+	}
+	m.FlushSummary()
+}
+
+// pkg returns the package of the entries
+func (m *HTTPMiddleware) pkg() string {
+	if m.Package == "" {
+		return "http"
+	}
+	return m.Package
+}
+
+// newRequestID returns the ID of a request without one
+func (m *HTTPMiddleware) newRequestID() string {
+	if m.RequestIDs != nil {
+		return m.RequestIDs()
+	}
+	return UUIDv4()
+}
+
+// correlationID returns the correlation ID of the caller, or a new one
+func (m *HTTPMiddleware) correlationID(req *http.Request) string {
+	if id := req.Header.Get(CorrelationIDHeader); id != "" {
+		return id
+	}
+	if m.CorrelationIDs != nil {
+		return m.CorrelationIDs()
+	}
+	return NewCorrelationID()
+}
+
+// route returns the route template of the request, or "" when unknown.
+// When next is a ServeMux it is asked for the pattern up front.
+func (m *HTTPMiddleware) route(req *http.Request, next http.Handler) string {
+	if m.Route != nil {
+		return m.Route(req)
+	}
+	pattern := req.Pattern
+	if mux, ok := next.(*http.ServeMux); ok && pattern == "" {
+		_, pattern = mux.Handler(req)
+	}
+	// ServeMux patterns may start with a method, "GET /users/{id}"
+	if _, path, found := strings.Cut(pattern, " "); found {
+		return path
+	}
+	return pattern
+}
+
+// ClientIP returns the address of the client that sent the request: the
+// peer, unless it is a trusted proxy, in which case the last address of
+// X-Forwarded-For that is not a trusted proxy, or X-Real-IP
+func (m *HTTPMiddleware) ClientIP(req *http.Request) string {
+	peer := remoteIP(req.RemoteAddr)
+	if !m.trusted(peer) {
+		return peer
+	}
+	var hops []string
+	for _, header := range req.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !m.trusted(hops[i]) {
+			return hops[i]
+		}
+	}
+	if len(hops) > 0 {
+		return hops[0]
+	}
+	if real := strings.TrimSpace(req.Header.Get("X-Real-IP")); real != "" {
+		return real
+	}
+	return peer
+}
+
+// trusted reports whether ip is in one of the trusted proxy networks
+func (m *HTTPMiddleware) trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range m.TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP strips the port from a RemoteAddr
+func remoteIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+// statusLevel is Error for 5xx responses, Warn for 4xx and Info otherwise
+func statusLevel(status int) LogLevel {
+	switch {
+	case status >= 500:
+		return LevelError
+	case status >= 400:
+		return LevelWarn
+	}
+	return LevelInfo
+}
+
+// statusWriter records the status code written by a handler
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the underlying writer when it is an http.Flusher, so that
+// streaming handlers behind the middleware still stream
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack hands over the connection when the underlying writer is an
+// http.Hijacker, for websockets behind the middleware
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
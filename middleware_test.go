@@ -0,0 +1,259 @@
+package applogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var m map[string]interface{}
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, m)
+	}
+	return entries
+}
+
+func TestMiddlewareRoute(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	h := logger.Middleware(mux)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %v", entries)
+	}
+	first := entries[0]
+	attributes := first["attributes"].(map[string]interface{})
+	if first["message"] != "GET /users/{id}" || first["code"] != float64(404) || first["level"] != "WARN" {
+		t.Fatalf("unexpected entry %v", first)
+	}
+	if attributes["route"] != "/users/{id}" || attributes["path"] != "/users/42" || attributes["method"] != "GET" {
+		t.Fatalf("unexpected attributes %v", attributes)
+	}
+	if entries[1]["message"] != "GET /missing" {
+		t.Fatalf("expected the raw path without a route, got %v", entries[1])
+	}
+}
+
+func TestMiddlewareRouteFunc(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+
+	m := NewHTTPMiddleware(logger)
+	m.Route = func(r *http.Request) string { return "/orders/:id" }
+	m.Wrap(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/orders/7", nil))
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 1 || entries[0]["message"] != "DELETE /orders/:id" {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+}
+
+func TestMiddlewareFlush(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+
+	h := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("the writer given to the handler is not an http.Flusher")
+		}
+		w.Write([]byte("data: 1\n\n"))
+		f.Flush()
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Fatal("the writer given to the handler is not an http.Hijacker")
+		}
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+
+	if !rec.Flushed || rec.Body.String() != "data: 1\n\n" {
+		t.Fatalf("expected the event to be flushed, got flushed=%v body=%q", rec.Flushed, rec.Body)
+	}
+	if entries := decodeLines(t, &buf); len(entries) != 1 || entries[0]["code"] != float64(200) {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	m := &HTTPMiddleware{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}
+	cases := []struct {
+		remote, forwarded, real, want string
+	}{
+		{"203.0.113.9:5000", "198.51.100.1", "", "203.0.113.9"},
+		{"10.0.0.2:5000", "198.51.100.1, 10.0.0.7", "", "198.51.100.1"},
+		{"10.0.0.2:5000", "1.2.3.4, 198.51.100.1, 10.0.0.7", "", "198.51.100.1"},
+		{"10.0.0.2:5000", "", "198.51.100.2", "198.51.100.2"},
+		{"10.0.0.2:5000", "", "", "10.0.0.2"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = c.remote
+		if c.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		if c.real != "" {
+			req.Header.Set("X-Real-IP", c.real)
+		}
+		if got := m.ClientIP(req); got != c.want {
+			t.Errorf("ClientIP(%s, %q, %q) = %s want %s", c.remote, c.forwarded, c.real, got, c.want)
+		}
+	}
+}
+
+func TestMiddlewareRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		scoped, ok := FromContext(r.Context())
+		if !ok {
+			t.Fatal("no logger in the request context")
+		}
+		scoped.Log("INFO", "orders", "create", "order created")
+	})
+	req := httptest.NewRequest("POST", "/orders/9", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	logger.Middleware(mux).ServeHTTP(rec, req)
+
+	if rec.Header().Get(RequestIDHeader) != "req-1" {
+		t.Fatalf("request ID not echoed, got %q", rec.Header().Get(RequestIDHeader))
+	}
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %v", entries)
+	}
+	for _, e := range entries {
+		attributes := e["attributes"].(map[string]interface{})
+		if attributes["request_id"] != "req-1" || attributes["route"] != "/orders/{id}" || attributes["method"] != "POST" {
+			t.Fatalf("entry is missing the request fields %v", e)
+		}
+	}
+}
+
+func TestMiddlewareSummary(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}}
+	logger.Initialise()
+	defer logger.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewHTTPMiddleware(logger)
+	m.Summary = &LatencySummary{Interval: time.Minute, now: func() time.Time { return now }}
+	defer m.Close()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "0" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	h := m.Wrap(mux)
+	for _, path := range []string{"/users/1", "/users/2", "/users/0", "/nope"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	now = now.Add(time.Minute)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/3", nil))
+
+	var summaries []Entry
+	for _, e := range mem.entries {
+		if e.Func == "LatencySummary" {
+			summaries = append(summaries, e)
+		}
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("expected a summary per route of the first window, got %+v", summaries)
+	}
+	users := summaries[0]
+	if users.Message != "summary GET /users/{id}" || users.Level != "WARN" {
+		t.Fatalf("unexpected summary %+v", users)
+	}
+	if count, _ := users.Lookup("count"); count != 3 {
+		t.Fatalf("count = %v", count)
+	}
+	if n, _ := users.Lookup("status_2xx"); n != 2 {
+		t.Fatalf("status_2xx = %v", n)
+	}
+	if n, _ := users.Lookup("status_5xx"); n != 1 {
+		t.Fatalf("status_5xx = %v", n)
+	}
+	if summaries[1].Message != "summary GET unmatched" {
+		t.Fatalf("unexpected summary %+v", summaries[1])
+	}
+
+	m.FlushSummary()
+	if last := mem.entries[len(mem.entries)-1]; last.Message != "summary GET /users/{id}" {
+		t.Fatalf("FlushSummary did not write the current window: %+v", last)
+	}
+}
+
+func TestMiddlewareSummaryTicker(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}}
+	logger.Initialise()
+	defer logger.Close()
+
+	m := NewHTTPMiddleware(logger)
+	m.Summary = &LatencySummary{Interval: 20 * time.Millisecond}
+	h := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/idle", nil))
+
+	summaries := func() int {
+		mem.mu.Lock()
+		defer mem.mu.Unlock()
+		n := 0
+		for _, e := range mem.entries {
+			if e.Func == "LatencySummary" {
+				n++
+			}
+		}
+		return n
+	}
+	for deadline := time.Now().Add(time.Second); summaries() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("the window was not written without a further request")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/idle", nil))
+	m.Close()
+	n := summaries()
+	time.Sleep(50 * time.Millisecond)
+	if summaries() != n || n != 2 {
+		t.Fatalf("expected the last window written by Close and nothing after it, got %d then %d", n, summaries())
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	if p := percentile(values, 50); p != 5 {
+		t.Fatalf("p50 = %v", p)
+	}
+	if p := percentile(values, 99); p != 10 {
+		t.Fatalf("p99 = %v", p)
+	}
+}
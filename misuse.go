@@ -0,0 +1,39 @@
+package applogger
+
+import (
+	"fmt"
+)
+
+// MisuseError is the panic value of a logger in Development mode when it
+// is used wrongly, it carries the entry that triggered it
+type MisuseError struct {
+	Problem string
+	Level   string
+	Package string
+	Func    string
+	Message string
+}
+
+func (m *MisuseError) Error() string {
+	return fmt.Sprintf("applogger: %s (level=%s package=%s func=%s message=%q)", m.Problem, m.Level, m.Package, m.Func, m.Message)
+}
+
+// checkMisuse panics with a MisuseError when the entry is logged after
+// Close, has attributes that cannot be serialized or has a value without a
+// key from the key/value arguments of Info and the others. It is only called in
+// Development mode, production loggers degrade instead.
+func (r AppLogger) checkMisuse(e Entry) {
+	problem := ""
+	if r.out != nil && r.out.isClosed() {
+		problem = "entry logged after Close"
+	} else if e.hasAttributes() {
+		if _, err := encodeAttributes(e); err != nil {
+			problem = "attribute cannot be serialized: " + err.Error()
+		} else if v, ok := e.Lookup(BadKey); ok {
+			problem = fmt.Sprintf("value %v has no key", v)
+		}
+	}
+	if problem != "" {
+		panic(&MisuseError{Problem: problem, Level: e.Level, Package: e.Package, Func: e.Func, Message: e.Message})
+	}
+}
@@ -0,0 +1,46 @@
+package applogger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func expectMisuse(t *testing.T, problem string, f func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		m, ok := recover().(*MisuseError)
+		if !ok {
+			t.Fatalf("expected a *MisuseError panic for %s", problem)
+		}
+		if !strings.Contains(m.Problem, problem) || m.Level != "INFO" {
+			t.Fatalf("unexpected misuse %s", m)
+		}
+	}()
+	f()
+}
+
+func TestDevelopmentMisuse(t *testing.T) {
+	logger := AppLogger{Path: "/dev/null", Development: true}
+	logger.Initialise()
+
+	expectMisuse(t, "cannot be serialized", func() {
+		logger.LogFields("INFO", "main", "app", "bad value", map[string]interface{}{"ch": make(chan int)})
+	})
+	expectMisuse(t, "has no key", func() {
+		logger.Info(context.Background(), "odd arguments", "order_id", 7, 1250)
+	})
+
+	logger.Close()
+	expectMisuse(t, "after Close", func() {
+		logger.Log("INFO", "main", "app", "too late")
+	})
+}
+
+func TestProductionDegrades(t *testing.T) {
+	logger := AppLogger{Path: "/dev/null"}
+	logger.Initialise()
+	logger.Close()
+	logger.Log("INFO", "main", "app", "dropped after close")
+}
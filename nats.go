@@ -0,0 +1,109 @@
+package applogger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NATSPublisher is the part of a NATS connection used by NATSSink, it is
+// satisfied by *nats.Conn
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// NATSPublisherFunc adapts a function to NATSPublisher, which is how a
+// JetStream context is plugged in to get persisted entries:
+//
+//	applogger.NATSPublisherFunc(func(subject string, data []byte) error {
+//		_, err := js.Publish(subject, data)
+//		return err
+//	})
+type NATSPublisherFunc func(subject string, data []byte) error
+
+// Publish calls f(subject, data)
+func (f NATSPublisherFunc) Publish(subject string, data []byte) error {
+	return f(subject, data)
+}
+
+// NATSSink publishes every entry as ndjson to a NATS subject. The subject
+// can be templated with {level}, {package}, {func} and {attributes.<key>},
+// for example "logs.{level}.{attributes.tenant}". A missing attribute
+// renders as "_".
+type NATSSink struct {
+	Conn    NATSPublisher
+	Subject string
+	// Encoder defaults to JSONEncoder
+	Encoder Encoder
+	// Retry retries failed publishes, nil publishes once
+	Retry *RetryPolicy
+}
+
+// Write publishes the entry on the subject rendered for it
+func (s NATSSink) Write(e Entry) error {
+	enc := s.Encoder
+	if enc == nil {
+		enc = JSONEncoder{}
+	}
+	data, err := enc.Encode(e)
+	if err != nil {
+		return err
+	}
+	subject := s.subject(e)
+	return s.Retry.Do(func() error { return s.Conn.Publish(subject, data) })
+}
+
+// Close does nothing, the connection is owned by the caller
+func (s NATSSink) Close() error {
+	return nil
+}
+
+// subject renders the subject template for the entry in one pass, so that
+// a value holding a placeholder is not rendered again
+func (s NATSSink) subject(e Entry) string {
+	if !strings.Contains(s.Subject, "{") {
+		return s.Subject
+	}
+	var b strings.Builder
+	rest := s.Subject
+	for {
+		i := strings.IndexByte(rest, '{')
+		j := strings.IndexByte(rest[i+1:], '}')
+		if i < 0 || j < 0 {
+			b.WriteString(rest)
+			return b.String()
+		}
+		b.WriteString(rest[:i])
+		placeholder := rest[i : i+j+2]
+		rest = rest[i+j+2:]
+		switch name := placeholder[1 : len(placeholder)-1]; {
+		case name == "level":
+			b.WriteString(subjectToken(strings.ToLower(e.Level)))
+		case name == "package":
+			b.WriteString(subjectToken(e.Package))
+		case name == "func":
+			b.WriteString(subjectToken(e.Func))
+		case strings.HasPrefix(name, "attributes."):
+			var value string
+			if v, ok := e.Lookup(strings.TrimPrefix(name, "attributes.")); ok && v != nil {
+				value = fmt.Sprint(v)
+			}
+			b.WriteString(subjectToken(value))
+		default:
+			b.WriteString(placeholder)
+		}
+	}
+}
+
+// subjectToken makes a value safe to be used as a single NATS subject token
+func subjectToken(v string) string {
+	if v == "" {
+		return "_"
+	}
+	return strings.Map(func(c rune) rune {
+		switch c {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return c
+	}, v)
+}
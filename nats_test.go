@@ -0,0 +1,70 @@
+package applogger
+
+import (
+	"os"
+	"testing"
+)
+
+type publishedMessage struct {
+	subject string
+	data    []byte
+}
+
+func TestNATSSink(t *testing.T) {
+	directoryPath := "./tmp"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	var published []publishedMessage
+	conn := NATSPublisherFunc(func(subject string, data []byte) error {
+		published = append(published, publishedMessage{subject, data})
+		return nil
+	})
+
+	logger := AppLogger{Path: directoryPath + "/nats.ndjson", Sinks: []Sink{NATSSink{Conn: conn, Subject: "logs.{level}.{package}"}}}
+	logger.Initialise()
+	logger.Log("INFO", "main", "app", "This is a test")
+	logger.LogHTTP("ERROR", "github.com/acme.svc", "perform", "failed", 500, 0.3)
+
+	if len(published) != 2 {
+		t.Fatalf("expected 2 published messages, got %d", len(published))
+	}
+	if published[0].subject != "logs.info.main" {
+		t.Fatalf("unexpected subject %s", published[0].subject)
+	}
+	if published[1].subject != "logs.error.github_com/acme_svc" {
+		t.Fatalf("unexpected subject %s", published[1].subject)
+	}
+	for _, m := range published {
+		if err := isJSON(string(m.data)); err != nil {
+			t.Fatalf("payload is not in a json format %s with error %s", m.data, err)
+		}
+	}
+}
+
+func TestNATSSinkAttributeSubject(t *testing.T) {
+	directoryPath := "./tmp"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	var published []publishedMessage
+	conn := NATSPublisherFunc(func(subject string, data []byte) error {
+		published = append(published, publishedMessage{subject, data})
+		return nil
+	})
+
+	logger := AppLogger{Path: directoryPath + "/nats.ndjson", Sinks: []Sink{NATSSink{Conn: conn, Subject: "logs.{attributes.tenant}.{level}"}}}
+	logger.Initialise()
+	logger.LogFields("INFO", "main", "app", "billed", map[string]interface{}{"tenant": "acme.eu {level}"})
+	logger.LogFields("INFO", "main", "app", "billed", map[string]interface{}{"tenant": 7})
+	logger.Log("INFO", "main", "app", "no tenant")
+
+	if len(published) != 3 {
+		t.Fatalf("expected 3 published messages, got %d", len(published))
+	}
+	for i, want := range []string{"logs.acme_eu_{level}.info", "logs.7.info", "logs._.info"} {
+		if published[i].subject != want {
+			t.Fatalf("expected subject %s, got %s", want, published[i].subject)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package applogger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Operation times a unit of work and writes a single summary entry for it
+// on End, a span for services that do not run tracing. It is safe for
+// concurrent use.
+type Operation struct {
+	logger  AppLogger
+	ctx     context.Context
+	name    string
+	parent  string
+	pkg     string
+	fn      string
+	started time.Time
+
+	mu     sync.Mutex
+	fields []attr
+	err    error
+	ended  bool
+}
+
+type operationKey struct{}
+
+// StartOperation starts timing the operation name, e.g. "import.users".
+// Operations started from the Context of another one record its name as
+// parent_operation. Package and Func are those of the caller.
+func (r AppLogger) StartOperation(ctx context.Context, name string) *Operation {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	op := &Operation{logger: r, name: name, started: r.now()}
+	op.pkg, op.fn = getCallerInfo(1)
+	if parent, ok := ctx.Value(operationKey{}).(*Operation); ok {
+		op.parent = parent.name
+	}
+	op.ctx = context.WithValue(ctx, operationKey{}, op)
+	return op
+}
+
+// Context returns the context of the operation, to start nested ones
+func (op *Operation) Context() context.Context {
+	return op.ctx
+}
+
+// AddField adds an attribute to the summary entry
+func (op *Operation) AddField(key string, value interface{}) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.fields = append(op.fields, attr{key, value})
+}
+
+// Fail marks the operation as failed with err, the summary entry is then
+// written at the level of LogError
+func (op *Operation) Fail(err error) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.err = err
+}
+
+// End writes the summary entry with the duration in milliseconds and the
+// outcome, success or failure. Only the first call writes.
+func (op *Operation) End() {
+	op.mu.Lock()
+	if op.ended {
+		op.mu.Unlock()
+		return
+	}
+	op.ended = true
+	attrs := append([]attr(nil), op.fields...)
+	err := op.err
+	op.mu.Unlock()
+
+	s1 := op.logger.now()
+
+	attrs = append(attrs, attr{"operation", op.name}, attr{"duration_ms", float64(s1.Sub(op.started).Microseconds()) / 1000})
+	if op.parent != "" {
+		attrs = append(attrs, attr{"parent_operation", op.parent})
+	}
+	level := LevelInfo
+	if err != nil {
+		level = op.logger.ErrorLevel(err)
+		attrs = append(attrs, attr{"outcome", "failure"}, attr{ErrorKey, err.Error()})
+	} else {
+		attrs = append(attrs, attr{"outcome", "success"})
+	}
+	e := Entry{PID: op.logger.newID(), Level: level.String(), Package: op.pkg, Func: op.fn, Message: op.name, Time: s1}
+	op.logger.logInternal(e, op.logger.contextAttrs(op.ctx, s1, attrs))
+}
@@ -0,0 +1,38 @@
+package applogger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOperation(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+
+	op := logger.StartOperation(context.Background(), "import.users")
+	op.AddField("rows", 120)
+	child := logger.StartOperation(op.Context(), "import.users.validate")
+	child.Fail(errors.New("bad email on row 7"))
+	child.End()
+	op.End()
+	op.End()
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected one entry per operation, got %v", entries)
+	}
+	child1 := entries[0]["attributes"].(map[string]interface{})
+	if entries[0]["level"] != "ERROR" || child1["outcome"] != "failure" || child1["parent_operation"] != "import.users" || child1[ErrorKey] != "bad email on row 7" {
+		t.Fatalf("unexpected child entry %v", entries[0])
+	}
+	parent := entries[1]["attributes"].(map[string]interface{})
+	if entries[1]["message"] != "import.users" || parent["outcome"] != "success" || parent["rows"] != float64(120) || entries[1]["func"] != "TestOperation" {
+		t.Fatalf("unexpected entry %v", entries[1])
+	}
+	if _, ok := parent["duration_ms"].(float64); !ok {
+		t.Fatalf("missing duration %v", entries[1])
+	}
+}
@@ -0,0 +1,157 @@
+package applogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// OTelEncoder writes every entry as an OTLP/JSON ExportLogsServiceRequest
+// holding a single log record, the layout of the OpenTelemetry Collector
+// file exporter, so the otlpjsonfile receiver reads the file as is.
+// Package, Func, PID and the HTTP fields become attributes following the
+// semantic conventions, Resource (e.g. service.name) goes on the resource.
+type OTelEncoder struct {
+	Resource map[string]interface{}
+}
+
+// otelSeverity maps a level to its OpenTelemetry severity number
+func otelSeverity(l LogLevel) int {
+	switch l {
+	case LevelTrace:
+		return 1
+	case LevelDebug:
+		return 5
+	case LevelInfo:
+		return 9
+	case LevelWarn:
+		return 13
+	case LevelError:
+		return 17
+	}
+	if l < LevelTrace {
+		return 1
+	}
+	return 21
+}
+
+// Encode writes the entry as a single line OTLP/JSON document
+func (o OTelEncoder) Encode(e Entry) ([]byte, error) {
+	var rec bytes.Buffer
+	rec.WriteByte('{')
+	nanos := []byte(strconv.Quote(strconv.FormatInt(e.Time.UnixNano(), 10)))
+	writeJSONRaw(&rec, "timeUnixNano", nanos)
+	writeJSONRaw(&rec, "observedTimeUnixNano", nanos)
+	writeJSONField(&rec, "severityNumber", otelSeverity(levelOf(e.Level)))
+	writeJSONInterned(&rec, "severityText", e.Level)
+	body, err := otelValue(e.Message)
+	if err != nil {
+		return nil, err
+	}
+	writeJSONRaw(&rec, "body", body)
+
+	attributes := []attr{{"code.namespace", e.Package}, {"code.function", e.Func}, {"log.record.uid", e.PID}}
+	if e.HTTP {
+		attributes = append(attributes, attr{"http.response.status_code", e.Code}, attr{"http.server.request.duration", e.Duration})
+	}
+	e.eachAttribute(func(k string, v interface{}) { attributes = append(attributes, attr{k, v}) })
+	kvs, err := otelKeyValues(attributes)
+	if err != nil {
+		return nil, err
+	}
+	writeJSONRaw(&rec, "attributes", kvs)
+	rec.WriteByte('}')
+
+	resource := appendMap(nil, o.Resource)
+	sort.Slice(resource, func(i, j int) bool { return resource[i].key < resource[j].key })
+	res, err := otelKeyValues(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"resourceLogs":[{"resource":{"attributes":`)
+	buf.Write(res)
+	buf.WriteString(`},"scopeLogs":[{"scope":{"name":"github.com/junkd0g/applogger"},"logRecords":[`)
+	buf.Write(rec.Bytes())
+	buf.WriteString(`]}]}]}`)
+	return buf.Bytes(), nil
+}
+
+// otelKeyValues encodes attributes as an OTLP KeyValue list
+func otelKeyValues(attrs []attr) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, a := range attrs {
+		v, err := otelValue(a.value)
+		if err != nil {
+			return nil, fmt.Errorf("applogger: attribute %s: %w", a.key, err)
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"key":`)
+		buf.Write(quoteInterned(a.key))
+		buf.WriteString(`,"value":`)
+		buf.Write(v)
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// otelValue encodes v as an OTLP AnyValue, values without a matching
+// AnyValue type are written as their JSON text
+func otelValue(v interface{}) ([]byte, error) {
+	var kind string
+	var value interface{} = v
+	switch x := v.(type) {
+	case nil:
+		return []byte("{}"), nil
+	case string:
+		kind = "stringValue"
+	case bool:
+		kind = "boolValue"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32:
+		kind, value = "intValue", fmt.Sprint(x)
+	case float32, float64:
+		kind = "doubleValue"
+	case []interface{}:
+		values := make([]json.RawMessage, len(x))
+		for i, item := range x {
+			b, err := otelValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = b
+		}
+		kind, value = "arrayValue", map[string]interface{}{"values": values}
+	case map[string]interface{}:
+		kvs := appendMap(nil, x)
+		sort.Slice(kvs, func(i, j int) bool { return kvs[i].key < kvs[j].key })
+		b, err := otelKeyValues(kvs)
+		if err != nil {
+			return nil, err
+		}
+		kind, value = "kvlistValue", map[string]json.RawMessage{"values": b}
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var text string
+		if json.Unmarshal(b, &text) != nil {
+			text = string(b)
+		}
+		kind, value = "stringValue", text
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if err := writeJSONField(&buf, kind, value); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
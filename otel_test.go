@@ -0,0 +1,84 @@
+package applogger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOTelEncoder(t *testing.T) {
+	e := Entry{PID: "1", Level: "WARN", Package: "main", Func: "app", Message: "slow", Time: time.Unix(1, 5).UTC(), HTTP: true, Code: 200, Duration: 0.5,
+		Attributes: map[string]interface{}{"user_id": 42, "tags": []interface{}{"a", true}}}
+	line, err := OTelEncoder{Resource: map[string]interface{}{"service.name": "api"}}.Encode(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string                 `json:"key"`
+					Value map[string]interface{} `json:"value"`
+				} `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				LogRecords []struct {
+					TimeUnixNano   string                 `json:"timeUnixNano"`
+					SeverityNumber int                    `json:"severityNumber"`
+					SeverityText   string                 `json:"severityText"`
+					Body           map[string]interface{} `json:"body"`
+					Attributes     []struct {
+						Key   string                 `json:"key"`
+						Value map[string]interface{} `json:"value"`
+					} `json:"attributes"`
+				} `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+	if err := json.Unmarshal(line, &doc); err != nil {
+		t.Fatalf("%v: %s", err, line)
+	}
+	if res := doc.ResourceLogs[0].Resource.Attributes; len(res) != 1 || res[0].Value["stringValue"] != "api" {
+		t.Fatalf("unexpected resource %s", line)
+	}
+	rec := doc.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	if rec.TimeUnixNano != "1000000005" || rec.SeverityNumber != 13 || rec.SeverityText != "WARN" || rec.Body["stringValue"] != "slow" {
+		t.Fatalf("unexpected record %s", line)
+	}
+	attributes := make(map[string]map[string]interface{})
+	for _, a := range rec.Attributes {
+		attributes[a.Key] = a.Value
+	}
+	if attributes["code.namespace"]["stringValue"] != "main" || attributes["user_id"]["intValue"] != "42" || attributes["http.response.status_code"]["intValue"] != "200" {
+		t.Fatalf("unexpected attributes %s", line)
+	}
+	if _, ok := attributes["tags"]["arrayValue"]; !ok {
+		t.Fatalf("expected an arrayValue for tags %s", line)
+	}
+}
+
+func TestFormatOTel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "otel.ndjson")
+	logger := AppLogger{Path: path, Format: FormatOTel, Resource: map[string]interface{}{"service.name": "api"}}
+	logger.Initialise()
+	logger.Log("INFO", "main", "app", "hello")
+	logger.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("expected a line")
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil || doc["resourceLogs"] == nil {
+		t.Fatalf("unexpected line %s: %v", scanner.Text(), err)
+	}
+}
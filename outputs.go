@@ -0,0 +1,77 @@
+package applogger
+
+import (
+	"io"
+	"sync"
+)
+
+// outputSet holds the outputs added with AddOutput, shared by a logger and
+// the loggers derived from it
+type outputSet struct {
+	mu      sync.Mutex
+	outputs []output
+}
+
+type output struct {
+	w     io.Writer
+	owned bool
+}
+
+// AddOutput writes every line of the main output to w as well. Close
+// closes w when owned and it is an io.Closer, otherwise it only flushes w
+// when it has a Flush method, so a writer shared with other code is never
+// closed under it.
+func (r AppLogger) AddOutput(w io.Writer, owned bool) {
+	r.outputs.mu.Lock()
+	defer r.outputs.mu.Unlock()
+	r.outputs.outputs = append(r.outputs.outputs, output{w: w, owned: owned})
+}
+
+// write writes the line to every output
+func (s *outputSet) write(line []byte) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range s.outputs {
+		o.w.Write(line)
+	}
+}
+
+// flush flushes the outputs that can be flushed
+func (s *outputSet) flush() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	for _, o := range s.outputs {
+		if f, ok := o.w.(interface{ Flush() error }); ok {
+			if ferr := f.Flush(); err == nil {
+				err = ferr
+			}
+		}
+	}
+	return err
+}
+
+// close closes the owned outputs and flushes the others
+func (s *outputSet) close() error {
+	if s == nil {
+		return nil
+	}
+	err := s.flush()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range s.outputs {
+		if c, ok := o.w.(io.Closer); ok && o.owned {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	s.outputs = nil
+	return err
+}
@@ -0,0 +1,66 @@
+package applogger
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestAddOutput(t *testing.T) {
+	owned := &closeRecorder{}
+	shared := &closeRecorder{}
+	var flushed bytes.Buffer
+	buffered := bufio.NewWriter(&flushed)
+
+	logger := AppLogger{Path: "/dev/null"}
+	logger.Initialise()
+	logger.AddOutput(owned, true)
+	logger.AddOutput(shared, false)
+	logger.AddOutput(buffered, false)
+	logger.Log("INFO", "main", "app", "everywhere")
+	logger.Close()
+
+	for name, b := range map[string]string{"owned": owned.String(), "shared": shared.String(), "buffered": flushed.String()} {
+		if strings.Count(b, "\n") != 1 || !strings.Contains(b, "everywhere") {
+			t.Fatalf("%s output did not get the entry: %q", name, b)
+		}
+	}
+	if !owned.closed || shared.closed {
+		t.Fatalf("only the owned output should be closed, owned=%v shared=%v", owned.closed, shared.closed)
+	}
+}
+
+func TestDerivedCloseKeepsOutput(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	logger := AppLogger{Path: "./tmp/derived.ndjson", BufferSize: 4096, FlushInterval: -1}
+	logger.Initialise()
+	child := logger.WithFields(map[string]interface{}{"request_id": "abc"})
+	child.Log("INFO", "main", "app", "from child")
+	child.Close()
+
+	b, _ := os.ReadFile("./tmp/derived.ndjson")
+	if !strings.Contains(string(b), "from child") {
+		t.Fatalf("closing the child should flush, got %q", b)
+	}
+	logger.Log("INFO", "main", "app", "parent still open")
+	logger.Close()
+
+	b, _ = os.ReadFile("./tmp/derived.ndjson")
+	if !strings.Contains(string(b), "parent still open") {
+		t.Fatalf("closing the child closed the parent output: %q", b)
+	}
+}
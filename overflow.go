@@ -0,0 +1,57 @@
+package applogger
+
+// OverflowCountKey is the attribute counting the attributes dropped from an
+// entry over MaxAttributes
+const OverflowCountKey = "_overflow_count"
+
+// pipelineKeys are the attributes the pipeline adds to an entry, never
+// dropped by MaxAttributes
+var pipelineKeys = map[string]bool{
+	CallerKey:           true,
+	StackKey:            true,
+	StackRefKey:         true,
+	GoroutinesKey:       true,
+	RetentionKey:        true,
+	SchemaViolationsKey: true,
+	MarshalErrorKey:     true,
+	QuotaDroppedKey:     true,
+}
+
+// capAttributes keeps max attributes of the entry and records how many
+// were dropped under _overflow_count. The attributes added by the pipeline
+// are kept and count towards max, the room left goes to the first of the
+// others, the default fields first.
+func capAttributes(e *Entry, max int) {
+	n := len(e.attrs)
+	if e.base != nil {
+		n += len(e.base.attrs)
+	}
+	if n <= max {
+		return
+	}
+
+	room := max
+	e.eachAttribute(func(k string, v interface{}) {
+		if pipelineKeys[k] {
+			room--
+		}
+	})
+	kept := make([]attr, 0, max+1)
+	dropped := 0
+	e.eachAttribute(func(k string, v interface{}) {
+		switch {
+		case pipelineKeys[k]:
+			kept = append(kept, attr{k, v})
+		case room > 0:
+			kept = append(kept, attr{k, v})
+			room--
+		default:
+			dropped++
+		}
+	})
+	if dropped == 0 {
+		return
+	}
+	e.base = nil
+	e.attrs = append(kept, attr{OverflowCountKey, dropped})
+}
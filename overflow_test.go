@@ -0,0 +1,46 @@
+package applogger
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestMaxAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}, MaxAttributes: 3}
+	logger.Initialise()
+	derived := logger.WithFields(map[string]interface{}{"service": "api"})
+
+	fields := make(map[string]interface{})
+	for i := 0; i < 1000; i++ {
+		fields[fmt.Sprint("k", i)] = i
+	}
+	derived.LogFields("INFO", "main", "app", "huge", fields)
+	derived.LogFields("INFO", "main", "app", "small", map[string]interface{}{"a": 1})
+
+	entries := decodeLines(t, &buf)
+	huge := entries[0]["attributes"].(map[string]interface{})
+	if len(huge) != 4 || huge[OverflowCountKey] != float64(998) || huge["service"] != "api" {
+		t.Fatalf("unexpected attributes %v", huge)
+	}
+	if small := entries[1]["attributes"].(map[string]interface{}); len(small) != 2 {
+		t.Fatalf("entry under the limit was changed %v", small)
+	}
+}
+
+func TestMaxAttributesKeepsPipelineKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}, MaxAttributes: 3, Caller: true, Retention: "audit"}
+	logger.Initialise()
+
+	logger.LogFields("INFO", "main", "app", "huge", map[string]interface{}{"a": 1, "b": 2, "c": 3})
+
+	attrs := decodeLines(t, &buf)[0]["attributes"].(map[string]interface{})
+	if len(attrs) != 4 || attrs[OverflowCountKey] != float64(2) {
+		t.Fatalf("expected 3 attributes and 2 dropped, got %v", attrs)
+	}
+	if attrs[CallerKey] == nil || attrs[RetentionKey] != "audit" {
+		t.Fatalf("attributes added by the pipeline were dropped %v", attrs)
+	}
+}
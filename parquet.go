@@ -0,0 +1,306 @@
+package applogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// parquetWriter builds a Parquet file in memory: a single row group whose
+// columns are all optional, stored as one uncompressed PLAIN data page
+// each. Only what ConvertParquet needs is implemented.
+type parquetWriter struct {
+	columns []parquetColumn
+	rows    int
+}
+
+type parquetKind int
+
+const (
+	parquetString parquetKind = iota
+	parquetInt64
+	parquetDouble
+	parquetTimestamp
+)
+
+type parquetColumn struct {
+	name   string
+	kind   parquetKind
+	levels []bool // definition level of every row, false for null
+	values bytes.Buffer
+}
+
+// parquet format enum values
+const (
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	parquetOptional = 1
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMicros = 10
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+)
+
+func newParquetWriter(names []string) *parquetWriter {
+	pw := &parquetWriter{}
+	for _, name := range names {
+		kind := parquetString
+		switch name {
+		case "time":
+			kind = parquetTimestamp
+		case "code":
+			kind = parquetInt64
+		case "duration":
+			kind = parquetDouble
+		}
+		pw.columns = append(pw.columns, parquetColumn{name: name, kind: kind})
+	}
+	return pw
+}
+
+// add appends a row, nil values are nulls
+func (pw *parquetWriter) add(row []interface{}) {
+	for i := range pw.columns {
+		c := &pw.columns[i]
+		v := row[i]
+		c.levels = append(c.levels, v != nil)
+		switch v := v.(type) {
+		case string:
+			binary.Write(&c.values, binary.LittleEndian, uint32(len(v)))
+			c.values.WriteString(v)
+		case int64:
+			binary.Write(&c.values, binary.LittleEndian, v)
+		case float64:
+			binary.Write(&c.values, binary.LittleEndian, math.Float64bits(v))
+		}
+	}
+	pw.rows++
+}
+
+// bytes returns the whole file
+func (pw *parquetWriter) bytes() []byte {
+	var out bytes.Buffer
+	out.WriteString("PAR1")
+
+	type chunk struct {
+		offset int64
+		size   int64
+	}
+	chunks := make([]chunk, len(pw.columns))
+	for i := range pw.columns {
+		c := &pw.columns[i]
+		var page bytes.Buffer
+		levels := encodeLevels(c.levels)
+		binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+		page.Write(c.values.Bytes())
+
+		var header thriftWriter
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(page.Len()))
+		header.structBegin(5)
+		header.i32(1, int32(pw.rows))
+		header.i32(2, parquetEncodingPlain)
+		header.i32(3, parquetEncodingRLE)
+		header.i32(4, parquetEncodingRLE)
+		header.structEnd()
+		header.stop()
+
+		chunks[i].offset = int64(out.Len())
+		out.Write(header.buf.Bytes())
+		out.Write(page.Bytes())
+		chunks[i].size = int64(out.Len()) - chunks[i].offset
+	}
+
+	var meta thriftWriter
+	meta.i32(1, 1)
+	meta.listBegin(2, thriftStruct, len(pw.columns)+1)
+	meta.elemBegin()
+	meta.binary(4, []byte("schema"))
+	meta.i32(5, int32(len(pw.columns)))
+	meta.elemEnd()
+	for _, c := range pw.columns {
+		meta.elemBegin()
+		meta.i32(1, c.physicalType())
+		meta.i32(3, parquetOptional)
+		meta.binary(4, []byte(c.name))
+		if converted, ok := c.convertedType(); ok {
+			meta.i32(6, converted)
+		}
+		meta.elemEnd()
+	}
+	meta.i64(3, int64(pw.rows))
+
+	var total int64
+	for _, ch := range chunks {
+		total += ch.size
+	}
+	meta.listBegin(4, thriftStruct, 1)
+	meta.elemBegin()
+	meta.listBegin(1, thriftStruct, len(pw.columns))
+	for i, c := range pw.columns {
+		meta.elemBegin()
+		meta.i64(2, chunks[i].offset)
+		meta.structBegin(3)
+		meta.i32(1, c.physicalType())
+		meta.listBegin(2, thriftI32, 2)
+		meta.varint(zigzag(parquetEncodingPlain))
+		meta.varint(zigzag(parquetEncodingRLE))
+		meta.listBegin(3, thriftBinary, 1)
+		meta.varint(uint64(len(c.name)))
+		meta.buf.WriteString(c.name)
+		meta.i32(4, 0) // UNCOMPRESSED
+		meta.i64(5, int64(pw.rows))
+		meta.i64(6, chunks[i].size)
+		meta.i64(7, chunks[i].size)
+		meta.i64(9, chunks[i].offset)
+		meta.structEnd()
+		meta.elemEnd()
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(pw.rows))
+	meta.elemEnd()
+	meta.binary(6, []byte("applogger"))
+	meta.stop()
+
+	out.Write(meta.buf.Bytes())
+	binary.Write(&out, binary.LittleEndian, uint32(meta.buf.Len()))
+	out.WriteString("PAR1")
+	return out.Bytes()
+}
+
+func (c parquetColumn) physicalType() int32 {
+	switch c.kind {
+	case parquetInt64, parquetTimestamp:
+		return parquetTypeInt64
+	case parquetDouble:
+		return parquetTypeDouble
+	}
+	return parquetTypeByteArray
+}
+
+func (c parquetColumn) convertedType() (int32, bool) {
+	switch c.kind {
+	case parquetString:
+		return parquetConvertedUTF8, true
+	case parquetTimestamp:
+		return parquetConvertedTimestampMicros, true
+	}
+	return 0, false
+}
+
+// encodeLevels encodes definition levels of bit width 1 as RLE runs of the
+// RLE/bit-packing hybrid encoding
+func encodeLevels(levels []bool) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		writeUvarint(&buf, uint64(j-i)<<1)
+		if levels[i] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		i = j
+	}
+	return buf.Bytes()
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+// thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter writes the subset of the thrift compact protocol used by
+// the Parquet metadata
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16
+	id   int16
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.id; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	t.id = id
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	writeUvarint(&t.buf, v)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) binary(id int16, v []byte) {
+	t.field(id, thriftBinary)
+	t.varint(uint64(len(v)))
+	t.buf.Write(v)
+}
+
+func (t *thriftWriter) structBegin(id int16) {
+	t.field(id, thriftStruct)
+	t.elemBegin()
+}
+
+func (t *thriftWriter) structEnd() {
+	t.elemEnd()
+}
+
+// elemBegin starts a struct that is an element of a list
+func (t *thriftWriter) elemBegin() {
+	t.last = append(t.last, t.id)
+	t.id = 0
+}
+
+func (t *thriftWriter) elemEnd() {
+	t.stop()
+	t.id = t.last[len(t.last)-1]
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
+
+func (t *thriftWriter) listBegin(id int16, elem byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elem)
+		return
+	}
+	t.buf.WriteByte(0xf0 | elem)
+	t.varint(uint64(size))
+}
@@ -0,0 +1,95 @@
+package applogger
+
+// Stage is a step of the pipeline every entry goes through between the
+// call to Log and the outputs, in this order:
+//
+//  1. StageEnrich adds data, after the goroutine dump of FATAL entries
+//  2. StageRedact rewrites or removes data, after Scrub
+//  3. StageFilter drops entries, after the Schema check
+//  4. StageSample drops entries to reduce volume
+//
+// After the stages the entry is serialized (StrictSerialization), encoded
+// in the format of the logger and written to the main output, then to
+// every sink, which may have enrichers and encoders of its own (see
+// WithEnrichers). Levels are checked before anything else so that
+// filtered entries cost nothing.
+type Stage int
+
+const (
+	StageEnrich Stage = iota
+	StageRedact
+	StageFilter
+	StageSample
+)
+
+// Processor is a step of the pipeline added through AppLogger.Processors.
+// Process changes the entry in place, with Entry.Set, Entry.Lookup and
+// Entry.Delete, and returns false to drop it. Processors of the same
+// Stage run in the order they are listed.
+type Processor struct {
+	Stage   Stage
+	Process func(e *Entry) bool
+}
+
+// runStage runs the processors of stage and reports whether the entry is
+// still to be written
+func (r AppLogger) runStage(stage Stage, e *Entry) bool {
+	for _, p := range r.Processors {
+		if p.Stage == stage && !p.Process(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// Lookup returns the value of the attribute key of the entry
+func (e *Entry) Lookup(key string) (interface{}, bool) {
+	if e.base == nil && e.attrs == nil {
+		v, ok := e.Attributes[key]
+		return v, ok
+	}
+	for i := len(e.attrs) - 1; i >= 0; i-- {
+		if e.attrs[i].key == key {
+			return e.attrs[i].value, true
+		}
+	}
+	if e.base != nil {
+		for _, a := range e.base.attrs {
+			if a.key == key {
+				return a.value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Set sets the attribute key of the entry to value
+func (e *Entry) Set(key string, value interface{}) {
+	if e.base == nil && e.attrs == nil && e.Attributes != nil {
+		e.Attributes[key] = value
+		return
+	}
+	e.attrs = append(e.attrs, attr{key, value})
+}
+
+// Delete removes the attribute key from the entry
+func (e *Entry) Delete(key string) {
+	if e.base == nil && e.attrs == nil {
+		delete(e.Attributes, key)
+		return
+	}
+	if _, ok := e.Lookup(key); !ok {
+		return
+	}
+	var kept []attr
+	e.eachAttribute(func(k string, v interface{}) {
+		if k != key {
+			kept = append(kept, attr{k, v})
+		}
+	})
+	e.base = nil
+	e.attrs = kept
+	if e.attrs == nil {
+		e.attrs = []attr{}
+	}
+}
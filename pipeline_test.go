@@ -0,0 +1,58 @@
+package applogger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProcessorOrder(t *testing.T) {
+	var buf bytes.Buffer
+	var order []string
+	step := func(name string) func(e *Entry) bool {
+		return func(e *Entry) bool {
+			order = append(order, name)
+			return true
+		}
+	}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}, Processors: []Processor{
+		{Stage: StageSample, Process: step("sample")},
+		{Stage: StageFilter, Process: func(e *Entry) bool {
+			order = append(order, "filter")
+			_, secret := e.Lookup("password")
+			return !secret
+		}},
+		{Stage: StageRedact, Process: func(e *Entry) bool {
+			order = append(order, "redact")
+			if _, ok := e.Lookup("token"); ok {
+				e.Set("token", "[redacted]")
+			}
+			e.Delete("internal")
+			return true
+		}},
+		{Stage: StageEnrich, Process: func(e *Entry) bool {
+			order = append(order, "enrich")
+			e.Set("region", "eu-west-1")
+			return true
+		}},
+		{Stage: StageEnrich, Process: step("enrich2")},
+	}}
+	logger.Initialise()
+	derived := logger.WithFields(map[string]interface{}{"internal": true, "service": "api"})
+	derived.LogFields("INFO", "main", "app", "login", map[string]interface{}{"token": "abc"})
+
+	if got := strings.Join(order, ","); got != "enrich,enrich2,redact,filter,sample" {
+		t.Fatalf("unexpected order %s", got)
+	}
+	line := buf.String()
+	if !strings.Contains(line, `"token":"[redacted]"`) || !strings.Contains(line, `"region":"eu-west-1"`) || !strings.Contains(line, `"service":"api"`) || strings.Contains(line, "internal") {
+		t.Fatalf("unexpected entry %s", line)
+	}
+
+	buf.Reset()
+	order = nil
+	logger.LogFields("INFO", "main", "app", "login", map[string]interface{}{"password": "x"})
+	if buf.Len() != 0 || strings.Join(order, ",") != "enrich,enrich2,redact,filter" {
+		t.Fatalf("filtered entry was written or sampled: %s %v", buf.String(), order)
+	}
+}
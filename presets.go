@@ -0,0 +1,68 @@
+package applogger
+
+import (
+	"os"
+)
+
+// NewProduction returns an initialised logger for services: NDJSON to the
+// file at path (stdout when empty) from Info up, buffered and asynchronous
+// with Debug and Trace entries shed under load and sampled at 10% when
+// SetLevel lets them through, the caller of every entry, unserializable
+// attributes reported instead of failing the entry and startup and
+// shutdown entries. APPLOGGER_LEVELS replaces the levels when it is set.
+func NewProduction(path string) (*AppLogger, error) {
+	logger := &AppLogger{
+		Path:                path,
+		Format:              FormatJSON,
+		Levels:              presetLevels(LevelInfo),
+		Rules:               []Rule{{When: `level<=debug`, Sample: 0.1}},
+		Caller:              true,
+		BufferSize:          64 << 10,
+		Async:               true,
+		HighWaterMark:       10000,
+		ShedLevel:           LevelInfo,
+		StrictSerialization: true,
+		Lifecycle:           true,
+	}
+	if err := logger.open(); err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+// NewDevelopment returns an initialised logger for working on a service:
+// unbuffered console lines on stdout from Debug up with their caller, with
+// misuse turned into panics
+func NewDevelopment() (*AppLogger, error) {
+	logger := &AppLogger{
+		Format:      FormatConsole,
+		Levels:      presetLevels(LevelDebug),
+		Caller:      true,
+		Development: true,
+	}
+	if err := logger.open(); err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+// NewStdout returns an initialised logger for serverless functions (AWS
+// Lambda, Cloud Run): NDJSON on stdout, unbuffered so every entry is
+// written before Log returns, and without any file or background goroutine
+// that a frozen or recycled instance could lose entries in
+func NewStdout() (*AppLogger, error) {
+	logger := &AppLogger{Format: FormatJSON}
+	if err := logger.open(); err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+// presetLevels is level for every package, or nil so that open reads
+// APPLOGGER_LEVELS when it is set
+func presetLevels(level LogLevel) map[string]LogLevel {
+	if os.Getenv(LevelsEnv) != "" {
+		return nil
+	}
+	return map[string]LogLevel{"": level}
+}
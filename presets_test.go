@@ -0,0 +1,63 @@
+package applogger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewProduction(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	logger, err := NewProduction("./tmp/production.ndjson")
+	if err != nil {
+		t.Fatalf("NewProduction failed: %v", err)
+	}
+	if logger.Levels[""] != LevelInfo || !logger.Caller || len(logger.Rules) != 1 || logger.Rules[0].Sample != 0.1 || logger.Rules[0].When != `level<=debug` {
+		t.Fatalf("unexpected production logger %+v", logger)
+	}
+	logger.Log("DEBUG", "main", "app", "hidden")
+	logger.Log("INFO", "main", "app", "hello")
+	logger.Close()
+
+	b, err := os.ReadFile("./tmp/production.ndjson")
+	if err != nil {
+		t.Fatalf("Couldn't read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], `"message":"hello"`) || !strings.Contains(lines[1], `/presets_test.go:21"`) {
+		t.Fatalf("expected startup, entry and shutdown, got:\n%s", b)
+	}
+	for _, l := range lines {
+		if err := isJSON(l); err != nil {
+			t.Fatalf("%q is not json: %v", l, err)
+		}
+	}
+}
+
+func TestNewProductionBadPath(t *testing.T) {
+	if _, err := NewProduction("./does/not/exist/app.ndjson"); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
+
+func TestNewDevelopment(t *testing.T) {
+	logger, err := NewDevelopment()
+	if err != nil {
+		t.Fatalf("NewDevelopment failed: %v", err)
+	}
+	if !logger.Development || logger.format != FormatConsole || logger.Levels[""] != LevelDebug || !logger.Caller {
+		t.Fatalf("unexpected development logger %+v", logger)
+	}
+}
+
+func TestNewStdout(t *testing.T) {
+	logger, err := NewStdout()
+	if err != nil {
+		t.Fatalf("NewStdout failed: %v", err)
+	}
+	if logger.out.file != os.Stdout || logger.out.buf != nil || logger.out.stop != nil || logger.async != nil || logger.format != FormatJSON {
+		t.Fatal("expected unbuffered NDJSON on stdout without background goroutines")
+	}
+}
@@ -0,0 +1,51 @@
+package applogger
+
+import (
+	"context"
+	"fmt"
+)
+
+// Debugf writes a DEBUG entry with the message formatted by fmt.Sprintf,
+// taking attributes from the context like LogContext. Package and Func are
+// those of the caller. Nothing is formatted when the level is filtered out.
+func (r AppLogger) Debugf(ctx context.Context, format string, args ...interface{}) {
+	r.logf(ctx, LevelDebug, format, args)
+}
+
+// Infof writes an INFO entry like Debugf
+func (r AppLogger) Infof(ctx context.Context, format string, args ...interface{}) {
+	r.logf(ctx, LevelInfo, format, args)
+}
+
+// Warnf writes a WARN entry like Debugf
+func (r AppLogger) Warnf(ctx context.Context, format string, args ...interface{}) {
+	r.logf(ctx, LevelWarn, format, args)
+}
+
+// Errorf writes an ERROR entry like Debugf
+func (r AppLogger) Errorf(ctx context.Context, format string, args ...interface{}) {
+	r.logf(ctx, LevelError, format, args)
+}
+
+// Fatalf writes a FATAL entry like Debugf, closes the logger and exits the
+// process with status 1, like Fatal
+func (r AppLogger) Fatalf(ctx context.Context, format string, args ...interface{}) {
+	r.muted = false
+	r.logf(ctx, LevelFatal, format, args)
+	r.Close()
+	exit(1)
+}
+
+// logf formats and writes the entry of the helpers above. The level is
+// checked before formatting unless Escalations could still raise it.
+func (r AppLogger) logf(ctx context.Context, level LogLevel, format string, args []interface{}) {
+	logPackage, logFunc := getCallerInfo(2)
+	if r.disabled(level, logPackage) {
+		return
+	}
+
+	s1 := r.now()
+
+	e := Entry{PID: r.newID(), Level: level.String(), Package: logPackage, Func: logFunc, Message: fmt.Sprintf(format, args...), Time: s1}
+	r.logInternal(e, r.contextAttrs(ctx, s1, nil))
+}
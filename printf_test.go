@@ -0,0 +1,58 @@
+package applogger
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// countingStringer counts how often it is formatted
+type countingStringer struct{ n *int }
+
+func (c countingStringer) String() string {
+	*c.n++
+	return "formatted"
+}
+
+func TestPrintfHelpers(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, Levels: map[string]LogLevel{"": LevelInfo}}
+	logger.Initialise()
+	defer logger.Close()
+
+	ctx := WithCorrelationID(context.Background(), "abc")
+	formatted := 0
+	logger.Debugf(ctx, "skipped %s", countingStringer{&formatted})
+	logger.Infof(ctx, "user %d %s", 42, countingStringer{&formatted})
+	logger.Warnf(context.Background(), "disk at %d%%", 91)
+	logger.Errorf(ctx, "failed: %v", os.ErrNotExist)
+
+	if formatted != 1 {
+		t.Fatalf("expected only the enabled entry to be formatted, got %d", formatted)
+	}
+	if len(mem.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(mem.entries))
+	}
+	e := mem.entries[0]
+	if e.Level != "INFO" || e.Message != "user 42 formatted" || e.Func != "TestPrintfHelpers" || e.Attributes[CorrelationIDKey] != "abc" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if mem.entries[1].Message != "disk at 91%" || mem.entries[2].Level != "ERROR" || mem.entries[2].Message != "failed: file does not exist" {
+		t.Fatalf("unexpected entries %+v", mem.entries[1:])
+	}
+}
+
+func TestFatalf(t *testing.T) {
+	code := -1
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}}
+	logger.Initialise()
+	logger.Fatalf(context.Background(), "cannot open %s", "db")
+
+	if code != 1 || len(mem.entries) != 1 || mem.entries[0].Level != "FATAL" || mem.entries[0].Message != "cannot open db" {
+		t.Fatalf("unexpected exit %d and entries %+v", code, mem.entries)
+	}
+}
@@ -0,0 +1,98 @@
+package applogger
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// queueNode is a link of mpscQueue, the node at the tail is a stub whose
+// entry has already been consumed
+type queueNode struct {
+	next atomic.Pointer[queueNode]
+	e    Entry
+}
+
+// mpscQueue is an unbounded lock-free multi producer single consumer queue
+// (Vyukov's algorithm). Producers only do one atomic swap and one store, so
+// concurrent loggers never serialize on a mutex or a channel lock. Only one
+// goroutine may call pop and wait.
+type mpscQueue struct {
+	head atomic.Pointer[queueNode]
+	tail *queueNode
+	n    atomic.Int64
+
+	sleeping atomic.Bool
+	wake     chan struct{}
+}
+
+func newMPSCQueue() *mpscQueue {
+	q := &mpscQueue{wake: make(chan struct{}, 1)}
+	stub := &queueNode{}
+	q.head.Store(stub)
+	q.tail = stub
+	return q
+}
+
+// nodePool recycles consumed stubs, no producer can still reference a node
+// once the consumer has moved past it
+var nodePool = sync.Pool{New: func() interface{} { return new(queueNode) }}
+
+// push adds an entry and wakes the consumer if it is waiting
+func (q *mpscQueue) push(e Entry) {
+	n := nodePool.Get().(*queueNode)
+	n.e = e
+	n.next.Store(nil)
+	q.n.Add(1)
+	prev := q.head.Swap(n)
+	prev.next.Store(n)
+
+	if q.sleeping.Load() && q.sleeping.CompareAndSwap(true, false) {
+		q.wake <- struct{}{}
+	}
+}
+
+// pop removes the oldest entry. It returns false when the queue is empty,
+// spinning briefly when a producer is half way through a push.
+func (q *mpscQueue) pop() (Entry, bool) {
+	for {
+		next := q.tail.next.Load()
+		if next != nil {
+			stub := q.tail
+			q.tail = next
+			e := next.e
+			next.e = Entry{}
+			q.n.Add(-1)
+			nodePool.Put(stub)
+			return e, true
+		}
+		if q.n.Load() == 0 {
+			return Entry{}, false
+		}
+		runtime.Gosched()
+	}
+}
+
+// len returns the number of queued entries
+func (q *mpscQueue) len() int {
+	return int(q.n.Load())
+}
+
+// wait blocks until an entry may be available or done is closed
+func (q *mpscQueue) wait(done <-chan struct{}) {
+	q.sleeping.Store(true)
+	if q.n.Load() > 0 {
+		if !q.sleeping.CompareAndSwap(true, false) {
+			// a producer already took the wake up, consume it
+			<-q.wake
+		}
+		return
+	}
+	select {
+	case <-q.wake:
+	case <-done:
+		if !q.sleeping.CompareAndSwap(true, false) {
+			<-q.wake
+		}
+	}
+}
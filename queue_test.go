@@ -0,0 +1,129 @@
+package applogger
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestMPSCQueue(t *testing.T) {
+	q := newMPSCQueue()
+	const producers, perProducer = 8, 1000
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				q.push(Entry{Package: strconv.Itoa(p), Code: i})
+			}
+		}(p)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	last := make(map[string]int)
+	received := 0
+	for received < producers*perProducer {
+		e, ok := q.pop()
+		if !ok {
+			q.wait(done)
+			continue
+		}
+		if prev, seen := last[e.Package]; seen && e.Code != prev+1 {
+			t.Fatalf("producer %s out of order: %d after %d", e.Package, e.Code, prev)
+		}
+		last[e.Package] = e.Code
+		received++
+	}
+	if q.len() != 0 {
+		t.Fatalf("queue not empty after consuming everything: %d", q.len())
+	}
+}
+
+func TestAsyncLogger(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Async: true, Sinks: []Sink{mem}}
+	logger.Initialise()
+
+	var wg sync.WaitGroup
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				logger.Log("INFO", "main", "app", "async")
+			}
+		}()
+	}
+	wg.Wait()
+	logger.Close()
+
+	if len(mem.entries) != 400 {
+		t.Fatalf("expected 400 entries after close, got %d", len(mem.entries))
+	}
+}
+
+// BenchmarkQueue compares the lock-free queue with a buffered channel
+// under 8 to 64 concurrent producers
+func BenchmarkQueue(b *testing.B) {
+	for _, producers := range []int{8, 16, 32, 64} {
+		b.Run(fmt.Sprintf("mpsc/producers=%d", producers), func(b *testing.B) {
+			q := newMPSCQueue()
+			stop := make(chan struct{})
+			consumed := make(chan struct{})
+			go func() {
+				defer close(consumed)
+				for {
+					if _, ok := q.pop(); !ok {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						q.wait(stop)
+					}
+				}
+			}()
+			benchmarkProducers(b, producers, func(e Entry) { q.push(e) })
+			close(stop)
+			<-consumed
+		})
+		b.Run(fmt.Sprintf("channel/producers=%d", producers), func(b *testing.B) {
+			ch := make(chan Entry, 4096)
+			consumed := make(chan struct{})
+			go func() {
+				defer close(consumed)
+				for range ch {
+				}
+			}()
+			benchmarkProducers(b, producers, func(e Entry) { ch <- e })
+			close(ch)
+			<-consumed
+		})
+	}
+}
+
+func benchmarkProducers(b *testing.B, producers int, push func(e Entry)) {
+	b.ReportAllocs()
+	per := b.N/producers + 1
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e := Entry{Level: "INFO", Message: "benchmark"}
+			for i := 0; i < per; i++ {
+				push(e)
+			}
+		}()
+	}
+	wg.Wait()
+}
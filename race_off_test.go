@@ -0,0 +1,5 @@
+//go:build !race
+
+package applogger
+
+const raceEnabled = false
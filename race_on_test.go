@@ -0,0 +1,7 @@
+//go:build race
+
+package applogger
+
+// raceEnabled is set when the tests run with the race detector, which
+// allocates on its own and breaks testing.AllocsPerRun
+const raceEnabled = true
@@ -0,0 +1,194 @@
+package applogger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// LogEntry is an entry read back from an ndjson log
+type LogEntry struct {
+	PID        string                 `json:"pid"`
+	Level      string                 `json:"level"`
+	Package    string                 `json:"package"`
+	Func       string                 `json:"func"`
+	Message    string                 `json:"message"`
+	Time       time.Time              `json:"time"`
+	Code       int                    `json:"code,omitempty"`
+	Duration   float64                `json:"duration,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Field returns a value of the entry by name: pid, level, package, func,
+// message, time, code, duration or attributes.<key>
+func (e LogEntry) Field(name string) (interface{}, bool) {
+	switch name {
+	case "pid":
+		return e.PID, true
+	case "level":
+		return e.Level, true
+	case "package":
+		return e.Package, true
+	case "func":
+		return e.Func, true
+	case "message":
+		return e.Message, true
+	case "time":
+		return e.Time, true
+	case "code":
+		// code and duration are only written for HTTP entries
+		return e.Code, e.Code != 0
+	case "duration":
+		return e.Duration, e.Code != 0
+	}
+	if key := strings.TrimPrefix(name, "attributes."); key != name {
+		v, ok := e.Attributes[key]
+		return v, ok
+	}
+	return nil, false
+}
+
+// MaxLineSize is the longest line a Reader decodes, longer lines are
+// corrupt
+const MaxLineSize = 16 * 1024 * 1024
+
+// CorruptLineError reports a line of the log that is not an entry: a
+// partial line cut by a crash, entries interleaved by concurrent writers,
+// a line longer than MaxLineSize or anything else that is not a JSON object
+type CorruptLineError struct {
+	Line int
+	Raw  []byte
+	Err  error
+}
+
+func (e *CorruptLineError) Error() string {
+	return fmt.Sprintf("applogger: line %d: %v", e.Line, e.Err)
+}
+
+func (e *CorruptLineError) Unwrap() error {
+	return e.Err
+}
+
+// errLineTooLong is the error of lines longer than MaxLineSize
+var errLineTooLong = errors.New("line longer than MaxLineSize")
+
+// Reader reads entries from an ndjson log
+type Reader struct {
+	reader  *bufio.Reader
+	raw     []byte
+	line    int
+	filter  *Filter
+	skip    func(*CorruptLineError)
+	skipped int
+	maxLine int
+}
+
+// NewReader returns a reader of the ndjson entries of r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{reader: bufio.NewReaderSize(r, 64*1024), maxLine: MaxLineSize}
+}
+
+// SetFilter makes Next skip entries not matching f, nil removes the filter
+func (r *Reader) SetFilter(f *Filter) {
+	r.filter = f
+}
+
+// SkipCorrupt makes Next skip corrupt lines instead of returning a
+// *CorruptLineError, report is called with each of them when not nil
+func (r *Reader) SkipCorrupt(report func(*CorruptLineError)) {
+	r.skip = report
+	if report == nil {
+		r.skip = func(*CorruptLineError) {}
+	}
+}
+
+// Skipped returns the number of corrupt lines skipped so far
+func (r *Reader) Skipped() int {
+	return r.skipped
+}
+
+// Line returns the raw bytes of the entry last returned by Next, they are
+// only valid until the next call
+func (r *Reader) Line() []byte {
+	return r.raw
+}
+
+// Next returns the next entry, io.EOF after the last one. Blank lines and
+// entries not matching the filter are skipped. A corrupt line is returned
+// as a *CorruptLineError, reading can go on with the following line.
+func (r *Reader) Next() (LogEntry, error) {
+	for {
+		line, err := r.readLine()
+		if err != nil && err != errLineTooLong {
+			r.raw = nil
+			return LogEntry{}, err
+		}
+		r.line++
+		r.raw = line
+		var e LogEntry
+		if err == nil {
+			trimmed := bytes.TrimSpace(line)
+			if len(trimmed) == 0 {
+				continue
+			}
+			if trimmed[0] != '{' {
+				err = errors.New("not a JSON object")
+			} else {
+				err = json.Unmarshal(trimmed, &e)
+			}
+		}
+		if err != nil {
+			corrupt := &CorruptLineError{Line: r.line, Raw: append([]byte(nil), line...), Err: err}
+			if r.skip == nil {
+				return LogEntry{}, corrupt
+			}
+			r.skipped++
+			r.skip(corrupt)
+			continue
+		}
+		if r.filter != nil && !r.filter.Match(e) {
+			continue
+		}
+		return e, nil
+	}
+}
+
+// readLine returns the next line without its line ending. A last line
+// without a newline is returned as is, Next reports it when it is partial.
+// A line longer than maxLine is consumed and returned cut with
+// errLineTooLong.
+func (r *Reader) readLine() ([]byte, error) {
+	r.raw = r.raw[:0]
+	long := false
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+		if !long {
+			if len(r.raw)+len(chunk) > r.maxLine+1 {
+				long = true
+			} else {
+				r.raw = append(r.raw, chunk...)
+			}
+		}
+		switch err {
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			if len(r.raw) == 0 && !long {
+				return nil, io.EOF
+			}
+		case nil:
+		default:
+			return nil, err
+		}
+		line := bytes.TrimSuffix(bytes.TrimSuffix(r.raw, []byte("\n")), []byte("\r"))
+		if long {
+			return line, errLineTooLong
+		}
+		return line, nil
+	}
+}
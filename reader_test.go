@@ -0,0 +1,90 @@
+package applogger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReaderCorruptLines(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(testLog), "\n")
+	input := lines[0] + "\n" +
+		`{"pid":"x","level":"INFO","mess` + "\n" + // partial line of a crash
+		lines[0] + lines[1] + "\n" + // interleaved writes
+		"null\n" +
+		`{"pid":"3","message":"caf` + "\xff\xfe" + `"}` + "\n" + // invalid UTF-8 is replaced
+		strings.Repeat("x", 100) + "\n" +
+		lines[1] + "\n" +
+		`{"pid":"4"` // partial last line
+
+	r := NewReader(strings.NewReader(input))
+	if _, err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := r.Next()
+	var corrupt *CorruptLineError
+	if !errors.As(err, &corrupt) || corrupt.Line != 2 || !bytes.HasPrefix(corrupt.Raw, []byte(`{"pid":"x"`)) {
+		t.Fatalf("expected corrupt line 2, got %v", err)
+	}
+
+	var reported []int
+	r.SkipCorrupt(func(c *CorruptLineError) { reported = append(reported, c.Line) })
+	var pids []string
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		pids = append(pids, e.PID)
+	}
+	if strings.Join(pids, ",") != "3,2" {
+		t.Fatalf("unexpected entries %v", pids)
+	}
+	if len(reported) != 4 || reported[0] != 3 || reported[3] != 8 || r.Skipped() != 4 {
+		t.Fatalf("unexpected reported lines %v, skipped %d", reported, r.Skipped())
+	}
+}
+
+func TestReaderLongLine(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(testLog), "\n")
+	input := `{"message":"` + strings.Repeat("a", 200<<10) + "\"}\n" + lines[0] + "\n"
+	r := NewReader(strings.NewReader(input))
+	r.maxLine = 100 << 10
+	r.SkipCorrupt(nil)
+	e, err := r.Next()
+	if err != nil || e.PID != "1" || r.Skipped() != 1 {
+		t.Fatalf("unexpected entry %v %v, skipped %d", e, err, r.Skipped())
+	}
+}
+
+func FuzzReader(f *testing.F) {
+	f.Add([]byte(testLog))
+	f.Add([]byte(`{"pid":"1"}{"pid":"2"}` + "\n"))
+	f.Add([]byte("{\"message\":\"\xff\"}\r\n\n"))
+	f.Add([]byte(`{"time":"yesterday"}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		valid := strings.Split(strings.TrimSpace(testLog), "\n")[0]
+		r := NewReader(io.MultiReader(bytes.NewReader(data), strings.NewReader("\n"+valid+"\n")))
+		r.SkipCorrupt(nil)
+		var last LogEntry
+		for {
+			e, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			last = e
+		}
+		// the dirty input never swallows the entry written after it
+		if last.PID != "1" || last.Message != "started" {
+			t.Fatalf("entry after the input lost, got %+v", last)
+		}
+	})
+}
@@ -0,0 +1,273 @@
+package applogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SinkFactory builds a sink from the options of a config file entry
+type SinkFactory func(options map[string]interface{}) (Sink, error)
+
+var (
+	sinksMu   sync.RWMutex
+	factories = make(map[string]SinkFactory)
+)
+
+// RegisterSink makes a sink available by name to config files, it is meant
+// to be called from the init function of the package providing the sink.
+// Registering the same name twice or a nil factory panics.
+func RegisterSink(name string, factory SinkFactory) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	if factory == nil {
+		panic("applogger: RegisterSink factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("applogger: RegisterSink called twice for sink " + name)
+	}
+	factories[name] = factory
+}
+
+// NewSink builds the sink registered under name
+func NewSink(name string, options map[string]interface{}) (Sink, error) {
+	sinksMu.RLock()
+	factory, ok := factories[name]
+	sinksMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("applogger: unknown sink %q (forgotten import?)", name)
+	}
+	return factory(options)
+}
+
+// Sinks returns the sorted names of the registered sinks
+func Sinks() []string {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterSink("stdout", func(options map[string]interface{}) (Sink, error) {
+		enc, err := encoderOption(options)
+		if err != nil {
+			return nil, err
+		}
+		return NewWriterSink(nopCloser{os.Stdout}, enc), nil
+	})
+	RegisterSink("stderr", func(options map[string]interface{}) (Sink, error) {
+		enc, err := encoderOption(options)
+		if err != nil {
+			return nil, err
+		}
+		return NewWriterSink(nopCloser{os.Stderr}, enc), nil
+	})
+	RegisterSink("file", func(options map[string]interface{}) (Sink, error) {
+		path, _ := options["path"].(string)
+		if path == "" {
+			return nil, fmt.Errorf("applogger: file sink needs a path")
+		}
+		enc, err := encoderOption(options)
+		if err != nil {
+			return nil, err
+		}
+		min, err := levelOption(options, "min_level", LevelTrace)
+		if err != nil {
+			return nil, err
+		}
+		max, err := levelOption(options, "max_level", LevelFatal)
+		if err != nil {
+			return nil, err
+		}
+		f, err := openLogFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var w io.Writer = f
+		if maxSize, _ := options["max_size"].(float64); maxSize > 0 {
+			maxBackups, _ := options["max_backups"].(float64)
+			compress, _ := options["compress_rotated"].(bool)
+			fw := newFileWriter(f, true, 0, 0)
+			if err := fw.rotateSize(path, int64(maxSize), nil); err != nil {
+				fw.Close()
+				return nil, err
+			}
+			if compress || maxBackups > 0 {
+				fw.cleanRotated(compress, 0, int(maxBackups))
+			}
+			w = fw
+		}
+		var s Sink = NewWriterSink(w, enc)
+		if min > LevelTrace || max < LevelFatal {
+			s = levelRangeSink{Sink: s, min: min, max: max}
+		}
+		return s, nil
+	})
+	RegisterSink("http", func(options map[string]interface{}) (Sink, error) {
+		url, _ := options["url"].(string)
+		if url == "" {
+			return nil, fmt.Errorf("applogger: http sink needs a url")
+		}
+		enc, err := encoderOption(options)
+		if err != nil {
+			return nil, err
+		}
+		batch, err := batchOption(options)
+		if err != nil {
+			return nil, err
+		}
+		s := NewHTTPSink(url)
+		s.Encoder = enc
+		s.Batch = batch
+		s.Compression, _ = options["compression"].(string)
+		if s.Compression != "" && s.Compression != "gzip" {
+			return nil, fmt.Errorf("applogger: unsupported compression %q", s.Compression)
+		}
+		if s.TLS, err = tlsOption(options); err != nil {
+			return nil, err
+		}
+		if s.Auth, err = authOption(options); err != nil {
+			return nil, err
+		}
+		if s.Proxy, _ = options["proxy"].(string); s.Proxy != "" {
+			if _, err := parseProxy(s.Proxy); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	})
+	RegisterSink("split", func(options map[string]interface{}) (Sink, error) {
+		key, _ := options["key"].(string)
+		pattern, _ := options["path"].(string)
+		if key == "" || !strings.Contains(pattern, "{value}") {
+			return nil, fmt.Errorf("applogger: split sink needs a key and a path containing {value}")
+		}
+		enc, err := encoderOption(options)
+		if err != nil {
+			return nil, err
+		}
+		maxOpen, _ := options["max_open"].(float64)
+		s := NewSplitSink(key, pattern, int(maxOpen))
+		s.Missing, _ = options["missing"].(string)
+		s.Encoder = enc
+		return s, nil
+	})
+}
+
+// encoderOption reads the "encoder" option, wrapped in a TransformEncoder
+// when there is a "transforms" option
+func encoderOption(options map[string]interface{}) (Encoder, error) {
+	enc, err := namedEncoder(options)
+	if err != nil {
+		return nil, err
+	}
+	transforms, err := transformsOption(options)
+	if err != nil || transforms == nil {
+		return enc, err
+	}
+	return TransformEncoder{Encoder: enc, Transforms: transforms}, nil
+}
+
+// namedEncoder returns the encoder named by the "encoder" option (json,
+// console, with "diff" to show the changes between the entries of a
+// request, ecs, otel or gcp, with the project in "gcp_project")
+func namedEncoder(options map[string]interface{}) (Encoder, error) {
+	name, _ := options["encoder"].(string)
+	switch name {
+	case "", "json", "ndjson":
+		return JSONEncoder{}, nil
+	case "console":
+		var enc ConsoleEncoder
+		if diff, _ := options["diff"].(bool); diff {
+			enc.Diff = NewConsoleDiff()
+		}
+		return enc, nil
+	case "ecs":
+		return ECSEncoder{}, nil
+	case "otel":
+		return OTelEncoder{}, nil
+	case "gcp":
+		project, _ := options["gcp_project"].(string)
+		return GCPEncoder{ProjectID: project}, nil
+	}
+	return nil, fmt.Errorf("applogger: unknown encoder %q", name)
+}
+
+// batchOption reads the max_entries, max_bytes and max_latency (a duration
+// like "500ms") options of a batching sink
+func batchOption(options map[string]interface{}) (BatchConfig, error) {
+	var b BatchConfig
+	entries, _ := options["max_entries"].(float64)
+	bytes, _ := options["max_bytes"].(float64)
+	b.MaxEntries, b.MaxBytes = int(entries), int(bytes)
+	if latency, _ := options["max_latency"].(string); latency != "" {
+		d, err := time.ParseDuration(latency)
+		if err != nil {
+			return b, fmt.Errorf("applogger: max_latency: %w", err)
+		}
+		b.MaxLatency = d
+	}
+	return b, nil
+}
+
+// tlsOption reads the "tls" option, an object with the fields of
+// TLSConfig, and checks it can be built
+func tlsOption(options map[string]interface{}) (*TLSConfig, error) {
+	raw, ok := options["tls"]
+	if !ok {
+		return nil, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var c TLSConfig
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("applogger: tls: %w", err)
+	}
+	if _, err := c.Build(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// levelOption reads a level option, def when it is not set
+func levelOption(options map[string]interface{}, key string, def LogLevel) (LogLevel, error) {
+	name, _ := options[key].(string)
+	if name == "" {
+		return def, nil
+	}
+	return ParseLevel(name)
+}
+
+// levelRangeSink only passes entries from min to max on, so a file sink
+// can hold only the errors ("min_level": "error") or everything else
+// ("max_level": "warn")
+type levelRangeSink struct {
+	Sink
+	min, max LogLevel
+}
+
+func (s levelRangeSink) Write(e Entry) error {
+	if l := levelOf(e.Level); l < s.min || l > s.max {
+		return nil
+	}
+	return s.Sink.Write(e)
+}
+
+// nopCloser keeps WriterSink.Close from closing the standard streams
+type nopCloser struct {
+	*os.File
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,127 @@
+package applogger
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	entries []Entry
+	closed  bool
+}
+
+func (m *memorySink) Write(e Entry) error {
+	m.mu.Lock()
+	m.entries = append(m.entries, e)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memorySink) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+func (m *memorySink) Close() error {
+	m.closed = true
+	return nil
+}
+
+var registerTestSink sync.Once
+
+func TestRegisterSink(t *testing.T) {
+	mem := &memorySink{}
+	registerTestSink.Do(func() {
+		RegisterSink("test-memory", func(options map[string]interface{}) (Sink, error) {
+			return mem, nil
+		})
+	})
+
+	found := false
+	for _, name := range Sinks() {
+		found = found || name == "test-memory"
+	}
+	if !found {
+		t.Fatal("registered sink is not listed")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on duplicate registration")
+		}
+	}()
+	RegisterSink("test-memory", func(options map[string]interface{}) (Sink, error) { return mem, nil })
+}
+
+func TestNewFromConfig(t *testing.T) {
+	directoryPath := "./tmp"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	configPath := directoryPath + "/config.json"
+	config := `{
+		"path": "./tmp/main.ndjson",
+		"format": "json",
+		"sinks": [{"type": "file", "options": {"path": "./tmp/console.log", "encoder": "console"}}]
+	}`
+	if err := os.WriteFile(configPath, []byte(config), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Log("INFO", "main", "app", "from config")
+
+	b, _ := os.ReadFile(directoryPath + "/console.log")
+	if !strings.Contains(string(b), "INFO  main.app from config") {
+		t.Fatalf("file sink did not receive the entry: %q", b)
+	}
+
+	if _, err := NewFromConfig(Config{Sinks: []SinkConfig{{Type: "nope"}}}); err == nil {
+		t.Fatal("expected error for an unknown sink")
+	}
+}
+
+func TestMustNewFromConfig(t *testing.T) {
+	logger := MustNewFromConfig(Config{Path: "/dev/null"})
+	logger.Close()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for an unknown sink")
+		}
+	}()
+	MustNewFromConfig(Config{Sinks: []SinkConfig{{Type: "nope"}}})
+}
+
+func TestFileSinkLevelRange(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	s, err := NewSink("file", map[string]interface{}{"path": "./tmp/errors.log", "min_level": "error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write(Entry{Level: "WARN", Message: "skipped"})
+	s.Write(Entry{Level: "ERROR", Message: "kept"})
+	s.Close()
+
+	b, _ := os.ReadFile("./tmp/errors.log")
+	if strings.Count(string(b), "\n") != 1 || !strings.Contains(string(b), "kept") {
+		t.Fatalf("expected only the error, got %q", b)
+	}
+
+	if _, err := NewSink("file", map[string]interface{}{"path": "./tmp/x.log", "min_level": "loud"}); err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+}
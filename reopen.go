@@ -0,0 +1,53 @@
+package applogger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// Reopen closes the files at Path and ErrorPath and opens the files now at
+// those paths, for logrotate and other tools that move or truncate the log
+// from outside the process. Entries written meanwhile go to the old file
+// until Reopen returns. When a file cannot be opened the old one is kept.
+func (r AppLogger) Reopen() error {
+	var err error
+	if r.Path != "" {
+		err = r.out.reopen(r.Path)
+	}
+	if r.ErrorPath != "" && r.errOut != nil {
+		if rerr := r.errOut.reopen(r.ErrorPath); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// reopener calls Reopen on every reopen signal until stop is called
+type reopener struct {
+	signals chan os.Signal
+	done    chan struct{}
+}
+
+func listenReopen(r AppLogger) *reopener {
+	if reopenSignal == nil {
+		return nil
+	}
+	o := &reopener{signals: make(chan os.Signal, 1), done: make(chan struct{})}
+	signal.Notify(o.signals, reopenSignal)
+	go func() {
+		defer close(o.done)
+		for range o.signals {
+			if err := r.Reopen(); err != nil {
+				fmt.Fprintf(os.Stderr, "applogger: reopening %s: %v\n", r.Path, err)
+			}
+		}
+	}()
+	return o
+}
+
+func (o *reopener) stop() {
+	signal.Stop(o.signals)
+	close(o.signals)
+	<-o.done
+}
@@ -0,0 +1,86 @@
+package applogger
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// OriginalTimeKey is the attribute holding the time of a replayed entry
+// when it was first logged
+const OriginalTimeKey = "original_time"
+
+// ReplayOptions tunes Replay
+type ReplayOptions struct {
+	// Filter skips entries not matching it
+	Filter *Filter
+	// Speed paces the replay relative to the original gaps between
+	// entries, 1 is real time and 10 ten times faster. Zero replays as
+	// fast as the sink accepts entries.
+	Speed float64
+}
+
+// Replay re-emits the entries of the ndjson log r into the sink, for
+// backfilling a new log backend or reproducing an incident. Entries keep
+// their pid and attributes, get the current time and carry their original
+// time in the original_time attribute. It returns the number of entries
+// written.
+func Replay(ctx context.Context, r io.Reader, s Sink, opts ReplayOptions) (int, error) {
+	reader := NewReader(r)
+	reader.SetFilter(opts.Filter)
+
+	var previous time.Time
+	n := 0
+	for {
+		le, err := reader.Next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		if opts.Speed > 0 && !previous.IsZero() {
+			if gap := le.Time.Sub(previous); gap > 0 {
+				t := time.NewTimer(time.Duration(float64(gap) / opts.Speed))
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return n, ctx.Err()
+				case <-t.C:
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		previous = le.Time
+
+		if err := s.Write(replayEntry(le)); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// replayEntry turns an entry read back from a log into one for a sink
+func replayEntry(le LogEntry) Entry {
+	attributes := make(map[string]interface{}, len(le.Attributes)+1)
+	for k, v := range le.Attributes {
+		attributes[k] = v
+	}
+	attributes[OriginalTimeKey] = le.Time.Format(time.RFC3339Nano)
+
+	return Entry{
+		PID:        le.PID,
+		Level:      le.Level,
+		Package:    le.Package,
+		Func:       le.Func,
+		Message:    le.Message,
+		Time:       time.Now(),
+		HTTP:       le.Code != 0,
+		Code:       le.Code,
+		Duration:   le.Duration,
+		Attributes: attributes,
+	}
+}
@@ -0,0 +1,34 @@
+package applogger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	mem := &memorySink{}
+	f, _ := ParseFilter(`level==error`)
+	n, err := Replay(context.Background(), strings.NewReader(testLog), mem, ReplayOptions{Filter: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || len(mem.entries) != 1 {
+		t.Fatalf("expected 1 replayed entry, got %d", n)
+	}
+	e := mem.entries[0]
+	if e.PID != "2" || !e.HTTP || e.Code != 500 || e.Attributes[OriginalTimeKey] != "2020-08-23T10:00:01Z" {
+		t.Fatalf("unexpected replayed entry %+v", e)
+	}
+}
+
+func TestReplayPacedCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the second entry is a second after the first, real time pacing
+	// has to wait for it and notices the cancelled context
+	n, err := Replay(ctx, strings.NewReader(testLog), &memorySink{}, ReplayOptions{Speed: 1})
+	if err != context.Canceled || n != 0 {
+		t.Fatalf("expected cancellation before any write, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,165 @@
+package applogger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetentionKey is the attribute holding the retention class of an entry,
+// for downstream retention jobs and RetentionPeriods
+const RetentionKey = "retention"
+
+// Retention classes, any other name works as well
+const (
+	RetentionShort    = "short"
+	RetentionStandard = "standard"
+	RetentionAudit    = "audit"
+)
+
+// RetentionInterval is how often the rotated files of a logger with
+// RetentionPeriods are checked
+var RetentionInterval = time.Minute
+
+// retentionSweeper removes the rotated files of the log at path once they
+// are older than the period of every retention class they hold. Rotated
+// files do not change, so each is read once to find its classes.
+type retentionSweeper struct {
+	path      string
+	errorPath string
+	periods   map[string]time.Duration
+
+	mu      sync.Mutex
+	classes map[string]fileClasses
+	now     func() time.Time
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// fileClasses are the retention classes found in a rotated file, valid
+// while its size and modification time are unchanged
+type fileClasses struct {
+	size    int64
+	modTime time.Time
+	classes map[string]bool
+}
+
+func newRetentionSweeper(path, errorPath string, periods map[string]time.Duration) *retentionSweeper {
+	s := &retentionSweeper{path: path, errorPath: errorPath, periods: periods, classes: make(map[string]fileClasses), now: time.Now,
+		stop: make(chan struct{}), done: make(chan struct{})}
+	s.sweep()
+	go s.run()
+	return s
+}
+
+func (s *retentionSweeper) run() {
+	defer close(s.done)
+	t := time.NewTicker(RetentionInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			s.sweep()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// sweep removes the rotated files whose retention has expired. A file
+// holding a class without a period, or that cannot be read, is kept.
+func (s *retentionSweeper) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	seen := make(map[string]bool)
+	for _, path := range rotatedFiles(s.path, s.errorPath) {
+		seen[path] = true
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		fc, ok := s.classes[path]
+		if !ok || fc.size != fi.Size() || !fc.modTime.Equal(fi.ModTime()) {
+			classes, err := s.read(path)
+			if err != nil {
+				continue
+			}
+			fc = fileClasses{size: fi.Size(), modTime: fi.ModTime(), classes: classes}
+			s.classes[path] = fc
+		}
+		if keep, ok := s.keep(fc.classes); ok && now.Sub(fi.ModTime()) > keep {
+			if os.Remove(path) == nil {
+				os.Remove(path + IndexSuffix)
+				delete(s.classes, path)
+			}
+		}
+	}
+	for path := range s.classes {
+		if !seen[path] {
+			delete(s.classes, path)
+		}
+	}
+}
+
+// read returns the retention classes of the entries of a rotated file,
+// entries without one, and files without entries, count as
+// RetentionStandard
+func (s *retentionSweeper) read(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rd io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		rd = gz
+	}
+	classes := make(map[string]bool)
+	reader := NewReader(rd)
+	reader.SkipCorrupt(nil)
+	for {
+		e, err := reader.Next()
+		if err != nil {
+			break
+		}
+		class, ok := e.String(RetentionKey)
+		if !ok {
+			class = RetentionStandard
+		}
+		classes[class] = true
+	}
+	if len(classes) == 0 {
+		classes[RetentionStandard] = true
+	}
+	return classes, nil
+}
+
+// keep returns the longest period of the classes, false when one of them
+// has no period and is kept forever
+func (s *retentionSweeper) keep(classes map[string]bool) (time.Duration, bool) {
+	var keep time.Duration
+	for class := range classes {
+		period, ok := s.periods[class]
+		if !ok {
+			return 0, false
+		}
+		if period > keep {
+			keep = period
+		}
+	}
+	return keep, true
+}
+
+func (s *retentionSweeper) Close() {
+	close(s.stop)
+	<-s.done
+}
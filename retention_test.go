@@ -0,0 +1,90 @@
+package applogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRetentionClass(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, Retention: RetentionStandard, Rules: []Rule{
+		{When: `package=="audit"`, Retention: RetentionAudit},
+	}}
+	logger.Initialise()
+	defer logger.Close()
+
+	logger.Log("INFO", "main", "app", "standard")
+	logger.Log("INFO", "audit", "app", "audit")
+	logger.LogFields("DEBUG", "main", "app", "short", map[string]interface{}{RetentionKey: RetentionShort})
+
+	for i, want := range []string{RetentionStandard, RetentionAudit, RetentionShort} {
+		if class, _ := mem.entries[i].Lookup(RetentionKey); class != want {
+			t.Fatalf("entry %d has class %v, want %s", i, class, want)
+		}
+	}
+}
+
+func TestRetentionSweep(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/retain.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	files := map[string]string{
+		"retain.1.ndjson": `{"message":"a","attributes":{"retention":"short"}}`,
+		"retain.2.ndjson": `{"message":"a","attributes":{"retention":"short"}}` + "\n" + `{"message":"b"}`,
+		"retain.3.ndjson": `{"message":"a","attributes":{"retention":"audit"}}`,
+		"retain.4.ndjson": `{"message":"b"}`,
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	for name, content := range files {
+		os.WriteFile(directoryPath+"/"+name, []byte(content+"\n"), 0666)
+		os.Chtimes(directoryPath+"/"+name, old, old)
+	}
+
+	logger := AppLogger{Path: filePath, RetentionPeriods: map[string]time.Duration{RetentionShort: time.Hour, RetentionStandard: 72 * time.Hour}}
+	logger.Initialise()
+	defer logger.Close()
+
+	// short only: expired; short and standard: standard keeps it; audit:
+	// no period, kept forever; standard: not expired yet
+	for name, kept := range map[string]bool{"retain.1.ndjson": false, "retain.2.ndjson": true, "retain.3.ndjson": true, "retain.4.ndjson": true} {
+		if fileExists(directoryPath+"/"+name) != kept {
+			t.Fatalf("%s kept = %v, want %v", name, !kept, kept)
+		}
+	}
+
+	logger.retention.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	logger.retention.sweep()
+	if fileExists(directoryPath+"/retain.2.ndjson") || fileExists(directoryPath+"/retain.4.ndjson") || !fileExists(directoryPath+"/retain.3.ndjson") {
+		t.Fatal("standard files not removed after their period")
+	}
+}
+
+func TestRetentionKeepsErrorPath(t *testing.T) {
+	directoryPath := "./tmp"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{"app.error.ndjson", "app.7.ndjson", "app.1.ndjson", "app.2024-01-02T10-00.ndjson.gz", "app.backup.ndjson"} {
+		os.WriteFile(directoryPath+"/"+name, []byte(`{"message":"a","attributes":{"retention":"short"}}`+"\n"), 0666)
+		os.Chtimes(directoryPath+"/"+name, old, old)
+	}
+	if got := rotatedFiles(directoryPath+"/app.ndjson", directoryPath+"/app.7.ndjson"); len(got) != 2 {
+		t.Fatalf("expected only the rotated files, got %v", got)
+	}
+
+	for _, errorPath := range []string{"app.error.ndjson", "app.7.ndjson"} {
+		logger := AppLogger{Path: directoryPath + "/app.ndjson", ErrorPath: directoryPath + "/" + errorPath, RetentionPeriods: map[string]time.Duration{RetentionShort: time.Hour}}
+		logger.Initialise()
+		logger.Close()
+		if !fileExists(directoryPath + "/" + errorPath) {
+			t.Fatalf("retention removed the live ErrorPath %s", errorPath)
+		}
+	}
+	if fileExists(directoryPath+"/app.1.ndjson") || !fileExists(directoryPath+"/app.backup.ndjson") {
+		t.Fatal("expected only the rotated file to be removed")
+	}
+}
@@ -0,0 +1,134 @@
+package applogger
+
+import (
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of a RetryPolicy
+const (
+	DefaultRetryAttempts   = 3
+	DefaultRetryBackoff    = 100 * time.Millisecond
+	DefaultRetryMaxBackoff = 5 * time.Second
+)
+
+// RetryPolicy retries failed writes of remote sinks with exponential
+// backoff. The delay before attempt n+1 is Backoff*2^(n-1), capped at
+// MaxBackoff, moved randomly by up to Jitter (a fraction, 0.2 is ±20%) so
+// many processes losing the same backend do not retry in lockstep. An
+// entry still failing after MaxAttempts is dropped and counted, see
+// Dropped.
+//
+// A policy can be shared by several sinks, a nil policy tries once.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Jitter      float64
+	// Retryable classifies errors, by default everything but errors
+	// wrapped with Permanent is retried
+	Retryable func(err error) bool
+
+	dropped atomic.Uint64
+	sleep   func(d time.Duration)
+}
+
+// permanentError marks an error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (p permanentError) Error() string { return p.err.Error() }
+func (p permanentError) Unwrap() error { return p.err }
+
+// Permanent wraps err so the default classification does not retry it,
+// e.g. for a payload the backend rejected
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// Do calls f until it succeeds, returns an error that is not retryable or
+// runs out of attempts
+func (p *RetryPolicy) Do(f func() error) error {
+	err := p.do(f, nil)
+	if err != nil && p != nil {
+		p.dropped.Add(1)
+	}
+	return err
+}
+
+// do is Do calling attempt after every call of f with the attempt number,
+// its error and the wait before the next attempt, 0 after the last one
+func (p *RetryPolicy) do(f func() error, attempt func(n int, err error, backoff time.Duration)) error {
+	if p == nil {
+		err := f()
+		if attempt != nil {
+			attempt(1, err, 0)
+		}
+		return err
+	}
+	attempts := p.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultRetryAttempts
+	}
+
+	for n := 1; ; n++ {
+		err := f()
+		if err == nil || n >= attempts || !p.retryable(err) {
+			if attempt != nil {
+				attempt(n, err, 0)
+			}
+			return err
+		}
+		d := p.delay(n)
+		if attempt != nil {
+			attempt(n, err, d)
+		}
+		sleep := p.sleep
+		if sleep == nil {
+			sleep = time.Sleep
+		}
+		sleep(d)
+	}
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	var perm permanentError
+	return !errors.As(err, &perm)
+}
+
+// delay returns the wait after the n-th failed attempt
+func (p *RetryPolicy) delay(n int) time.Duration {
+	backoff, max := p.Backoff, p.MaxBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	if max <= 0 {
+		max = DefaultRetryMaxBackoff
+	}
+	d := backoff
+	for i := 1; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// Dropped returns the number of entries given up after exhausting the
+// retries or failing with an error that is not retryable
+func (p *RetryPolicy) Dropped() uint64 {
+	if p == nil {
+		return 0
+	}
+	return p.dropped.Load()
+}
@@ -0,0 +1,74 @@
+package applogger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var delays []time.Duration
+	p := &RetryPolicy{MaxAttempts: 4, Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	p.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	calls := 0
+	err := p.Do(func() error {
+		calls++
+		return errors.New("unavailable")
+	})
+	if err == nil || calls != 4 || p.Dropped() != 1 {
+		t.Fatalf("expected 4 attempts and a drop, got %d attempts, %d drops, %v", calls, p.Dropped(), err)
+	}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Fatalf("expected delays %v, got %v", want, delays)
+		}
+	}
+
+	calls = 0
+	p.Do(func() error {
+		calls++
+		return Permanent(errors.New("rejected"))
+	})
+	if calls != 1 || p.Dropped() != 2 {
+		t.Fatalf("permanent errors should not be retried, got %d attempts", calls)
+	}
+
+	calls = 0
+	if err := p.Do(func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	}); err != nil || calls != 3 {
+		t.Fatalf("expected success on the third attempt, got %d attempts, %v", calls, err)
+	}
+}
+
+func TestRetryJitter(t *testing.T) {
+	p := &RetryPolicy{Backoff: time.Second, MaxBackoff: time.Minute, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		if d := p.delay(2); d < 1600*time.Millisecond || d > 2400*time.Millisecond {
+			t.Fatalf("delay %v outside ±20%% of 2s", d)
+		}
+	}
+}
+
+func TestNATSSinkRetry(t *testing.T) {
+	attempts := 0
+	conn := NATSPublisherFunc(func(subject string, data []byte) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("reconnecting")
+		}
+		return nil
+	})
+	retry := &RetryPolicy{}
+	retry.sleep = func(time.Duration) {}
+	s := NATSSink{Conn: conn, Subject: "logs", Retry: retry}
+	if err := s.Write(Entry{Level: "INFO"}); err != nil || attempts != 2 {
+		t.Fatalf("expected the publish to be retried, got %d attempts, %v", attempts, err)
+	}
+}
@@ -0,0 +1,47 @@
+package applogger
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetryIDKey is the attribute shared by the entries of one call to Retry
+const RetryIDKey = "retry_id"
+
+// Retry runs op with the retries of policy (one attempt when nil) and logs
+// every failed attempt at Warn with its number, the error and the backoff
+// before the next one, then the outcome: Info on success, Error when the
+// attempts ran out or the error was not retryable. All entries of a call
+// share a RetryIDKey attribute, so a retry storm reads as one story. A done
+// ctx stops the retries.
+func (r AppLogger) Retry(ctx context.Context, policy *RetryPolicy, logPackage string, logFunc string, operation string, op func(ctx context.Context) error) error {
+	retryID := r.newID()
+	started := r.now()
+	log := func(level LogLevel, message string, attrs ...attr) {
+		if r.disabled(level, logPackage) {
+			return
+		}
+		s1 := r.now()
+		e := Entry{PID: r.newID(), Level: level.String(), Package: logPackage, Func: logFunc, Message: message, Time: s1}
+		r.logInternal(e, r.contextAttrs(ctx, s1, append(attrs, attr{RetryIDKey, retryID})))
+	}
+
+	err := policy.do(func() error {
+		if err := ctx.Err(); err != nil {
+			return Permanent(err)
+		}
+		return op(ctx)
+	}, func(n int, err error, backoff time.Duration) {
+		elapsed := r.now().Sub(started).Milliseconds()
+		switch {
+		case err == nil:
+			log(LevelInfo, operation+" succeeded", attr{"attempts", n}, attr{"elapsed_ms", elapsed})
+		case backoff > 0:
+			log(LevelWarn, fmt.Sprintf("%s attempt %d failed", operation, n), attr{"attempt", n}, attr{"backoff_ms", backoff.Milliseconds()}, attr{ErrorKey, err.Error()})
+		default:
+			log(LevelError, operation+" failed", attr{"attempts", n}, attr{"elapsed_ms", elapsed}, attr{ErrorKey, err.Error()})
+		}
+	})
+	return err
+}
@@ -0,0 +1,65 @@
+package applogger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryLogsAttempts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+	policy := &RetryPolicy{MaxAttempts: 3, Backoff: time.Second}
+	policy.sleep = func(time.Duration) {}
+
+	calls := 0
+	err := logger.Retry(context.Background(), policy, "billing", "charge", "charge card", func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("503 from gateway")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("got %v after %d calls", err, calls)
+	}
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 3 {
+		t.Fatalf("expected 2 attempts and an outcome, got %v", entries)
+	}
+	id := entries[0]["attributes"].(map[string]interface{})[RetryIDKey]
+	for _, e := range entries {
+		if e["attributes"].(map[string]interface{})[RetryIDKey] != id {
+			t.Fatalf("entries do not share the retry id %v", entries)
+		}
+	}
+	second := entries[1]["attributes"].(map[string]interface{})
+	if entries[1]["level"] != "WARN" || second["attempt"] != float64(2) || second["backoff_ms"] != float64(2000) {
+		t.Fatalf("unexpected attempt entry %v", entries[1])
+	}
+	if entries[2]["level"] != "INFO" || entries[2]["message"] != "charge card succeeded" {
+		t.Fatalf("unexpected outcome %v", entries[2])
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}}
+	logger.Initialise()
+
+	rejected := errors.New("card declined")
+	err := logger.Retry(context.Background(), &RetryPolicy{}, "billing", "charge", "charge card", func(ctx context.Context) error {
+		return Permanent(rejected)
+	})
+	if !errors.Is(err, rejected) {
+		t.Fatalf("got %v", err)
+	}
+	entries := decodeLines(t, &buf)
+	if len(entries) != 1 || entries[0]["level"] != "ERROR" || entries[0]["message"] != "charge card failed" {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+}
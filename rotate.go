@@ -0,0 +1,256 @@
+package applogger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// timeRotation cuts the output file at period boundaries. Boundaries are
+// computed on the calendar of loc, every day starts a new period at local
+// midnight so DST days of 23 or 25 hours still cut at midnight, and periods
+// of whole days are counted in calendar days.
+type timeRotation struct {
+	every time.Duration
+	loc   *time.Location
+	now   func() time.Time
+
+	// start and next delimit the period of the current file
+	start time.Time
+	next  time.Time
+}
+
+func newTimeRotation(every time.Duration, loc *time.Location) *timeRotation {
+	if loc == nil {
+		loc = time.UTC
+	}
+	tr := &timeRotation{every: every, loc: loc, now: time.Now}
+	tr.reset(tr.now())
+	return tr
+}
+
+// reset moves the rotation to the period containing t
+func (tr *timeRotation) reset(t time.Time) {
+	tr.start, tr.next = tr.period(t)
+}
+
+// period returns the boundaries of the period containing t
+func (tr *timeRotation) period(t time.Time) (time.Time, time.Time) {
+	t = t.In(tr.loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, tr.loc)
+	nextMidnight := midnight.AddDate(0, 0, 1)
+
+	if tr.every >= day {
+		days := int(tr.every / day)
+		index := int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / int64(day/time.Second))
+		start := midnight.AddDate(0, 0, -(index % days))
+		return start, start.AddDate(0, 0, days)
+	}
+
+	k := t.Sub(midnight) / tr.every
+	start := midnight.Add(k * tr.every)
+	next := start.Add(tr.every)
+	if next.After(nextMidnight) {
+		next = nextMidnight
+	}
+	return start, next
+}
+
+// due reports whether t is past the end of the current period. A clock
+// that jumps backwards simply delays the cut until the boundary is reached.
+func (tr *timeRotation) due(t time.Time) bool {
+	return !t.Before(tr.next)
+}
+
+// layout returns the time layout used in rotated file names
+func (tr *timeRotation) layout() string {
+	if tr.every >= day {
+		return "2006-01-02"
+	}
+	return "2006-01-02T15-04"
+}
+
+// rotatedName returns a name that does not exist yet for the file at path
+// covering the period starting at start, app.ndjson becomes
+// app.2020-08-23.ndjson, then app.2020-08-23.1.ndjson and so on
+func rotatedName(path string, stamp string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	name := fmt.Sprintf("%s.%s%s", base, stamp, ext)
+	for i := 1; fileExists(name); i++ {
+		name = fmt.Sprintf("%s.%s.%d%s", base, stamp, i, ext)
+	}
+	return name
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// indexedName returns the name of the i-th backup of the file at path,
+// app.ndjson becomes app.1.ndjson, the file itself for 0
+func indexedName(path string, i int) string {
+	if i == 0 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(path, ext), i, ext)
+}
+
+// rotateSize turns on size based rotation of the file at path: a write
+// that would take the file over maxSize bytes first moves it to the
+// backup app.1.ndjson, shifting older backups up by one and removing those
+// over maxBackups, see cleanRotated
+func (w *fileWriter) rotateSize(path string, maxSize int64, onRotate func(path string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.path = path
+	w.onRotate = onRotate
+	w.maxSize = maxSize
+	fi, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	w.size = fi.Size()
+	return nil
+}
+
+// rotateSizeLocked moves the current file to the first backup and opens a
+// new one at the original path
+func (w *fileWriter) rotateSizeLocked() error {
+	if err := w.flushLocked(); err != nil {
+		return err
+	}
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	// a backup still being compressed must not move under the compressor
+	w.compressing.Wait()
+	exists := func(i int) bool {
+		return fileExists(indexedName(w.path, i)) || fileExists(indexedName(w.path, i)+".gz")
+	}
+	last := w.maxBackups
+	if last <= 0 {
+		for last = 1; exists(last); last++ {
+		}
+	}
+	for _, suffix := range []string{"", ".gz", IndexSuffix} {
+		os.Remove(indexedName(w.path, last) + suffix)
+	}
+	var renameErr error
+	for i := last; i > 0; i-- {
+		for _, suffix := range []string{"", ".gz", IndexSuffix} {
+			from := indexedName(w.path, i-1) + suffix
+			if !fileExists(from) {
+				continue
+			}
+			if err := os.Rename(from, indexedName(w.path, i)+suffix); err != nil && suffix != IndexSuffix {
+				renameErr = err
+			}
+		}
+	}
+	if renameErr == nil {
+		w.afterRotate(indexedName(w.path, 1))
+	}
+
+	f, err := openLogFile(w.path)
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.size = 0
+	if w.gz != nil {
+		w.gz.Reset(f)
+	}
+	if err := w.resetIndexLocked(w.path); err != nil {
+		return err
+	}
+	return renameErr
+}
+
+// gzipFile compresses the file at path to path+".gz", keeping its
+// modification time for age based cleanup, and removes it
+func gzipFile(path string) (string, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return "", err
+	}
+	name := path + ".gz"
+	out, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return "", err
+	}
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if cerr := gz.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	os.Chtimes(name, fi.ModTime(), fi.ModTime())
+	return name, os.Remove(path)
+}
+
+// pruneRotated removes the rotated files older than maxAge and, oldest
+// first, those over maxBackups
+func (w *fileWriter) pruneRotated() {
+	if w.maxAge <= 0 && w.maxBackups <= 0 {
+		return
+	}
+	files := rotatedFiles(w.path, w.errorPath)
+	now := time.Now()
+	for i, path := range files {
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		expired := w.maxAge > 0 && now.Sub(fi.ModTime()) > w.maxAge
+		if expired || w.maxBackups > 0 && len(files)-i > w.maxBackups {
+			os.Remove(path)
+			os.Remove(strings.TrimSuffix(path, ".gz") + IndexSuffix)
+		}
+	}
+}
+
+// cleanRotated turns on the compression of rotated files and their
+// removal after maxAge or over maxBackups, and cleans up the rotated files
+// an earlier run left
+func (w *fileWriter) cleanRotated(compress bool, maxAge time.Duration, maxBackups int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.compressRotated, w.maxAge, w.maxBackups = compress, maxAge, maxBackups
+	w.hooks.Add(1)
+	w.compressing.Add(1)
+	go func() {
+		defer w.hooks.Done()
+		if compress {
+			for _, path := range rotatedFiles(w.path, w.errorPath) {
+				if !strings.HasSuffix(path, ".gz") {
+					if _, err := gzipFile(path); err != nil {
+						fmt.Fprintf(os.Stderr, "applogger: compressing %s: %v\n", path, err)
+					}
+				}
+			}
+		}
+		w.compressing.Done()
+		w.pruneRotated()
+	}()
+}
@@ -0,0 +1,197 @@
+package applogger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotationPeriodDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	tr := &timeRotation{every: day, loc: ny}
+
+	// 2021-03-14 is 23 hours long in New York
+	start, next := tr.period(time.Date(2021, 3, 14, 12, 0, 0, 0, ny))
+	if !start.Equal(time.Date(2021, 3, 14, 0, 0, 0, 0, ny)) || !next.Equal(time.Date(2021, 3, 15, 0, 0, 0, 0, ny)) {
+		t.Fatalf("unexpected period %s - %s", start, next)
+	}
+	if next.Sub(start) != 23*time.Hour {
+		t.Fatalf("expected a 23 hour day, got %s", next.Sub(start))
+	}
+
+	tr = &timeRotation{every: 6 * time.Hour, loc: time.UTC}
+	start, next = tr.period(time.Date(2021, 3, 14, 13, 30, 0, 0, time.UTC))
+	if start.Hour() != 12 || next.Hour() != 18 {
+		t.Fatalf("unexpected period %s - %s", start, next)
+	}
+}
+
+func TestRotateTime(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/rotate.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	// a file left over from yesterday is rotated on start
+	os.WriteFile(filePath, []byte("{}\n"), 0666)
+	yesterday := time.Now().Add(-day)
+	os.Chtimes(filePath, yesterday, yesterday)
+	// and the name it would get is already taken by a previous run
+	os.WriteFile(directoryPath+"/rotate."+yesterday.UTC().Format("2006-01-02")+".ndjson", []byte("{}\n"), 0666)
+
+	logger := AppLogger{Path: filePath, RotateEvery: day}
+	logger.Initialise()
+	defer logger.Close()
+
+	files, _ := filepath.Glob(directoryPath + "/rotate.*.ndjson")
+	if len(files) != 2 {
+		t.Fatalf("expected 2 rotated files, got %v", files)
+	}
+
+	// moving the clock past the boundary cuts the file on the next write
+	logger.Log("INFO", "main", "app", "today")
+	logger.out.rotation.now = func() time.Time { return time.Now().Add(day) }
+	logger.Log("INFO", "main", "app", "tomorrow")
+
+	files, _ = filepath.Glob(directoryPath + "/rotate.*.ndjson")
+	if len(files) != 3 {
+		t.Fatalf("expected 3 rotated files, got %v", files)
+	}
+	b, _ := os.ReadFile(filePath)
+	if err := isJSON(string(b)); err != nil || len(b) == 0 {
+		t.Fatalf("expected a single entry in the new file, got %q", b)
+	}
+}
+
+func TestOnRotate(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/hook.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	rotated := make(chan string, 2)
+	logger := AppLogger{Path: filePath, RotateEvery: time.Hour, OnRotate: func(path string) {
+		rotated <- path
+		panic("hooks must not crash the process")
+	}}
+	logger.Initialise()
+
+	logger.Log("INFO", "main", "app", "before")
+	logger.out.rotation.now = func() time.Time { return time.Now().Add(time.Hour) }
+	logger.Log("INFO", "main", "app", "after")
+	logger.Close()
+
+	select {
+	case path := <-rotated:
+		if !fileExists(path) || filepath.Dir(path) != filepath.Dir(filePath) {
+			t.Fatalf("hook got unexpected path %s", path)
+		}
+	default:
+		t.Fatal("rotation hook was not called before Close returned")
+	}
+}
+
+func TestRotateSize(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/size.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, Format: FormatJSON, MaxSize: 300, MaxBackups: 2}
+	logger.Initialise()
+	for i := 0; i < 10; i++ {
+		// every entry is about 150 bytes, two fit in a file
+		logger.Log("INFO", "main", "app", "entry")
+	}
+	logger.Close()
+
+	files, _ := filepath.Glob(directoryPath + "/size*.ndjson")
+	if len(files) != 3 {
+		t.Fatalf("expected the file and 2 backups, got %v", files)
+	}
+	for _, f := range files {
+		b, _ := os.ReadFile(f)
+		if len(b) > 300 || len(b) == 0 {
+			t.Fatalf("%s has %d bytes", f, len(b))
+		}
+	}
+	if fileExists(directoryPath + "/size.3.ndjson") {
+		t.Fatal("backup over MaxBackups kept")
+	}
+
+	if err := (&AppLogger{Path: filePath, MaxSize: 300, RotateEvery: day}).Open(); err == nil {
+		t.Fatal("expected an error for MaxSize with RotateEvery")
+	}
+}
+
+func TestFileSinkMaxSize(t *testing.T) {
+	directoryPath := "./tmp"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	s, err := NewSink("file", map[string]interface{}{"path": directoryPath + "/sink.ndjson", "max_size": float64(200)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		s.Write(Entry{PID: "1", Level: "INFO", Message: "entry", Time: time.Now()})
+	}
+	s.Close()
+	files, _ := filepath.Glob(directoryPath + "/sink*.ndjson")
+	if len(files) < 2 {
+		t.Fatalf("expected backups of the sink file, got %v", files)
+	}
+}
+
+func TestCompressRotated(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/gz.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	// left over by an earlier run: one to compress, one too old to keep
+	os.WriteFile(directoryPath+"/gz.2020-01-01.ndjson", []byte("{}\n"), 0666)
+	os.WriteFile(directoryPath+"/gz.2019-01-01.ndjson", []byte("{}\n"), 0666)
+	old := time.Now().Add(-30 * day)
+	os.Chtimes(directoryPath+"/gz.2019-01-01.ndjson", old, old)
+
+	logger := AppLogger{Path: filePath, Format: FormatJSON, MaxSize: 300, MaxBackups: 3, CompressRotated: true, MaxAge: 7 * day}
+	logger.Initialise()
+	for i := 0; i < 10; i++ {
+		logger.Log("INFO", "main", "app", "entry")
+	}
+	logger.Close()
+
+	files, _ := filepath.Glob(directoryPath + "/gz.*.ndjson*")
+	if len(files) != 3 {
+		t.Fatalf("expected 3 compressed backups, got %v", files)
+	}
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		b, _ := io.ReadAll(gz)
+		f.Close()
+		if len(b) == 0 {
+			t.Fatalf("%s is empty", name)
+		}
+	}
+	if fileExists(directoryPath + "/gz.2019-01-01.ndjson.gz") {
+		t.Fatal("file older than MaxAge kept")
+	}
+
+	if err := (&AppLogger{Path: filePath, CompressRotated: true}).Open(); err == nil {
+		t.Fatal("expected an error for CompressRotated without rotation")
+	}
+}
@@ -0,0 +1,20 @@
+package applogger
+
+// To returns a logger like r whose entries also go to the Routes names,
+// in addition to the main output and Sinks:
+//
+//	logger.To("audit").Log("WARN", "auth", "login", "password reset requested")
+func (r AppLogger) To(names ...string) AppLogger {
+	r.to = append(append([]string(nil), r.to...), names...)
+	r.derived = true
+	return r
+}
+
+// OnlyTo returns a logger like r whose entries only go to the Routes
+// names, not to the main output or Sinks
+func (r AppLogger) OnlyTo(names ...string) AppLogger {
+	r.to = append([]string(nil), names...)
+	r.toOnly = true
+	r.derived = true
+	return r
+}
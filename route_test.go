@@ -0,0 +1,24 @@
+package applogger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	var main, audit bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&main, nil)}, Routes: map[string]Sink{"audit": NewWriterSink(&audit, nil)}}
+	logger.Initialise()
+
+	logger.Log("INFO", "main", "app", "regular")
+	logger.To("audit").Log("WARN", "auth", "login", "password reset")
+	logger.OnlyTo("audit").Log("WARN", "auth", "login", "secret rotated")
+
+	if strings.Contains(audit.String(), "regular") || !strings.Contains(audit.String(), "password reset") || !strings.Contains(audit.String(), "secret rotated") {
+		t.Fatalf("unexpected audit output %s", audit.String())
+	}
+	if !strings.Contains(main.String(), "regular") || !strings.Contains(main.String(), "password reset") || strings.Contains(main.String(), "secret rotated") {
+		t.Fatalf("unexpected main output %s", main.String())
+	}
+}
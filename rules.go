@@ -0,0 +1,106 @@
+package applogger
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Rule is a policy for the entries matching When, a filter expression (see
+// ParseFilter) on level, package, func, message and attributes, e.g.
+// `package=="payments" && level>=warn`. An empty When matches every entry.
+// Rules are the central place to decide, per entry, where it goes, how
+// much of it is kept and what is hidden, instead of options spread over
+// sinks:
+//
+//	Rules: []applogger.Rule{
+//		{When: `attributes.security==true`, Routes: []string{"audit"}},
+//		{When: `level<=debug`, Sample: 0.1},
+//		{When: `package=="auth"`, Redact: []string{"email", "ip"}},
+//	}
+//
+// Every matching rule applies, in order, until one with Final.
+type Rule struct {
+	When string `json:"when"`
+	// Routes sends the entry to these Routes as well, like To, or only to
+	// them with Only, like OnlyTo
+	Routes []string `json:"routes"`
+	Only   bool     `json:"only"`
+	// Sample keeps that fraction of the entries, picked at random, zero
+	// keeps them all
+	Sample float64 `json:"sample"`
+	// Redact writes Redacted in place of the value of these attributes
+	Redact []string `json:"redact"`
+	// Retention sets the retention class of the entry, see RetentionKey
+	Retention string `json:"retention"`
+	// Drop drops the entry
+	Drop bool `json:"drop"`
+	// Final stops the evaluation of the rules that follow
+	Final bool `json:"final"`
+}
+
+// compileRules parses the When of every rule, checking that the routes
+// they name exist
+func compileRules(rules []Rule, routes map[string]Sink) ([]*Filter, error) {
+	filters := make([]*Filter, len(rules))
+	for i, rule := range rules {
+		if rule.When != "" {
+			f, err := ParseFilter(rule.When)
+			if err != nil {
+				return nil, fmt.Errorf("applogger: rule %d: %w", i, err)
+			}
+			filters[i] = f
+		}
+		for _, name := range rule.Routes {
+			if _, ok := routes[name]; !ok {
+				return nil, fmt.Errorf("applogger: rule %d: unknown route %q", i, name)
+			}
+		}
+		if rule.Sample < 0 || rule.Sample > 1 {
+			return nil, fmt.Errorf("applogger: rule %d: sample must be between 0 and 1", i)
+		}
+	}
+	return filters, nil
+}
+
+// applyRules runs the entry through the Rules, reporting whether it is to
+// be written. Rules match the entry as it was logged, before any of them
+// redacted it.
+func (r AppLogger) applyRules(e *Entry) bool {
+	le := logEntryOf(*e)
+	for i, rule := range r.Rules {
+		if f := r.rules[i]; f != nil && !f.Match(le) {
+			continue
+		}
+		if rule.Drop || rule.Sample > 0 && rand.Float64() >= rule.Sample {
+			return false
+		}
+		for _, key := range rule.Redact {
+			if _, ok := e.Lookup(key); ok {
+				e.Set(key, Redacted)
+			}
+		}
+		if rule.Retention != "" {
+			e.Set(RetentionKey, rule.Retention)
+		}
+		if len(rule.Routes) > 0 {
+			if rule.Only && !e.routeOnly {
+				e.route, e.routeOnly = nil, true
+			}
+			e.route = append(append([]string(nil), e.route...), rule.Routes...)
+		}
+		if rule.Final {
+			break
+		}
+	}
+	return true
+}
+
+// logEntryOf returns the entry as the Reader would read it back, for
+// filters
+func logEntryOf(e Entry) LogEntry {
+	le := LogEntry{PID: e.PID, Level: e.Level, Package: e.Package, Func: e.Func, Message: e.Message, Time: e.Time, Attributes: e.attributeMap()}
+	if e.HTTP {
+		le.Code, le.Duration = e.Code, e.Duration
+	}
+	return le
+}
@@ -0,0 +1,60 @@
+package applogger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	main, audit := &memorySink{}, &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{main}, Routes: map[string]Sink{"audit": audit}, Rules: []Rule{
+		{When: `attributes.security==true`, Routes: []string{"audit"}},
+		{When: `package=="auth"`, Redact: []string{"email"}},
+		{When: `level<=debug`, Drop: true, Final: true},
+		{When: `message~"^secret"`, Routes: []string{"audit"}, Only: true},
+	}}
+	logger.Initialise()
+	defer logger.Close()
+
+	logger.LogFields("WARN", "auth", "login", "failed", map[string]interface{}{"security": true, "email": "a@b.c"})
+	logger.Log("DEBUG", "auth", "login", "secret dropped")
+	logger.Log("INFO", "main", "app", "secret only audited")
+	logger.Log("INFO", "main", "app", "plain")
+
+	if len(main.entries) != 2 || main.entries[0].Message != "failed" || main.entries[1].Message != "plain" {
+		t.Fatalf("unexpected main entries %+v", main.entries)
+	}
+	if email, _ := main.entries[0].Lookup("email"); email != Redacted {
+		t.Fatalf("email not redacted: %v", email)
+	}
+	if len(audit.entries) != 2 || audit.entries[0].Message != "failed" || audit.entries[1].Message != "secret only audited" {
+		t.Fatalf("unexpected audit entries %+v", audit.entries)
+	}
+}
+
+func TestRulesSample(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, Rules: []Rule{{When: `level==info`, Sample: 0.1}}}
+	logger.Initialise()
+	defer logger.Close()
+	for i := 0; i < 1000; i++ {
+		logger.Log("INFO", "main", "app", "sampled")
+	}
+	logger.Log("ERROR", "main", "app", "kept")
+	if n := len(mem.entries); n < 50 || n > 200 || mem.entries[n-1].Message != "kept" {
+		t.Fatalf("expected about 100 sampled entries and the error, got %d", n)
+	}
+}
+
+func TestRulesConfig(t *testing.T) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(`{"path": "/dev/null", "rules": [{"when": "level>=", "drop": true}]}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromConfig(cfg); err == nil {
+		t.Fatal("expected an error for an invalid rule")
+	}
+	if err := (&AppLogger{Path: "/dev/null", Rules: []Rule{{Routes: []string{"missing"}}}}).Open(); err == nil {
+		t.Fatal("expected an error for an unknown route")
+	}
+}
@@ -0,0 +1,124 @@
+package applogger
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// SchemaViolationsKey is the attribute listing why an entry does not match
+// the schema of its logger
+const SchemaViolationsKey = "_schema_violations"
+
+// AttrType is the expected type of an attribute in a Schema
+type AttrType int
+
+const (
+	// AttrAny accepts any value, only presence is checked
+	AttrAny AttrType = iota
+	AttrString
+	// AttrInt accepts the integer types
+	AttrInt
+	// AttrFloat accepts every numeric type
+	AttrFloat
+	AttrBool
+	AttrTime
+)
+
+// String returns the type name used in violations
+func (t AttrType) String() string {
+	switch t {
+	case AttrString:
+		return "string"
+	case AttrInt:
+		return "int"
+	case AttrFloat:
+		return "float"
+	case AttrBool:
+		return "bool"
+	case AttrTime:
+		return "time"
+	}
+	return "any"
+}
+
+// AttrSpec describes one attribute of a Schema
+type AttrSpec struct {
+	Type     AttrType
+	Required bool
+}
+
+// Schema keeps the attributes of a logger consistent, for example
+//
+//	logger.Schema = &applogger.Schema{Attributes: map[string]applogger.AttrSpec{
+//		"user_id":  {Type: applogger.AttrInt},
+//		"tenant":   {Type: applogger.AttrString, Required: true},
+//	}}
+//
+// Entries that break it get a _schema_violations attribute, or are not
+// written at all when Strict is set. Attributes not in the schema are
+// allowed.
+type Schema struct {
+	Attributes map[string]AttrSpec
+	Strict     bool
+}
+
+// Validate returns the violations of the entry, sorted by key
+func (s *Schema) Validate(e Entry) []string {
+	present := make(map[string]interface{})
+	e.eachAttribute(func(k string, v interface{}) { present[k] = v })
+
+	keys := make([]string, 0, len(s.Attributes))
+	for k := range s.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var violations []string
+	for _, k := range keys {
+		spec := s.Attributes[k]
+		v, ok := present[k]
+		if !ok {
+			if spec.Required {
+				violations = append(violations, k+": required")
+			}
+			continue
+		}
+		if !spec.Type.matches(v) {
+			violations = append(violations, fmt.Sprintf("%s: expected %s, got %T", k, spec.Type, v))
+		}
+	}
+	return violations
+}
+
+func (t AttrType) matches(v interface{}) bool {
+	switch v.(type) {
+	case string:
+		return t == AttrAny || t == AttrString
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return t == AttrAny || t == AttrInt || t == AttrFloat
+	case float32, float64:
+		return t == AttrAny || t == AttrFloat
+	case bool:
+		return t == AttrAny || t == AttrBool
+	case time.Time:
+		return t == AttrAny || t == AttrTime
+	}
+	return t == AttrAny
+}
+
+// applySchema flags the entry with its violations and reports whether it
+// should be written
+func (r AppLogger) applySchema(e *Entry) bool {
+	violations := r.Schema.Validate(*e)
+	if len(violations) == 0 {
+		return true
+	}
+	if r.Schema.Strict {
+		fmt.Fprintln(os.Stderr, "Entry rejected by schema:", violations)
+		return false
+	}
+	e.attrs = append(e.attrs, attr{SchemaViolationsKey, violations})
+	return true
+}
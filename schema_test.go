@@ -0,0 +1,44 @@
+package applogger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, Schema: &Schema{Attributes: map[string]AttrSpec{
+		"user_id": {Type: AttrInt},
+		"tenant":  {Type: AttrString, Required: true},
+		"ratio":   {Type: AttrFloat},
+	}}}
+	logger.Initialise()
+	defer logger.Close()
+
+	logger.WithFields(map[string]interface{}{"tenant": "acme"}).LogFields("INFO", "main", "app", "valid", map[string]interface{}{"user_id": 42, "ratio": 1})
+	logger.LogFields("INFO", "main", "app", "invalid", map[string]interface{}{"user_id": "42", "other": true})
+
+	if _, ok := mem.entries[0].Attributes[SchemaViolationsKey]; ok {
+		t.Fatalf("valid entry was flagged: %v", mem.entries[0].Attributes)
+	}
+	want := []string{"tenant: required", "user_id: expected int, got string"}
+	if got := mem.entries[1].Attributes[SchemaViolationsKey]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected violations %v, got %v", want, got)
+	}
+}
+
+func TestSchemaStrict(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, Schema: &Schema{Strict: true, Attributes: map[string]AttrSpec{
+		"tenant": {Required: true},
+	}}}
+	logger.Initialise()
+	defer logger.Close()
+
+	logger.Log("INFO", "main", "app", "rejected")
+	logger.LogFields("INFO", "main", "app", "accepted", map[string]interface{}{"tenant": 7})
+
+	if len(mem.entries) != 1 || mem.entries[0].Message != "accepted" {
+		t.Fatalf("expected only the valid entry, got %+v", mem.entries)
+	}
+}
@@ -0,0 +1,56 @@
+package applogger
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// Scrubber rewrites the value of an attribute before it is encoded
+type Scrubber func(value interface{}) interface{}
+
+// scrubAttrs returns attrs with the scrubber of every key applied, attrs
+// itself is left untouched since it may be shared
+func scrubAttrs(scrub map[string]Scrubber, attrs []attr) []attr {
+	var out []attr
+	for i, a := range attrs {
+		s, ok := scrub[a.key]
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = append(make([]attr, 0, len(attrs)), attrs...)
+		}
+		out[i].value = s(a.value)
+	}
+	if out == nil {
+		return attrs
+	}
+	return out
+}
+
+// TruncateString cuts string values to at most n bytes, on a rune
+// boundary, and marks the cut with "..."
+func TruncateString(n int) Scrubber {
+	return func(value interface{}) interface{} {
+		s, ok := value.(string)
+		if !ok || len(s) <= n {
+			return value
+		}
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		return s[:n] + "..."
+	}
+}
+
+// ReplacePattern replaces every match of re in string values, e.g.
+// ReplacePattern(regexp.MustCompile(`/\d+`), "/{id}") turns /users/42
+// into /users/{id}
+func ReplacePattern(re *regexp.Regexp, replacement string) Scrubber {
+	return func(value interface{}) interface{} {
+		if s, ok := value.(string); ok {
+			return re.ReplaceAllString(s, replacement)
+		}
+		return value
+	}
+}
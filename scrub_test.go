@@ -0,0 +1,37 @@
+package applogger
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestScrub(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{NewWriterSink(&buf, nil)}, Scrub: map[string]Scrubber{
+		"sql":  TruncateString(10),
+		"path": ReplacePattern(regexp.MustCompile(`/\d+`), "/{id}"),
+	}}
+	logger.Initialise()
+	fields := map[string]interface{}{"path": "/users/42/orders/7"}
+	derived := logger.WithFields(fields)
+	derived.LogFields("INFO", "main", "app", "query", map[string]interface{}{"sql": "SELECT * FROM users WHERE id = 42"})
+
+	line := buf.String()
+	if !strings.Contains(line, `"path":"/users/{id}/orders/{id}"`) || !strings.Contains(line, `"sql":"SELECT * F..."`) {
+		t.Fatalf("attributes were not scrubbed %s", line)
+	}
+	if fields["path"] != "/users/42/orders/7" {
+		t.Fatal("scrubbing changed the caller's map")
+	}
+}
+
+func TestTruncateStringRuneBoundary(t *testing.T) {
+	if got := TruncateString(2)("héllo"); got != "h..." {
+		t.Fatalf("got %q", got)
+	}
+	if got := TruncateString(2)(42); got != 42 {
+		t.Fatalf("non strings should be left alone, got %v", got)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package applogger
+
+import (
+	"os"
+)
+
+// diagnosticSignal is nil where SIGUSR1 does not exist, Diagnostics can
+// still be called directly
+var diagnosticSignal os.Signal
+
+// reopenSignal is nil where SIGHUP does not exist, Reopen can still be
+// called directly
+var reopenSignal os.Signal
@@ -0,0 +1,14 @@
+//go:build unix
+
+package applogger
+
+import (
+	"os"
+	"syscall"
+)
+
+// diagnosticSignal is the signal that triggers a diagnostic dump
+var diagnosticSignal os.Signal = syscall.SIGUSR1
+
+// reopenSignal is the signal that reopens the log files
+var reopenSignal os.Signal = syscall.SIGHUP
@@ -0,0 +1,56 @@
+//go:build unix
+
+package applogger
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDiagnosticSignal(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", DiagnosticSignal: true, Sinks: []Sink{mem}}
+	logger.Initialise()
+	logger.Log("ERROR", "main", "app", "broken")
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	deadline := time.Now().Add(5 * time.Second)
+	for mem.len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	logger.Close()
+
+	if mem.len() != 2 || mem.entries[1].Message != "diagnostic dump" {
+		t.Fatalf("expected a diagnostic dump, got %+v", mem.entries)
+	}
+	if len(mem.entries[1].Attributes["recent_errors"].([]map[string]interface{})) != 1 {
+		t.Fatalf("expected the recent error in the dump, got %v", mem.entries[1].Attributes)
+	}
+}
+
+func TestReopenSignal(t *testing.T) {
+	directoryPath := "./tmp"
+	filePath := directoryPath + "/hup.ndjson"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: filePath, ReopenSignal: true}
+	logger.Initialise()
+	defer logger.Close()
+	logger.Log("INFO", "main", "app", "before")
+	os.Rename(filePath, filePath+".1")
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	deadline := time.Now().Add(5 * time.Second)
+	for !fileExists(filePath) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	logger.Log("INFO", "main", "app", "after")
+
+	if b, _ := os.ReadFile(filePath); !strings.Contains(string(b), "after") {
+		t.Fatalf("file not reopened on SIGHUP: %q", b)
+	}
+}
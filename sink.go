@@ -0,0 +1,51 @@
+package applogger
+
+import (
+	"io"
+	"sync"
+)
+
+// Sink receives every entry written by an AppLogger in addition to its main
+// output
+type Sink interface {
+	Write(e Entry) error
+	Close() error
+}
+
+// WriterSink writes every entry, encoded by Encoder (JSONEncoder by
+// default), as a line to Writer
+type WriterSink struct {
+	Writer  io.Writer
+	Encoder Encoder
+
+	mu sync.Mutex
+}
+
+// NewWriterSink returns a sink writing lines encoded by enc to w
+func NewWriterSink(w io.Writer, enc Encoder) *WriterSink {
+	return &WriterSink{Writer: w, Encoder: enc}
+}
+
+// Write encodes the entry and writes it followed by a newline
+func (s *WriterSink) Write(e Entry) error {
+	enc := s.Encoder
+	if enc == nil {
+		enc = JSONEncoder{}
+	}
+	line, err := enc.Encode(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.Writer.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the writer when it is an io.Closer
+func (s *WriterSink) Close() error {
+	if c, ok := s.Writer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
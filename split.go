@@ -0,0 +1,137 @@
+package applogger
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxOpenFiles is how many files a SplitSink keeps open when MaxOpen
+// is not set
+const DefaultMaxOpenFiles = 64
+
+// SplitSink writes every entry to a file chosen by one of its attributes,
+// e.g. one file per component:
+//
+//	applogger.NewSplitSink("component", "/var/log/app/{value}.ndjson", 32)
+//
+// {value} in Pattern is replaced by the attribute value, with characters
+// other than letters, digits, '.', '-' and '_' replaced by '_', or by
+// Missing ("unknown" when empty) for entries without the attribute. Only
+// MaxOpen files are kept open, the least recently used one is closed to
+// make room for another.
+type SplitSink struct {
+	Key     string
+	Pattern string
+	Missing string
+	MaxOpen int
+	Encoder Encoder
+
+	mu    sync.Mutex
+	files map[string]*list.Element
+	lru   *list.List
+}
+
+type splitFile struct {
+	path string
+	file *os.File
+}
+
+// NewSplitSink returns a sink splitting entries by the attribute key into
+// the files named by pattern, with at most maxOpen files open
+func NewSplitSink(key string, pattern string, maxOpen int) *SplitSink {
+	return &SplitSink{Key: key, Pattern: pattern, MaxOpen: maxOpen}
+}
+
+// Write appends the entry to the file of its attribute value
+func (s *SplitSink) Write(e Entry) error {
+	enc := s.Encoder
+	if enc == nil {
+		enc = JSONEncoder{}
+	}
+	line, err := enc.Encode(e)
+	if err != nil {
+		return err
+	}
+
+	value := s.Missing
+	if value == "" {
+		value = "unknown"
+	}
+	if v, ok := e.attributeMap()[s.Key]; ok {
+		value = sanitizePathValue(fmt.Sprint(v))
+	}
+	path := strings.ReplaceAll(s.Pattern, "{value}", value)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.fileLocked(path)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// fileLocked returns the open file at path, opening it and closing the
+// least recently used one when needed
+func (s *SplitSink) fileLocked(path string) (*os.File, error) {
+	if s.files == nil {
+		s.files = make(map[string]*list.Element)
+		s.lru = list.New()
+	}
+	if el, ok := s.files[path]; ok {
+		s.lru.MoveToFront(el)
+		return el.Value.(*splitFile).file, nil
+	}
+
+	max := s.MaxOpen
+	if max <= 0 {
+		max = DefaultMaxOpenFiles
+	}
+	for s.lru.Len() >= max {
+		oldest := s.lru.Remove(s.lru.Back()).(*splitFile)
+		delete(s.files, oldest.path)
+		oldest.file.Close()
+	}
+
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s.files[path] = s.lru.PushFront(&splitFile{path: path, file: f})
+	return f, nil
+}
+
+// Close closes every open file
+func (s *SplitSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	for path, el := range s.files {
+		if cerr := el.Value.(*splitFile).file.Close(); err == nil {
+			err = cerr
+		}
+		delete(s.files, path)
+	}
+	if s.lru != nil {
+		s.lru.Init()
+	}
+	return err
+}
+
+// sanitizePathValue keeps an attribute value from escaping the directory
+// of the pattern
+func sanitizePathValue(v string) string {
+	if v == "" || v == "." || v == ".." {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, v)
+}
@@ -0,0 +1,39 @@
+package applogger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSplitSink(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	s, err := NewSink("split", map[string]interface{}{"key": "component", "path": "./tmp/{value}.ndjson", "max_open": float64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	split := s.(*SplitSink)
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{s}}
+	logger.Initialise()
+
+	for _, c := range []string{"api", "db", "api", "cache", "db", "../etc/passwd"} {
+		logger.LogFields("INFO", "main", "app", c, map[string]interface{}{"component": c})
+		if split.lru.Len() > 2 {
+			t.Fatalf("more than 2 files open: %d", split.lru.Len())
+		}
+	}
+	logger.Log("INFO", "main", "app", "no component")
+	logger.Close()
+
+	for file, want := range map[string]int{"api": 2, "db": 2, "cache": 1, ".._etc_passwd": 1, "unknown": 1} {
+		b, err := os.ReadFile("./tmp/" + file + ".ndjson")
+		if err != nil {
+			t.Fatalf("Couldn't read %s: %v", file, err)
+		}
+		if n := strings.Count(string(b), "\n"); n != want {
+			t.Fatalf("expected %d entries in %s, got %d", want, file, n)
+		}
+	}
+}
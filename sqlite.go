@@ -0,0 +1,154 @@
+package applogger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// sqliteTimeLayout is fixed width so that the time column sorts correctly
+// as text and works with the sqlite date functions
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+var sqliteIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sqliteColumns are the columns every table has, promoted attributes cannot
+// take their names
+var sqliteColumns = []string{"pid", "time", "level", "package", "func", "message", "code", "duration", "attributes"}
+
+// SQLiteSink stores entries in a table of a SQLite database so recent logs
+// can be queried with SQL on the host. The database is opened by the caller
+// with the driver of their choice, for example:
+//
+//	db, _ := sql.Open("sqlite3", "/var/log/app/logs.db")
+//	sink, err := applogger.NewSQLiteSink(db, "logs", "user_id", "tenant")
+type SQLiteSink struct {
+	// Retry retries failed inserts, for example while the database is
+	// locked by another writer, nil inserts once
+	Retry *RetryPolicy
+
+	db       *sql.DB
+	insert   *sql.Stmt
+	promoted []string
+}
+
+// NewSQLiteSink creates the table (default "logs") and its indexes on time,
+// level and package if they do not exist yet. The attributes of an entry
+// are stored as a JSON object in the attributes column, the ones under the
+// promoted keys also get a column of the same name with an index, so they
+// can be filtered on without json_extract. Tables of an older version get
+// the missing columns added.
+func NewSQLiteSink(db *sql.DB, table string, promoted ...string) (*SQLiteSink, error) {
+	if table == "" {
+		table = "logs"
+	}
+	if !sqliteIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("applogger: invalid sqlite table name %q", table)
+	}
+	for i, key := range promoted {
+		if !sqliteIdentifier.MatchString(key) {
+			return nil, fmt.Errorf("applogger: attribute %q cannot be a sqlite column", key)
+		}
+		for _, c := range append(sqliteColumns, promoted[:i]...) {
+			if strings.EqualFold(c, key) {
+				return nil, fmt.Errorf("applogger: attribute %q cannot be promoted, the column %s exists", key, c)
+			}
+		}
+	}
+
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+			pid TEXT PRIMARY KEY,
+			time TEXT NOT NULL,
+			level TEXT NOT NULL,
+			package TEXT,
+			func TEXT,
+			message TEXT,
+			code INTEGER,
+			duration REAL,
+			attributes TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_time ON ` + table + ` (time)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_level ON ` + table + ` (level, time)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_package ON ` + table + ` (package, time)`,
+	}
+	for _, q := range schema {
+		if _, err := db.Exec(q); err != nil {
+			return nil, err
+		}
+	}
+	added := append([]string{"attributes TEXT"}, promoted...)
+	for _, column := range added {
+		if _, err := db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			return nil, err
+		}
+	}
+	for _, key := range promoted {
+		if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS ` + table + `_` + key + ` ON ` + table + ` (` + key + `, time)`); err != nil {
+			return nil, err
+		}
+	}
+
+	columns := append(sqliteColumns, promoted...)
+	insert, err := db.Prepare(`INSERT INTO ` + table + ` (` + strings.Join(columns, ", ") + `) VALUES (?` + strings.Repeat(", ?", len(columns)-1) + `)`)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteSink{db: db, insert: insert, promoted: promoted}, nil
+}
+
+// Write inserts the entry, code and duration are NULL for non HTTP entries,
+// attributes and promoted columns when the entry has none
+func (s *SQLiteSink) Write(e Entry) error {
+	var code, duration, attributes interface{}
+	if e.HTTP {
+		code, duration = e.Code, e.Duration
+	}
+	if e.hasAttributes() {
+		b, err := encodeAttributes(e)
+		if err != nil {
+			return err
+		}
+		attributes = string(b)
+	}
+	args := []interface{}{e.PID, e.Time.UTC().Format(sqliteTimeLayout), e.Level, e.Package, e.Func, e.Message, code, duration, attributes}
+	for _, key := range s.promoted {
+		v, _ := e.Lookup(key)
+		value, err := sqliteValue(v)
+		if err != nil {
+			return err
+		}
+		args = append(args, value)
+	}
+	return s.Retry.Do(func() error {
+		_, err := s.insert.Exec(args...)
+		return err
+	})
+}
+
+// sqliteValue is v as a value the driver takes: strings, numbers and
+// booleans as they are, durations in nanoseconds, times in the layout of
+// the time column and anything else as JSON
+func sqliteValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint8, uint16, uint32, float32, float64:
+		return v, nil
+	case time.Duration:
+		return int64(v), nil
+	case time.Time:
+		return v.UTC().Format(sqliteTimeLayout), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Close releases the prepared statement, the database is owned by the caller
+func (s *SQLiteSink) Close() error {
+	return s.insert.Close()
+}
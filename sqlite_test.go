@@ -0,0 +1,142 @@
+package applogger
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingDriver is a minimal database/sql driver that remembers the
+// statements and arguments it was asked to execute
+type recordingDriver struct {
+	mu    sync.Mutex
+	execs []string
+	args  [][]driver.Value
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) { return recordingConn{d}, nil }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return recordingStmt{d: c.d, query: query}, nil
+}
+func (c recordingConn) Close() error              { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s recordingStmt) Close() error  { return nil }
+func (s recordingStmt) NumInput() int { return strings.Count(s.query, "?") }
+func (s recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.execs = append(s.d.execs, s.query)
+	s.d.args = append(s.d.args, args)
+	return driver.RowsAffected(1), nil
+}
+func (s recordingStmt) Query(args []driver.Value) (driver.Rows, error) { return nil, driver.ErrSkip }
+
+var testDriver = &recordingDriver{}
+
+func init() {
+	sql.Register("applogger-recording", testDriver)
+}
+
+func TestSQLiteSink(t *testing.T) {
+	db, err := sql.Open("applogger-recording", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	sink, err := NewSQLiteSink(db, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	directoryPath := "./tmp"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: directoryPath + "/sqlite.ndjson", Sinks: []Sink{sink}}
+	logger.Initialise()
+	logger.LogHTTP("ERROR", "controller", "perform", "failed", 500, 1.25)
+
+	testDriver.mu.Lock()
+	defer testDriver.mu.Unlock()
+	if !strings.HasPrefix(strings.TrimSpace(testDriver.execs[0]), "CREATE TABLE IF NOT EXISTS logs") {
+		t.Fatalf("expected table creation, got %s", testDriver.execs[0])
+	}
+	last := testDriver.args[len(testDriver.args)-1]
+	if len(last) != 9 || last[2] != "ERROR" || last[3] != "controller" || last[6] != int64(500) {
+		t.Fatalf("unexpected insert arguments %v", last)
+	}
+}
+
+func TestSQLiteSinkAttributes(t *testing.T) {
+	db, err := sql.Open("applogger-recording", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	sink, err := NewSQLiteSink(db, "events", "user_id", "tenant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	directoryPath := "./tmp"
+	os.MkdirAll(directoryPath, os.ModePerm)
+	defer os.RemoveAll(directoryPath)
+
+	logger := AppLogger{Path: directoryPath + "/sqlite.ndjson", Sinks: []Sink{sink}}
+	logger.Initialise()
+	logger.LogFields("INFO", "billing", "charge", "charged", map[string]interface{}{"user_id": 42, "amount": 9.5})
+
+	testDriver.mu.Lock()
+	defer testDriver.mu.Unlock()
+	var index, insert bool
+	for _, q := range testDriver.execs {
+		index = index || strings.Contains(q, "INDEX IF NOT EXISTS events_user_id ON events (user_id, time)")
+		insert = insert || strings.HasPrefix(q, "INSERT INTO events (pid, time, level, package, func, message, code, duration, attributes, user_id, tenant)")
+	}
+	if !index || !insert {
+		t.Fatalf("expected the promoted columns to be indexed and inserted, got %q", testDriver.execs)
+	}
+	last := testDriver.args[len(testDriver.args)-1]
+	if len(last) != 11 || last[9] != int64(42) || last[10] != nil {
+		t.Fatalf("unexpected insert arguments %v", last)
+	}
+	var attributes map[string]interface{}
+	if s, _ := last[8].(string); json.Unmarshal([]byte(s), &attributes) != nil || attributes["amount"] != 9.5 || attributes["user_id"] != 42.0 {
+		t.Fatalf("unexpected attributes column %v", last[8])
+	}
+}
+
+func TestSQLiteSinkPromotedColumn(t *testing.T) {
+	db, _ := sql.Open("applogger-recording", "")
+	defer db.Close()
+	for _, key := range []string{"level", "user id", "a", "A"} {
+		if _, err := NewSQLiteSink(db, "logs", "a", key); err == nil {
+			t.Fatalf("expected error promoting %q", key)
+		}
+	}
+}
+
+func TestSQLiteSinkInvalidTable(t *testing.T) {
+	db, _ := sql.Open("applogger-recording", "")
+	defer db.Close()
+	if _, err := NewSQLiteSink(db, "logs; DROP TABLE x"); err == nil {
+		t.Fatal("expected error for an invalid table name")
+	}
+}
@@ -0,0 +1,169 @@
+package applogger
+
+import (
+	"fmt"
+	"hash/fnv"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attributes written by ErrorStack
+const (
+	StackKey    = "stack"
+	StackRefKey = "stack_ref"
+)
+
+// callerStack returns the stack of the goroutine logging an entry, without
+// the frames of the logger itself
+func callerStack() string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	inLogger := true
+	for {
+		frame, more := frames.Next()
+		if inLogger && strings.HasPrefix(frame.Function, "github.com/junkd0g/applogger.AppLogger.") {
+			if !more {
+				break
+			}
+			continue
+		}
+		inLogger = false
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// stackCache remembers the stacks written recently, so an error repeated
+// in a tight loop gets a reference to the first entry with its stack
+// instead of the same trace every time
+type stackCache struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	stacks map[uint64]stackSeen
+}
+
+type stackSeen struct {
+	pid  string
+	time time.Time
+}
+
+func newStackCache(window time.Duration) *stackCache {
+	return &stackCache{window: window, stacks: make(map[uint64]stackSeen)}
+}
+
+// ref returns the pid of the entry that carried stack within the window,
+// or records e as the one carrying it and returns ""
+func (c *stackCache) ref(e Entry, stack string) string {
+	h := fnv.New64a()
+	h.Write([]byte(stack))
+	key := h.Sum64()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if seen, ok := c.stacks[key]; ok && e.Time.Sub(seen.time) < c.window {
+		return seen.pid
+	}
+	if len(c.stacks) > 1024 {
+		for k, seen := range c.stacks {
+			if e.Time.Sub(seen.time) >= c.window {
+				delete(c.stacks, k)
+			}
+		}
+	}
+	c.stacks[key] = stackSeen{pid: e.PID, time: e.Time}
+	return ""
+}
+
+// stackAttr returns the stack attribute of an Error entry, or a
+// reference to an earlier entry with the same stack
+func (r AppLogger) stackAttr(e Entry) attr {
+	stack := callerStack()
+	if r.stacks != nil {
+		if pid := r.stacks.ref(e, stack); pid != "" {
+			return attr{StackRefKey, pid}
+		}
+	}
+	return attr{StackKey, stack}
+}
+
+// mainModule is the module path of the running program, its frames are
+// the ones emphasized by consoleStack
+var mainModule = sync.OnceValue(func() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Path
+	}
+	return ""
+})
+
+// consoleStack renders a stack written by ErrorStack as indented frames
+// below the console line. Frames of the main module are emphasized, those
+// of the standard library dimmed and runs of runtime frames collapsed into
+// a count. Anything that is not such a stack is indented as is.
+func consoleStack(b *strings.Builder, stack string, color bool) {
+	bold, dim, yellow, reset := "", "", "", ""
+	if color {
+		bold, dim, yellow, reset = colorBold, colorGray, colorYellow, colorReset
+	}
+	lines := strings.Split(strings.TrimRight(stack, "\n"), "\n")
+	if !isFrameList(lines) {
+		for _, line := range lines {
+			fmt.Fprintf(b, "\n    %s", strings.TrimSpace(line))
+		}
+		return
+	}
+
+	collapsed := 0
+	flush := func() {
+		if collapsed > 0 {
+			fmt.Fprintf(b, "\n    %s... %d runtime frames%s", dim, collapsed, reset)
+			collapsed = 0
+		}
+	}
+	for i := 0; i < len(lines); i += 2 {
+		function, location := lines[i], strings.TrimSpace(lines[i+1])
+		pkg, _ := splitFuncName(function)
+		if pkg == "runtime" || strings.HasPrefix(pkg, "runtime/") {
+			collapsed++
+			continue
+		}
+		flush()
+		where := dim + location + reset
+		if colon := strings.LastIndexByte(location, ':'); colon > 0 {
+			where = dim + location[:colon] + reset + ":" + yellow + location[colon+1:] + reset
+		}
+		module := mainModule()
+		switch {
+		case pkg == "main" || module != "" && (pkg == module || strings.HasPrefix(pkg, module+"/")):
+			fmt.Fprintf(b, "\n    at %s%s%s %s", bold, function, reset, where)
+		case !strings.Contains(strings.SplitN(pkg, "/", 2)[0], "."):
+			// standard library
+			fmt.Fprintf(b, "\n    %sat %s %s%s", dim, function, location, reset)
+		default:
+			fmt.Fprintf(b, "\n    at %s %s", function, where)
+		}
+	}
+	flush()
+}
+
+// isFrameList reports whether lines alternate between a function and its
+// tab indented location, the layout of callerStack
+func isFrameList(lines []string) bool {
+	if len(lines)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(lines); i += 2 {
+		if strings.HasPrefix(lines[i], "\t") || !strings.HasPrefix(lines[i+1], "\t") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package applogger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorStackDedup(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, ErrorStack: true, StackDedupWindow: time.Minute}
+	logger.Initialise()
+
+	for i := 0; i < 3; i++ {
+		logger.Log("ERROR", "main", "app", "query failed")
+	}
+	logger.Log("WARN", "main", "app", "no stack")
+
+	first := mem.entries[0].Attributes
+	stack, _ := first[StackKey].(string)
+	if !strings.HasPrefix(stack, "github.com/junkd0g/applogger.TestErrorStackDedup\n") {
+		t.Fatalf("stack should start at the caller:\n%s", stack)
+	}
+	for _, e := range mem.entries[1:3] {
+		if e.Attributes[StackRefKey] != mem.entries[0].PID || e.Attributes[StackKey] != nil {
+			t.Fatalf("expected a reference to the first stack, got %v", e.Attributes)
+		}
+	}
+	if len(mem.entries[3].Attributes) != 0 {
+		t.Fatalf("warn entry got a stack %v", mem.entries[3].Attributes)
+	}
+}
+
+func TestConsoleStack(t *testing.T) {
+	stack := "main.handle\n\t/src/app/main.go:42\n" +
+		"github.com/gorilla/mux.(*Router).ServeHTTP\n\t/go/pkg/mod/github.com/gorilla/mux/mux.go:210\n" +
+		"net/http.serverHandler.ServeHTTP\n\t/usr/local/go/src/net/http/server.go:2938\n" +
+		"runtime.goexit\n\t/usr/local/go/src/runtime/asm_amd64.s:1700\n" +
+		"runtime.main\n\t/usr/local/go/src/runtime/proc.go:271\n"
+	e := Entry{Level: "ERROR", Package: "main", Func: "handle", Message: "failed", attrs: []attr{{StackKey, stack}, {"user", 7}}}
+
+	got := consoleLine(e, false, nil)
+	want := " user=7\n" +
+		"    at main.handle /src/app/main.go:42\n" +
+		"    at github.com/gorilla/mux.(*Router).ServeHTTP /go/pkg/mod/github.com/gorilla/mux/mux.go:210\n" +
+		"    at net/http.serverHandler.ServeHTTP /usr/local/go/src/net/http/server.go:2938\n" +
+		"    ... 2 runtime frames"
+	if !strings.HasSuffix(got, want) {
+		t.Fatalf("unexpected console stack:\n%s", got)
+	}
+
+	colored := consoleLine(e, true, nil)
+	if !strings.Contains(colored, "at "+colorBold+"main.handle"+colorReset) || !strings.Contains(colored, colorGray+"at net/http.serverHandler.ServeHTTP") {
+		t.Fatalf("expected main frames emphasized and standard library frames dimmed:\n%q", colored)
+	}
+
+	e.attrs = []attr{{StackKey, "not a stack"}}
+	if got := consoleLine(e, false, nil); !strings.HasSuffix(got, "failed\n    not a stack") {
+		t.Fatalf("unexpected fallback %q", got)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package applogger
+
+import (
+	"errors"
+)
+
+// RedirectStderr is not supported where file descriptors cannot be
+// duplicated
+func (r AppLogger) RedirectStderr() (restore func() error, err error) {
+	return nil, errors.New("applogger: RedirectStderr is not supported on this platform")
+}
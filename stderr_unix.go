@@ -0,0 +1,61 @@
+//go:build unix
+
+package applogger
+
+import (
+	"bufio"
+	"os"
+	"syscall"
+	"time"
+)
+
+// RedirectStderr sends everything written to file descriptor 2, like
+// runtime panics, the standard log package and C code, to the logger as
+// ERROR entries, one per line, with package "stderr". os.Stderr is pointed
+// at the original stderr so the logger's own error reports cannot loop
+// back into it. The runtime's crash reports are also copied to the
+// original stderr, since a crashing process does not live long enough to
+// log them, unless SetCrashOutput sends them to a file already. restore
+// undoes the redirection and logs what is still in the pipe.
+func (r AppLogger) RedirectStderr() (restore func() error, err error) {
+	saved, err := syscall.Dup(2)
+	if err != nil {
+		return nil, err
+	}
+	original := os.NewFile(uintptr(saved), "/dev/stderr")
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		original.Close()
+		return nil, err
+	}
+	if err := dup2(int(pw.Fd()), 2); err != nil {
+		original.Close()
+		pr.Close()
+		pw.Close()
+		return nil, err
+	}
+	stderr := os.Stderr
+	os.Stderr = original
+	resetCrashOutput(original)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 64<<10), 1<<20)
+		for scanner.Scan() {
+			r.logInternal(Entry{PID: r.newID(), Level: "ERROR", Package: "stderr", Func: "", Message: scanner.Text(), Time: time.Now()}, nil)
+		}
+	}()
+
+	return func() error {
+		err := dup2(saved, 2)
+		os.Stderr = stderr
+		resetCrashOutput(nil)
+		pw.Close()
+		<-done
+		pr.Close()
+		original.Close()
+		return err
+	}, nil
+}
@@ -0,0 +1,80 @@
+//go:build unix
+
+package applogger
+
+import (
+	"log"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestRedirectStderr(t *testing.T) {
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}}
+	logger.Initialise()
+	defer logger.Close()
+
+	restore, err := logger.RedirectStderr()
+	if err != nil {
+		t.Fatalf("RedirectStderr failed: %v", err)
+	}
+	syscall.Write(2, []byte("raw write to fd 2\nsecond line\n"))
+	log.Print("from the log package")
+	if err := restore(); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	if len(mem.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", mem.entries)
+	}
+	for i, want := range []string{"raw write to fd 2", "second line", "from the log package"} {
+		e := mem.entries[i]
+		if !strings.HasSuffix(e.Message, want) || e.Level != "ERROR" || e.Package != "stderr" {
+			t.Fatalf("unexpected entry %+v", e)
+		}
+	}
+}
+
+func TestRedirectStderrKeepsCrashOutput(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	var outputs []string
+	runtimeCrashOutput = func(f *os.File, _ debug.CrashOptions) error {
+		name := "<nil>"
+		if f != nil {
+			name = f.Name()
+		}
+		outputs = append(outputs, name)
+		return nil
+	}
+	defer func() {
+		runtimeCrashOutput = debug.SetCrashOutput
+		crashOutput.file.Close()
+		crashOutput.file = nil
+	}()
+
+	logger := AppLogger{Path: "/dev/null"}
+	logger.Initialise()
+	defer logger.Close()
+
+	if err := SetCrashOutput("./tmp/runtime.crash"); err != nil {
+		t.Fatalf("SetCrashOutput failed: %v", err)
+	}
+	restore, err := logger.RedirectStderr()
+	if err != nil {
+		t.Fatalf("RedirectStderr failed: %v", err)
+	}
+	if err := restore(); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	for _, name := range outputs {
+		if name != "./tmp/runtime.crash" {
+			t.Fatalf("expected the crash file to stay the crash output, got %v", outputs)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package applogger
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Redacted replaces the value of fields tagged log:",redact"
+const Redacted = "[REDACTED]"
+
+// Fields returns the attributes of a struct, or pointer to one, for
+// LogFields and WithFields. Exported fields are named after their log tag,
+// or the field name when there is none, like encoding/json:
+//
+//	type SignupRequest struct {
+//		Email    string `log:"email"`
+//		Password string `log:"-"`
+//		Phone    string `log:"phone,redact"`
+//		Referrer string `log:"referrer,omitempty"`
+//	}
+//
+// "-" skips the field, redact writes Redacted instead of the value and
+// omitempty skips zero values. Embedded structs are flattened. Anything
+// else than a struct gives nil.
+func Fields(v interface{}) map[string]interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := make(map[string]interface{})
+	structFields(rv, fields)
+	return fields
+}
+
+func structFields(rv reflect.Value, fields map[string]interface{}) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("log")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+		if f.Anonymous && name == "" {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				structFields(fv, fields)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if hasTagOption(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+		if hasTagOption(opts, "redact") {
+			fields[name] = Redacted
+			continue
+		}
+		fields[name] = fv.Interface()
+	}
+}
+
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package applogger
+
+import (
+	"reflect"
+	"testing"
+)
+
+type auditInfo struct {
+	Actor string `log:"actor"`
+}
+
+type signupRequest struct {
+	auditInfo
+	Email    string `log:"email"`
+	Password string `log:"-"`
+	Phone    string `log:"phone,redact"`
+	Referrer string `log:"referrer,omitempty"`
+	Plan     string
+	internal string
+}
+
+func TestFields(t *testing.T) {
+	req := &signupRequest{auditInfo: auditInfo{Actor: "web"}, Email: "a@example.com", Password: "secret", Phone: "555", Plan: "pro", internal: "x"}
+	want := map[string]interface{}{"actor": "web", "email": "a@example.com", "phone": Redacted, "Plan": "pro"}
+	if got := Fields(req); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if Fields(42) != nil || Fields((*signupRequest)(nil)) != nil {
+		t.Fatal("expected nil for non structs")
+	}
+}
@@ -0,0 +1,190 @@
+package applogger
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultSummarySamples is the number of durations a LatencySummary keeps
+// per route and window when MaxSamples is not set
+const DefaultSummarySamples = 10000
+
+// LatencySummary collects the requests served by an HTTPMiddleware per
+// method and route and writes a summary entry for each of them at the end
+// of every window: the request count, p50_ms, p95_ms and p99_ms latencies
+// and the counts by status class (status_2xx, status_5xx, ...), the rate,
+// errors and duration of RED metrics from logs alone. A window is written
+// every Interval by a ticker that Wrap starts and Close stops, by the first
+// request after it ended when that comes first, or by FlushSummary.
+type LatencySummary struct {
+	// Interval is the length of a window, one minute when zero
+	Interval time.Duration
+	// MaxSamples bounds the durations kept per route and window, above it
+	// the percentiles come from a uniform sample of the requests,
+	// DefaultSummarySamples when zero
+	MaxSamples int
+
+	mu     sync.Mutex
+	now    func() time.Time
+	start  time.Time
+	routes map[string]*routeWindow
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// routeWindow holds the requests of one method and route in a window
+type routeWindow struct {
+	method, route string
+	count         int
+	samples       []float64
+	classes       [6]int
+}
+
+func (s *LatencySummary) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *LatencySummary) interval() time.Duration {
+	if s.Interval <= 0 {
+		return time.Minute
+	}
+	return s.Interval
+}
+
+// startTicker writes the current window every Interval until stopTicker,
+// only the first call starts the ticker
+func (s *LatencySummary) startTicker(logger AppLogger, pkg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.stop, s.done = stop, done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.interval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.flush(logger, pkg)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopTicker stops the ticker of startTicker, if it runs, and waits for it
+func (s *LatencySummary) stopTicker() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// record adds a request, writing the previous window first when it ended
+func (s *LatencySummary) record(logger AppLogger, pkg, method, route string, status int, d time.Duration) {
+	now := s.clock()
+	s.mu.Lock()
+	var done map[string]*routeWindow
+	var start time.Time
+	interval := s.interval()
+	if s.start.IsZero() {
+		s.start = now
+	} else if !now.Before(s.start.Add(interval)) {
+		done, start = s.routes, s.start
+		s.routes = nil
+		s.start = now
+	}
+	if s.routes == nil {
+		s.routes = make(map[string]*routeWindow)
+	}
+	key := method + " " + route
+	w := s.routes[key]
+	if w == nil {
+		w = &routeWindow{method: method, route: route}
+		s.routes[key] = w
+	}
+	w.add(status, d.Seconds()*1000, s.MaxSamples)
+	s.mu.Unlock()
+
+	writeSummary(logger, pkg, done, start, now)
+}
+
+// flush writes the current window
+func (s *LatencySummary) flush(logger AppLogger, pkg string) {
+	now := s.clock()
+	s.mu.Lock()
+	done, start := s.routes, s.start
+	s.routes, s.start = nil, time.Time{}
+	s.mu.Unlock()
+	writeSummary(logger, pkg, done, start, now)
+}
+
+func (w *routeWindow) add(status int, ms float64, max int) {
+	if max <= 0 {
+		max = DefaultSummarySamples
+	}
+	w.count++
+	if class := status / 100; class >= 1 && class <= 5 {
+		w.classes[class]++
+	}
+	if len(w.samples) < max {
+		w.samples = append(w.samples, ms)
+	} else if i := rand.Intn(w.count); i < max {
+		// reservoir sampling keeps every request equally likely
+		w.samples[i] = ms
+	}
+}
+
+// writeSummary writes an entry per route of a window, sorted by route
+func writeSummary(logger AppLogger, pkg string, routes map[string]*routeWindow, start, end time.Time) {
+	keys := make([]string, 0, len(routes))
+	for k := range routes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		w := routes[k]
+		sort.Float64s(w.samples)
+		attrs := []attr{
+			{"method", w.method}, {"route", w.route}, {"window_start", start}, {"window_s", end.Sub(start).Seconds()},
+			{"count", w.count}, {"p50_ms", percentile(w.samples, 50)}, {"p95_ms", percentile(w.samples, 95)}, {"p99_ms", percentile(w.samples, 99)},
+		}
+		for class := 1; class <= 5; class++ {
+			if w.classes[class] > 0 {
+				attrs = append(attrs, attr{fmt.Sprintf("status_%dxx", class), w.classes[class]})
+			}
+		}
+		level := LevelInfo
+		if w.classes[5] > 0 {
+			level = LevelWarn
+		}
+		e := Entry{PID: logger.newID(), Level: level.String(), Package: pkg, Func: "LatencySummary", Message: "summary " + k, Time: end}
+		logger.logInternal(e, attrs)
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted values
+func percentile(sorted []float64, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,152 @@
+package applogger
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// QuotaDroppedKey is the attribute counting the entries of a tenant dropped
+// over its quota since its previous entry that got through
+const QuotaDroppedKey = "_quota_dropped"
+
+// maxIdleTenants is the number of tenants with a bucket after which the
+// buckets of the tenants not seen for long enough to fill up again are let
+// go
+const maxIdleTenants = 10000
+
+// OtherTenantsKey is the tenant TenantDropped counts the drops of under
+// once maxTenantTotals tenants have their own count
+const OtherTenantsKey = "_other"
+
+// maxTenantTotals is the number of tenants TenantDropped counts apart
+const maxTenantTotals = 10000
+
+// tenantQuotas holds a token bucket per tenant refilled at rate entries a
+// second up to burst
+type tenantQuotas struct {
+	key   string
+	rate  float64
+	burst float64
+
+	dropped atomic.Uint64
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	// lru orders the buckets from the most to the least recently seen
+	lru *list.List
+	// totals counts the entries dropped per tenant, apart from the buckets
+	// so that evicting one does not lose them
+	totals map[string]uint64
+}
+
+type tenantBucket struct {
+	tenant string
+	tokens float64
+	// last is when the tenant was last seen
+	last time.Time
+	// dropped counts the entries dropped since the last one let through
+	dropped uint64
+}
+
+func newTenantQuotas(key string, rate float64, burst int) *tenantQuotas {
+	b := float64(burst)
+	if b <= 0 {
+		b = rate
+	}
+	if b < 1 {
+		b = 1
+	}
+	return &tenantQuotas{key: key, rate: rate, burst: b, buckets: map[string]*list.Element{}, lru: list.New(), totals: map[string]uint64{}}
+}
+
+// allow reports whether the entry may be written, taking a token from the
+// bucket of its tenant. Entries without a tenant and Error and Fatal
+// entries always go through. The first entry let through after some were
+// dropped gets their count under _quota_dropped.
+func (q *tenantQuotas) allow(e *Entry) bool {
+	v, ok := e.Lookup(q.key)
+	if !ok || levelOf(e.Level) >= LevelError {
+		return true
+	}
+	tenant := fmt.Sprint(v)
+
+	q.mu.Lock()
+	b := q.bucket(tenant, e.Time)
+	if elapsed := e.Time.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * q.rate
+		if b.tokens > q.burst {
+			b.tokens = q.burst
+		}
+		b.last = e.Time
+	}
+	if b.tokens < 1 {
+		b.dropped++
+		q.countDrop(tenant)
+		q.mu.Unlock()
+		q.dropped.Add(1)
+		return false
+	}
+	b.tokens--
+	dropped := b.dropped
+	b.dropped = 0
+	q.mu.Unlock()
+
+	if dropped > 0 {
+		e.attrs = append(e.attrs, attr{QuotaDroppedKey, dropped})
+	}
+	return true
+}
+
+// countDrop counts a dropped entry of tenant, under OtherTenantsKey when
+// too many tenants are counted already
+func (q *tenantQuotas) countDrop(tenant string) {
+	if _, ok := q.totals[tenant]; !ok && len(q.totals) >= maxTenantTotals {
+		tenant = OtherTenantsKey
+	}
+	q.totals[tenant]++
+}
+
+// bucket returns the bucket of tenant, making a full one for a new tenant
+func (q *tenantQuotas) bucket(tenant string, now time.Time) *tenantBucket {
+	if el, ok := q.buckets[tenant]; ok {
+		q.lru.MoveToFront(el)
+		return el.Value.(*tenantBucket)
+	}
+	if len(q.buckets) >= maxIdleTenants {
+		q.evictIdle(now)
+	}
+	b := &tenantBucket{tenant: tenant, tokens: q.burst, last: now}
+	q.buckets[tenant] = q.lru.PushFront(b)
+	return b
+}
+
+// evictIdle lets go of the least recently seen buckets as long as their
+// tenant was not seen since the bucket would have filled up again, a new
+// bucket for them is the same. Entries dropped and not yet reported under
+// _quota_dropped are forgotten with the bucket, TenantDropped still counts
+// them.
+func (q *tenantQuotas) evictIdle(now time.Time) {
+	refill := time.Duration(q.burst / q.rate * float64(time.Second))
+	for el := q.lru.Back(); el != nil; el = q.lru.Back() {
+		b := el.Value.(*tenantBucket)
+		if now.Sub(b.last) < refill {
+			return
+		}
+		q.lru.Remove(el)
+		delete(q.buckets, b.tenant)
+	}
+}
+
+// droppedByTenant returns the entries dropped per tenant so far
+func (q *tenantQuotas) droppedByTenant() map[string]uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	m := make(map[string]uint64, len(q.totals))
+	for tenant, n := range q.totals {
+		m[tenant] = n
+	}
+	return m
+}
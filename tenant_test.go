@@ -0,0 +1,101 @@
+package applogger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTenantQuota(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mem := &memorySink{}
+	logger := AppLogger{Path: "/dev/null", Sinks: []Sink{mem}, TenantKey: "tenant_id", TenantQuota: 2, Clock: func() time.Time { return now }}
+	logger.Initialise()
+	defer logger.Close()
+
+	noisy := logger.WithFields(map[string]interface{}{"tenant_id": "noisy"})
+	quiet := logger.WithFields(map[string]interface{}{"tenant_id": "quiet"})
+	for i := 0; i < 10; i++ {
+		noisy.Log("DEBUG", "main", "app", "chatter")
+	}
+	noisy.Log("ERROR", "main", "app", "failed")
+	quiet.Log("INFO", "main", "app", "hello")
+	logger.Log("INFO", "main", "app", "no tenant")
+
+	if len(mem.entries) != 5 {
+		t.Fatalf("expected 2 noisy, the error, quiet and untenanted entries, got %d", len(mem.entries))
+	}
+	if logger.Dropped() != 8 || logger.TenantDropped()["noisy"] != 8 || len(logger.TenantDropped()) != 1 {
+		t.Fatalf("unexpected drops %d %v", logger.Dropped(), logger.TenantDropped())
+	}
+
+	now = now.Add(time.Second)
+	noisy.Log("DEBUG", "main", "app", "again")
+	last := mem.entries[len(mem.entries)-1]
+	if n, _ := last.Lookup(QuotaDroppedKey); last.Message != "again" || n != uint64(8) {
+		t.Fatalf("expected the drop count on the next entry, got %+v", last)
+	}
+	noisy.Log("DEBUG", "main", "app", "once more")
+	if last := mem.entries[len(mem.entries)-1]; last.Message != "once more" {
+		t.Fatalf("expected the refilled bucket to let a second entry through, got %+v", last)
+	}
+	if _, ok := mem.entries[len(mem.entries)-1].Lookup(QuotaDroppedKey); ok {
+		t.Fatal("drop count repeated")
+	}
+}
+
+func TestTenantQuotaNeedsKey(t *testing.T) {
+	if err := (&AppLogger{Path: "/dev/null", TenantQuota: 10}).Open(); err == nil {
+		t.Fatal("expected an error without a TenantKey")
+	}
+}
+
+func TestTenantQuotaEvictsIdle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := newTenantQuotas("tenant_id", 1, 1)
+	entry := func(tenant string, at time.Time) *Entry {
+		return &Entry{Level: "INFO", Time: at, attrs: []attr{{"tenant_id", tenant}}}
+	}
+	q.allow(entry("noisy", now))
+	q.allow(entry("noisy", now))
+	q.allow(entry("recent", now.Add(2*time.Second)))
+
+	q.mu.Lock()
+	q.evictIdle(now.Add(2500 * time.Millisecond))
+	_, noisy := q.buckets["noisy"]
+	_, recent := q.buckets["recent"]
+	q.mu.Unlock()
+	if noisy || !recent {
+		t.Fatalf("expected only the idle tenant evicted, noisy kept %v, recent kept %v", noisy, recent)
+	}
+	if q.droppedByTenant()["noisy"] != 1 {
+		t.Fatalf("the drops of an evicted tenant were lost: %v", q.droppedByTenant())
+	}
+}
+
+func TestTenantQuotaBoundsTotals(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := newTenantQuotas("tenant_id", 1, 1)
+	for i := 0; i < maxTenantTotals+5; i++ {
+		tenant := fmt.Sprint("t", i)
+		q.allow(&Entry{Level: "INFO", Time: now, attrs: []attr{{"tenant_id", tenant}}})
+		q.allow(&Entry{Level: "INFO", Time: now, attrs: []attr{{"tenant_id", tenant}}})
+	}
+
+	totals := q.droppedByTenant()
+	if len(totals) != maxTenantTotals+1 || totals[OtherTenantsKey] != 5 {
+		t.Fatalf("expected %d tenants and 5 drops under %s, got %d and %d", maxTenantTotals+1, OtherTenantsKey, len(totals), totals[OtherTenantsKey])
+	}
+	if q.dropped.Load() != maxTenantTotals+5 {
+		t.Fatalf("expected every drop counted, got %d", q.dropped.Load())
+	}
+	if len(q.buckets) != q.lru.Len() || len(q.buckets) > maxIdleTenants+5 {
+		t.Fatalf("unexpected buckets %d with %d in the list", len(q.buckets), q.lru.Len())
+	}
+
+	// every bucket has filled up again, the next new tenant lets them go
+	q.allow(&Entry{Level: "INFO", Time: now.Add(time.Minute), attrs: []attr{{"tenant_id", "late"}}})
+	if len(q.buckets) != 1 || q.lru.Len() != 1 {
+		t.Fatalf("expected only the new tenant kept, got %d buckets", len(q.buckets))
+	}
+}
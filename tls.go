@@ -0,0 +1,62 @@
+package applogger
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSConfig configures the TLS connections of network sinks. CAFile adds a
+// PEM bundle of trusted roots to the system ones, CertFile and KeyFile are
+// the client certificate for mutual TLS, MinVersion is "1.2" (the default)
+// or "1.3". InsecureSkipVerify turns off certificate verification, for
+// tests only, and says so on stderr every time a sink uses it.
+type TLSConfig struct {
+	CAFile             string `json:"ca_file"`
+	CertFile           string `json:"cert_file"`
+	KeyFile            string `json:"key_file"`
+	MinVersion         string `json:"min_version"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+// Build returns the crypto/tls configuration
+func (c TLSConfig) Build() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch c.MinVersion {
+	case "", "1.2":
+	case "1.3":
+		cfg.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("applogger: unsupported TLS min_version %q", c.MinVersion)
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("applogger: no certificates found in %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.InsecureSkipVerify {
+		fmt.Fprintln(os.Stderr, "applogger: WARNING: TLS certificate verification is disabled (insecure_skip_verify), entries can be intercepted")
+		cfg.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
@@ -0,0 +1,101 @@
+package applogger
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// writeClientCert writes a self-signed client certificate and its key as
+// PEM files and returns the certificate
+func writeClientCert(t *testing.T, certFile, keyFile string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "applogger"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+func TestHTTPSinkMutualTLS(t *testing.T) {
+	os.MkdirAll("./tmp", os.ModePerm)
+	defer os.RemoveAll("./tmp")
+
+	clientCert := writeClientCert(t, "./tmp/client.pem", "./tmp/client.key")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	backend := &batchServer{}
+	srv := httptest.NewUnstartedServer(backend)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+	os.WriteFile("./tmp/ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600)
+
+	s, err := NewSink("http", map[string]interface{}{"url": srv.URL, "max_entries": float64(1), "tls": map[string]interface{}{
+		"ca_file":     "./tmp/ca.pem",
+		"cert_file":   "./tmp/client.pem",
+		"key_file":    "./tmp/client.key",
+		"min_version": "1.3",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write(Entry{Level: "INFO", Message: "over mtls"})
+	if err := s.Close(); err != nil {
+		t.Fatalf("mTLS write failed: %v", err)
+	}
+	if backend.len() != 1 {
+		t.Fatalf("expected the batch to arrive, got %d", backend.len())
+	}
+
+	noCert := &HTTPSink{URL: srv.URL, Batch: BatchConfig{MaxEntries: 1}, TLS: &TLSConfig{CAFile: "./tmp/ca.pem"}}
+	noCert.Write(Entry{Level: "INFO"})
+	if err := noCert.Close(); err == nil {
+		t.Fatal("expected the server to refuse a client without certificate")
+	}
+}
+
+func TestTLSConfigBuild(t *testing.T) {
+	if _, err := (TLSConfig{MinVersion: "1.0"}).Build(); err == nil {
+		t.Fatal("expected an error for TLS 1.0")
+	}
+	if _, err := (TLSConfig{CAFile: "./does/not/exist.pem"}).Build(); err == nil {
+		t.Fatal("expected an error for a missing CA file")
+	}
+	cfg, err := (TLSConfig{InsecureSkipVerify: true}).Build()
+	if err != nil || !cfg.InsecureSkipVerify || cfg.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("unexpected config %+v, %v", cfg, err)
+	}
+}
@@ -0,0 +1,185 @@
+package applogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Transform changes an entry decoded from the JSON object of an encoder.
+// Keys are paths with nested objects separated by dots, e.g.
+// "attributes.user_id".
+type Transform func(doc map[string]interface{})
+
+// TransformEncoder rewrites the JSON object written by Encoder (JSONEncoder
+// by default) with Transforms, in order, e.g. to keep the field names of a
+// legacy schema that saved queries depend on on a sink:
+//
+//	TransformEncoder{Transforms: []Transform{
+//		Move("message", "msg"),
+//		Move("time", "ts"),
+//		Synthesize("lvl", func(doc map[string]interface{}) interface{} {
+//			return strings.ToLower(fmt.Sprint(doc["level"]))
+//		}),
+//		Delete("level"),
+//	}}
+//
+// Keys of the rewritten object are sorted.
+type TransformEncoder struct {
+	Encoder    Encoder
+	Transforms []Transform
+}
+
+// Encode encodes the entry with Encoder and applies Transforms
+func (t TransformEncoder) Encode(e Entry) ([]byte, error) {
+	enc := t.Encoder
+	if enc == nil {
+		enc = JSONEncoder{}
+	}
+	line, err := enc.Encode(e)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("applogger: transform needs a JSON encoder: %w", err)
+	}
+	for _, transform := range t.Transforms {
+		transform(doc)
+	}
+	return json.Marshal(doc)
+}
+
+// Move moves the value at from to to, e.g. Move("message", "msg") or
+// Move("attributes.user_id", "user.id"). Nothing happens when from is
+// missing.
+func Move(from, to string) Transform {
+	return func(doc map[string]interface{}) {
+		if v, ok := lookupPath(doc, from); ok {
+			deletePath(doc, from)
+			setPath(doc, to, v)
+		}
+	}
+}
+
+// Copy copies the value at from to to, keeping it at from
+func Copy(from, to string) Transform {
+	return func(doc map[string]interface{}) {
+		if v, ok := lookupPath(doc, from); ok {
+			setPath(doc, to, v)
+		}
+	}
+}
+
+// Set sets key to a constant value, e.g. Set("schema_version", 1)
+func Set(key string, value interface{}) Transform {
+	return func(doc map[string]interface{}) {
+		setPath(doc, key, value)
+	}
+}
+
+// Synthesize sets key to a value computed from the entry, it is not set
+// when f returns nil
+func Synthesize(key string, f func(doc map[string]interface{}) interface{}) Transform {
+	return func(doc map[string]interface{}) {
+		if v := f(doc); v != nil {
+			setPath(doc, key, v)
+		}
+	}
+}
+
+// Delete removes key
+func Delete(key string) Transform {
+	return func(doc map[string]interface{}) {
+		deletePath(doc, key)
+	}
+}
+
+// lookupPath returns the value at the dotted path
+func lookupPath(doc map[string]interface{}, path string) (interface{}, bool) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := doc[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		doc = next
+	}
+	v, ok := doc[keys[len(keys)-1]]
+	return v, ok
+}
+
+// setPath sets the value at the dotted path, creating the objects on the
+// way and replacing values that are not objects
+func setPath(doc map[string]interface{}, path string, v interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := doc[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			doc[key] = next
+		}
+		doc = next
+	}
+	doc[keys[len(keys)-1]] = v
+}
+
+// deletePath removes the value at the dotted path and the objects it
+// leaves empty
+func deletePath(doc map[string]interface{}, path string) {
+	key, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		delete(doc, key)
+		return
+	}
+	next, ok := doc[key].(map[string]interface{})
+	if !ok {
+		return
+	}
+	deletePath(next, rest)
+	if len(next) == 0 {
+		delete(doc, key)
+	}
+}
+
+// transformsOption reads the "transforms" option of a sink, a list of
+// objects with an op of move, copy, set or delete:
+//
+//	[{"op": "move", "from": "message", "to": "msg"},
+//	 {"op": "set", "key": "schema_version", "value": 1}]
+func transformsOption(options map[string]interface{}) ([]Transform, error) {
+	raw, ok := options["transforms"]
+	if !ok {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("applogger: transforms must be a list")
+	}
+	var transforms []Transform
+	for i, item := range list {
+		spec, _ := item.(map[string]interface{})
+		op, _ := spec["op"].(string)
+		from, _ := spec["from"].(string)
+		to, _ := spec["to"].(string)
+		key, _ := spec["key"].(string)
+		var t Transform
+		switch {
+		case op == "move" && from != "" && to != "":
+			t = Move(from, to)
+		case op == "copy" && from != "" && to != "":
+			t = Copy(from, to)
+		case op == "set" && key != "":
+			t = Set(key, spec["value"])
+		case op == "delete" && key != "":
+			t = Delete(key)
+		default:
+			return nil, fmt.Errorf("applogger: transform %d: want a move or copy with from and to, or a set or delete with key", i)
+		}
+		transforms = append(transforms, t)
+	}
+	return transforms, nil
+}
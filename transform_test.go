@@ -0,0 +1,50 @@
+package applogger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransformEncoder(t *testing.T) {
+	enc := TransformEncoder{Transforms: []Transform{
+		Move("message", "msg"),
+		Move("time", "ts"),
+		Synthesize("lvl", func(doc map[string]interface{}) interface{} {
+			return strings.ToLower(fmt.Sprint(doc["level"]))
+		}),
+		Delete("level"),
+		Move("attributes.user_id", "uid"),
+		Copy("package", "origin.package"),
+		Set("schema", 1),
+	}}
+	e := Entry{PID: "1", Level: "WARN", Package: "main", Func: "run", Message: "slow", Time: time.Date(2020, 8, 23, 10, 0, 0, 0, time.UTC)}
+	e.Set("user_id", 42)
+	line, err := enc.Encode(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"func":"run","lvl":"warn","msg":"slow","origin":{"package":"main"},"package":"main","pid":"1","schema":1,"ts":"2020-08-23T10:00:00Z","uid":42}`
+	if string(line) != want {
+		t.Fatalf("got %s\nwant %s", line, want)
+	}
+}
+
+func TestTransformsOption(t *testing.T) {
+	enc, err := encoderOption(map[string]interface{}{"transforms": []interface{}{
+		map[string]interface{}{"op": "move", "from": "message", "to": "msg"},
+		map[string]interface{}{"op": "delete", "key": "pid"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := enc.Encode(Entry{PID: "1", Message: "hi"})
+	if err != nil || !strings.Contains(string(line), `"msg":"hi"`) || strings.Contains(string(line), `"pid"`) {
+		t.Fatalf("unexpected line %s %v", line, err)
+	}
+
+	if _, err := encoderOption(map[string]interface{}{"transforms": []interface{}{map[string]interface{}{"op": "move", "from": "a"}}}); err == nil {
+		t.Fatal("expected an error for a move without to")
+	}
+}